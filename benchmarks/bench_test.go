@@ -0,0 +1,123 @@
+//go:build cputext
+
+package benchmarks
+
+import "fmt"
+import "image"
+import "strings"
+import "testing"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ggfnt-fonts/jammy"
+import "github.com/tinne26/ggfnt-fonts/jumpy"
+import "github.com/tinne26/ptxt-examples/internal/pickers"
+
+const Scale = 2
+const CanvasWidth, CanvasHeight = 1024, 512
+const WrapWidth = 320
+
+var LabelText = "PRESS START (1 PLAYER)"
+var ParagraphText = strings.Repeat("The quick brown fox jumps over the lazy dog, 0123456789 times.\n", 8)
+var RewriteText = strings.Repeat("WE <3 PIXELS <3 <3 ", 12)
+var PickersText = strings.Repeat("BANANA SAYS HELLO, AAAA BBBB! ", 8)
+
+type benchText struct {
+	name string
+	text string
+	font func() *ggfnt.Font
+	rewriteRules bool
+	picker bool
+}
+
+var BenchTexts = []benchText{
+	{ name: "label", text: LabelText, font: jammy.Font },
+	{ name: "paragraph", text: ParagraphText, font: jammy.Font },
+	{ name: "rewrite", text: RewriteText, font: jammy.Font, rewriteRules: true },
+	{ name: "pickers", text: PickersText, font: jumpy.Font, picker: true },
+}
+
+var CacheSizes = []int{ 0, 192 }
+
+func BenchmarkDraw(b *testing.B) {
+	runBenchmarks(b, func(b *testing.B, renderer *ptxt.Renderer, _ func() *strand.Strand, text string, canvas *image.RGBA) {
+		renderer.Draw(canvas, text, 8, 8) // warm up glyph mask cache
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			renderer.Draw(canvas, text, 8, 8)
+		}
+	})
+}
+
+func BenchmarkMeasure(b *testing.B) {
+	runBenchmarks(b, func(b *testing.B, renderer *ptxt.Renderer, _ func() *strand.Strand, text string, _ *image.RGBA) {
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			renderer.Measure(text)
+		}
+	})
+}
+
+func BenchmarkDrawWithWrap(b *testing.B) {
+	runBenchmarks(b, func(b *testing.B, renderer *ptxt.Renderer, _ func() *strand.Strand, text string, canvas *image.RGBA) {
+		renderer.DrawWithWrap(canvas, text, 8, 8, WrapWidth)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			renderer.DrawWithWrap(canvas, text, 8, 8, WrapWidth)
+		}
+	})
+}
+
+func BenchmarkDrawFromBuffer(b *testing.B) {
+	runBenchmarks(b, func(b *testing.B, renderer *ptxt.Renderer, newStrand func() *strand.Strand, text string, canvas *image.RGBA) {
+		// the strand can't be used again after DrawFromBuffer (see the
+		// package comment), so each iteration gets a new one, untimed
+		renderer.Draw(canvas, text, 8, 8)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			renderer.SetStrand(newStrand())
+			renderer.Measure(text)
+			b.StartTimer()
+			renderer.Advanced().DrawFromBuffer(canvas, 8, 8)
+		}
+	})
+}
+
+// Runs the operation as a sub-benchmark for each text and cache size,
+// with a new renderer and canvas.
+func runBenchmarks(b *testing.B, op func(*testing.B, *ptxt.Renderer, func() *strand.Strand, string, *image.RGBA)) {
+	for _, text := range BenchTexts {
+		for _, cacheSize := range CacheSizes {
+			cacheName := "cache-off"
+			if cacheSize > 0 { cacheName = fmt.Sprintf("cache-%d", cacheSize) }
+			b.Run(text.name + "/" + cacheName, func(b *testing.B) {
+				newStrand := func() *strand.Strand {
+					return configureStrand(b, text.font(), cacheSize, text.rewriteRules, text.picker)
+				}
+				renderer := ptxt.NewRenderer()
+				renderer.SetStrand(newStrand())
+				renderer.SetAlign(ptxt.Top | ptxt.Left)
+				renderer.SetScale(Scale)
+				canvas := image.NewRGBA(image.Rect(0, 0, CanvasWidth, CanvasHeight))
+				b.ReportAllocs()
+				op(b, renderer, newStrand, text.text, canvas)
+			})
+		}
+	}
+}
+
+// ---- helpers ----
+
+func configureStrand(b *testing.B, font *ggfnt.Font, cacheSize int, rewriteRules, picker bool) *strand.Strand {
+	fontStrand, err := ptxt.NewStrand(font)
+	if err != nil { b.Fatal(err) }
+	fontStrand.Mapping().ConfigureCache(cacheSize)
+	if rewriteRules {
+		err = fontStrand.Mapping().AutoInitRewriteRules()
+		if err != nil { b.Fatal(err) }
+	}
+	if picker { fontStrand.GlyphPickers().Add(&pickers.AlternatePicker{}) }
+	return fontStrand
+}
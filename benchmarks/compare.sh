@@ -0,0 +1,41 @@
+#!/bin/sh
+# Runs the benchmarks and compares the results with benchstat.
+#
+# Usage:
+# > ./compare.sh save old.txt [go test flags]   (runs with -count 10 by default)
+# > ./compare.sh old.txt new.txt
+#
+# A typical workflow is saving a run, changing the ptxt version in
+# go.mod (or using a replace directive to a local ptxt checkout),
+# saving a second run and comparing both. benchstat is used from PATH
+# if available, or through "go run golang.org/x/perf/cmd/benchstat".
+set -e
+cd "$(dirname "$0")"
+
+usage() {
+	echo "Usage: ./compare.sh save results.txt [-bench regexp] [-count N] [-benchtime 1s]"
+	echo "       ./compare.sh old.txt new.txt"
+	exit 1
+}
+
+if [ "$1" = "save" ]; then
+	[ -n "$2" ] || usage
+	output="$2"
+	shift 2
+	count=10
+	case " $* " in
+		*" -count "*|*" -count="*|*" --count "*|*" --count="*) count="" ;;
+	esac
+	go test -tags cputext -run '^$' -bench . ${count:+-count $count} "$@" | tee "$output"
+	echo "Output: $output"
+	exit 0
+fi
+
+[ $# -eq 2 ] || usage
+[ -f "$1" ] || { echo "missing file $1"; exit 1; }
+[ -f "$2" ] || { echo "missing file $2"; exit 1; }
+if command -v benchstat > /dev/null 2>&1; then
+	benchstat "$1" "$2"
+else
+	go run golang.org/x/perf/cmd/benchstat@latest "$1" "$2"
+fi
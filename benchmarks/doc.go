@@ -0,0 +1,28 @@
+// Package benchmarks measures the main renderer operations on the cpu
+// path with standard Go benchmarks, so results can be saved and
+// compared with benchstat (see compare.sh):
+//   BenchmarkDraw/label/cache-off   41455   28382 ns/op   6000 B/op   1443 allocs/op
+//
+// Timings depend on the machine, but allocations should only change
+// with the ptxt version.
+//
+// Benchmarks are named Operation/text/cache-off or cache-192, where
+// the cache is the strand's mapping cache (ConfigureCache), which
+// matters for fonts with conditional mappings (jammy's digits depend
+// on the numeric-style and zero-disambiguation-mark settings). The
+// texts are:
+//  - label: a short UI label.
+//  - paragraph: a few hundred characters over multiple lines.
+//  - rewrite: text with many jammy rewrite rule matches ("<3" => ❤).
+//  - pickers: uppercase text with jumpy, which has multiple glyph
+//    variants per letter, using a glyph picker.
+//
+// DrawFromBuffer measures the draw after a Measure, the main use case
+// of the function. With the current ptxt version, the strand's rewrite
+// rule testers are left operating after DrawFromBuffer, and any later
+// operation with the same strand panics, so each iteration uses a new
+// strand (created and measured outside the timed section).
+//
+// Usage:
+// > go test -tags cputext -bench . [-bench Draw/label] [-count 10] [-benchtime 2s] > results.txt
+package benchmarks
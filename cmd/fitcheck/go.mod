@@ -0,0 +1,24 @@
+module github.com/tinne26/ptxt-examples/cmd/fitcheck
+
+go 1.22.2
+
+require (
+	github.com/tinne26/ptxt v0.0.0-20240705121025-08cffae613f3
+	github.com/tinne26/ptxt-examples/internal v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/ebitengine/purego v0.6.0 // indirect
+	github.com/hajimehoshi/ebiten/v2 v2.6.6 // indirect
+	github.com/jezek/xgb v1.1.0 // indirect
+	github.com/tinne26/ggfnt v0.0.0-20240705120847-d849d4c6e12d // indirect
+	github.com/tinne26/ggfnt-fonts/jammy v0.0.0-20240704182610-77ae6be48ecf // indirect
+	github.com/tinne26/ggfnt-fonts/jumpy v0.0.0-20240702174359-a662e6ba4b03 // indirect
+	golang.org/x/exp/shiny v0.0.0-20230817173708-d852ddb80c63 // indirect
+	golang.org/x/image v0.12.0 // indirect
+	golang.org/x/mobile v0.0.0-20230922142353-e2f452493d57 // indirect
+	golang.org/x/sync v0.3.0 // indirect
+	golang.org/x/sys v0.12.0 // indirect
+)
+
+replace github.com/tinne26/ptxt-examples/internal => ../../internal
@@ -0,0 +1,309 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "sort"
+import "image"
+import "image/png"
+import "image/draw"
+import "image/color"
+import "strings"
+import "strconv"
+import "path/filepath"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/strtable"
+
+// Checks that localized strings fit within the UI boxes where they
+// are displayed. Boxes are defined in a JSON manifest (see uiBox for
+// the format) with a width, max number of lines, font and scale, and
+// strings are read from JSON, CSV or PO string tables (see
+// strtable.Read() for the details of each format).
+//
+// Each string is wrapped to the box width with the renderer's
+// MeasureWithWrap(), and reported if it needs more lines than the box
+// allows or if it's still too wide (e.g. a single long word), with the
+// overflow in pixels. Strings with glyphs missing in the box font are
+// also reported. Rewrite rules are enabled and settings use their
+// default values.
+//
+// A contact sheet with all the failures is written as a PNG, showing
+// each box and the parts of the text that overflow it. The program
+// exits with status 1 if there are any failures, so it can be used in
+// CI.
+//
+// Usage:
+// > go run -tags cputext . --manifest boxes.json [--font jammy|font.ggfnt] [--sheet failures.png] table.csv es.json fr.po...
+
+const SheetPad = 8
+
+var BackColor = color.RGBA{ 32,  28,  44, 255} // dark purple
+var BoxColor  = color.RGBA{ 64,  60,  92, 255} // muted purple
+var WarnColor = color.RGBA{176,  48,  64, 255} // crimson
+var TextColor = color.RGBA{242, 240, 229, 255} // off-white
+var InfoColor = color.RGBA{156, 150, 186, 255} // lavender gray
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var manifestFlag = flag.String("manifest", "", "JSON file defining the UI boxes")
+var sheetFlag = flag.String("sheet", "fitcheck-failures.png", "output path for the contact sheet of failures (empty to skip it)")
+var localeFlag = flag.String("locale", "", "comma-separated list of locales to check; all by default")
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() == 0 || *manifestFlag == "" {
+		fmt.Print("Usage: go run -tags cputext . --manifest boxes.json [--font jammy|font.ggfnt] [--sheet failures.png] [--locale es,fr] table.csv es.json fr.po...\n")
+		os.Exit(1)
+	}
+
+	// read manifest and string tables
+	boxes, err := readManifest(*manifestFlag, *fontName)
+	if err != nil { log.Fatal(err) }
+	var strs []strtable.String
+	for _, path := range flag.Args() {
+		tableStrs, err := strtable.Read(path)
+		if err != nil { log.Fatalf("%s: %s", path, err) }
+		strs = append(strs, tableStrs...)
+	}
+	if *localeFlag != "" {
+		locales := strings.Split(*localeFlag, ",")
+		strs = filterStrings(strs, func(str strtable.String) bool {
+			for _, locale := range locales {
+				if strings.TrimSpace(locale) == str.Locale { return true }
+			}
+			return false
+		})
+	}
+	sort.SliceStable(strs, func(i, j int) bool {
+		if strs[i].Locale != strs[j].Locale { return strs[i].Locale < strs[j].Locale }
+		return strs[i].Key < strs[j].Key
+	})
+	if len(strs) == 0 { log.Fatal("no translated strings found") }
+
+	// check each string against its box
+	checker := newFitChecker()
+	var failures []*fitResult
+	var numChecked, numUnboxed int
+	for _, str := range strs {
+		box := findBox(boxes, str.Key)
+		if box == nil {
+			numUnboxed += 1
+			continue
+		}
+		numChecked += 1
+		result, err := checker.Check(str, box)
+		if err != nil { log.Fatal(err) }
+		if result.Fits() { continue }
+		failures = append(failures, result)
+		fmt.Printf("  %s %s: %s\n", str.Locale, str.Key, result.Description())
+	}
+
+	// report
+	if numUnboxed > 0 {
+		fmt.Printf("Note: %d strings without a matching box in the manifest were skipped.\n", numUnboxed)
+	}
+	fmt.Printf("Failures: %d of %d strings\n", len(failures), numChecked)
+	if len(failures) == 0 { return }
+	if *sheetFlag != "" {
+		labelRenderer, err := newRenderer(*fontName, 1) // not shared with the checker, as it's recolored
+		if err != nil { log.Fatal(err) }
+		writeSheet(*sheetFlag, failures, labelRenderer)
+	}
+	os.Exit(1)
+}
+
+// ---- checks ----
+
+// Measures strings with one renderer per font and scale.
+type fitChecker struct {
+	renderers map[string]*ptxt.Renderer
+}
+
+func newFitChecker() *fitChecker {
+	return &fitChecker{ renderers: make(map[string]*ptxt.Renderer) }
+}
+
+func (self *fitChecker) Renderer(fontName string, scale int) (*ptxt.Renderer, error) {
+	key := fontName + "@" + strconv.Itoa(scale)
+	renderer, found := self.renderers[key]
+	if found { return renderer, nil }
+	renderer, err := newRenderer(fontName, scale)
+	if err != nil { return nil, err }
+	self.renderers[key] = renderer
+	return renderer, nil
+}
+
+// Creates a renderer for the given font and scale, with rewrite rules
+// enabled, top-left align and the text color.
+func newRenderer(fontName string, scale int) (*ptxt.Renderer, error) {
+	font, err := fontreg.Resolve(fontName)
+	if err != nil { return nil, err }
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { return nil, err }
+	err = strand.Mapping().AutoInitRewriteRules()
+	if err != nil { return nil, err }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	renderer.SetScale(uint8(scale))
+	renderer.SetAlign(ptxt.Left | ptxt.Top)
+	renderer.SetColor(TextColor)
+	return renderer, nil
+}
+
+// The layout of a string within its box.
+type fitResult struct {
+	str strtable.String
+	box *uiBox
+	renderer *ptxt.Renderer
+	missing []rune // code points without glyphs; nothing else is set if any
+	width, height int // wrapped text
+	lines int
+	maxHeight int // height of box.MaxLines lines
+}
+
+func (self *fitChecker) Check(str strtable.String, box *uiBox) (*fitResult, error) {
+	renderer, err := self.Renderer(box.Font, box.Scale)
+	if err != nil { return nil, err }
+	result := &fitResult{ str: str, box: box, renderer: renderer }
+
+	// ptxt panics on missing glyphs, so check them first
+	var sample rune = -1
+	for _, codePoint := range str.Text {
+		if codePoint == '\n' { continue }
+		if !renderer.Advanced().IsRuneAvailable(codePoint) {
+			if !containsRune(result.missing, codePoint) { result.missing = append(result.missing, codePoint) }
+		} else if sample == -1 {
+			sample = codePoint
+		}
+	}
+	if len(result.missing) > 0 || sample == -1 { return result, nil }
+
+	// measure the wrapped text, and compare to the height of the
+	// same number of lines with a single glyph on each line
+	result.width, result.height = renderer.MeasureWithWrap(str.Text, box.Width)
+	oneLineHeight := linesHeight(renderer, sample, 1)
+	lineAdvance := linesHeight(renderer, sample, 2) - oneLineHeight
+	result.lines = 1
+	if lineAdvance > 0 {
+		result.lines += max(0, result.height - oneLineHeight + lineAdvance - 1)/lineAdvance
+	}
+	result.maxHeight = oneLineHeight + (box.MaxLines - 1)*lineAdvance
+	return result, nil
+}
+
+func (self *fitResult) Fits() bool {
+	return len(self.missing) == 0 && self.WidthOverflow() <= 0 && self.HeightOverflow() <= 0
+}
+
+func (self *fitResult) WidthOverflow() int { return self.width - self.box.Width }
+func (self *fitResult) HeightOverflow() int { return self.height - self.maxHeight }
+
+func (self *fitResult) Description() string {
+	if len(self.missing) > 0 {
+		codePoints := make([]string, 0, len(self.missing))
+		for _, codePoint := range self.missing {
+			codePoints = append(codePoints, fmt.Sprintf("U+%04X %s", codePoint, strconv.QuoteRune(codePoint)))
+		}
+		return fmt.Sprintf("can't be rendered with %s, missing %s", self.box.Font, strings.Join(codePoints, ", "))
+	}
+
+	var issues []string
+	if self.HeightOverflow() > 0 {
+		issues = append(issues, fmt.Sprintf("%d lines in a %d line box (+%dpx height)", self.lines, self.box.MaxLines, self.HeightOverflow()))
+	}
+	if self.WidthOverflow() > 0 {
+		issues = append(issues, fmt.Sprintf("%dpx wide in a %dpx box (+%dpx width)", self.width, self.box.Width, self.WidthOverflow()))
+	}
+	return strings.Join(issues, ", ")
+}
+
+// ---- contact sheet ----
+
+// Writes a PNG with one cell per failure: a label with the locale,
+// key and overflow, and the wrapped text drawn over its box, with the
+// overflowing area highlighted. Strings with missing glyphs can't be
+// drawn, so only their label is included.
+func writeSheet(path string, failures []*fitResult, labelRenderer *ptxt.Renderer) {
+	type sheetCell struct {
+		result *fitResult
+		label string
+		labelHeight int
+		width, height int
+	}
+	var cells []sheetCell
+	var sheetWidth, sheetHeight int
+	for _, result := range failures {
+		label := result.str.Locale + " " + result.str.Key + ": " + result.Description()
+		label = strings.Map(func(codePoint rune) rune {
+			if labelRenderer.Advanced().IsRuneAvailable(codePoint) { return codePoint }
+			return '?'
+		}, label)
+		labelWidth, labelHeight := labelRenderer.Measure(label)
+		cell := sheetCell{ result: result, label: label, labelHeight: labelHeight }
+		cell.width, cell.height = labelWidth, labelHeight
+		if len(result.missing) == 0 {
+			cell.width = max(cell.width, result.box.Width, result.width)
+			cell.height += SheetPad/2 + max(result.maxHeight, result.height)
+		}
+		cells = append(cells, cell)
+		sheetWidth = max(sheetWidth, cell.width + SheetPad*2)
+		sheetHeight += cell.height + SheetPad
+	}
+	sheetHeight += SheetPad
+
+	canvas := image.NewRGBA(image.Rect(0, 0, sheetWidth, sheetHeight))
+	fillRect(canvas, canvas.Bounds(), BackColor)
+	labelRenderer.SetColor(InfoColor)
+	y := SheetPad
+	for _, cell := range cells {
+		labelRenderer.Draw(canvas, cell.label, SheetPad, y)
+		result := cell.result
+		if len(result.missing) == 0 {
+			boxY := y + cell.labelHeight + SheetPad/2
+			fillRect(canvas, image.Rect(SheetPad, boxY, SheetPad + result.width, boxY + result.height), WarnColor)
+			fillRect(canvas, image.Rect(SheetPad, boxY, SheetPad + result.box.Width, boxY + result.maxHeight), BoxColor)
+			result.renderer.DrawWithWrap(canvas, result.str.Text, SheetPad, boxY, result.box.Width)
+		}
+		y += cell.height + SheetPad
+	}
+
+	file, err := os.Create(path)
+	if err != nil { log.Fatal(err) }
+	defer file.Close()
+	err = png.Encode(file, canvas)
+	if err != nil { log.Fatal(err) }
+	absPath, err := filepath.Abs(path)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output: %s\n", absPath)
+}
+
+// ---- helpers ----
+
+// Height of the given number of lines, each with a single glyph.
+func linesHeight(renderer *ptxt.Renderer, sample rune, numLines int) int {
+	line := string(sample)
+	_, height := renderer.Measure(strings.Repeat(line + "\n", numLines - 1) + line)
+	return height
+}
+
+func filterStrings(strs []strtable.String, keep func(strtable.String) bool) []strtable.String {
+	var kept []strtable.String
+	for _, str := range strs {
+		if keep(str) { kept = append(kept, str) }
+	}
+	return kept
+}
+
+func containsRune(runes []rune, codePoint rune) bool {
+	for _, r := range runes {
+		if r == codePoint { return true }
+	}
+	return false
+}
+
+func fillRect(canvas *image.RGBA, rect image.Rectangle, rgba color.RGBA) {
+	draw.Draw(canvas, rect, image.NewUniform(rgba), image.Point{}, draw.Src)
+}
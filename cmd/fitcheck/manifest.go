@@ -0,0 +1,57 @@
+package main
+
+import "os"
+import "fmt"
+import "path"
+import "encoding/json"
+
+// A UI box where localized strings have to fit. Example manifest:
+//	{
+//		"boxes": [
+//			{ "key": "menu.*", "width": 64, "maxLines": 1 },
+//			{ "key": "dialog.intro", "width": 120, "maxLines": 3, "font": "jumpy", "scale": 2 }
+//		]
+//	}
+//
+// Keys can use path.Match() patterns ("menu.*"), and each string is
+// checked against the first box that matches its key. Widths are in
+// final pixels, after scaling. Boxes without a font use --font, and
+// boxes without a scale use 1.
+type uiBox struct {
+	Key string `json:"key"`
+	Width int `json:"width"`
+	MaxLines int `json:"maxLines"`
+	Font string `json:"font"`
+	Scale int `json:"scale"`
+}
+
+func readManifest(filename string, defaultFont string) ([]uiBox, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil { return nil, err }
+	var manifest struct { Boxes []uiBox `json:"boxes"` }
+	err = json.Unmarshal(data, &manifest)
+	if err != nil { return nil, fmt.Errorf("%s: %w", filename, err) }
+	if len(manifest.Boxes) == 0 { return nil, fmt.Errorf("%s: no boxes defined", filename) }
+
+	for i := range manifest.Boxes {
+		box := &manifest.Boxes[i]
+		_, err := path.Match(box.Key, "")
+		if err != nil { return nil, fmt.Errorf("%s: box %d: invalid key pattern %q", filename, i, box.Key) }
+		if box.Key == "" { return nil, fmt.Errorf("%s: box %d: missing key", filename, i) }
+		if box.Width <= 0 { return nil, fmt.Errorf("%s: box %q: width must be positive", filename, box.Key) }
+		if box.MaxLines <= 0 { return nil, fmt.Errorf("%s: box %q: maxLines must be positive", filename, box.Key) }
+		if box.Scale == 0 { box.Scale = 1 }
+		if box.Scale < 0 || box.Scale > 255 { return nil, fmt.Errorf("%s: box %q: invalid scale %d", filename, box.Key, box.Scale) }
+		if box.Font == "" { box.Font = defaultFont }
+	}
+	return manifest.Boxes, nil
+}
+
+// Returns the first box matching the given key, or nil if none.
+func findBox(boxes []uiBox, key string) *uiBox {
+	for i := range boxes {
+		matched, _ := path.Match(boxes[i].Key, key) // patterns validated on readManifest()
+		if matched { return &boxes[i] }
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import "sync"
+import "container/list"
+
+// A least recently used cache of rendered PNGs, bounded both by the
+// number of entries and their total size in bytes. Safe for concurrent
+// use.
+type pngCache struct {
+	mutex sync.Mutex
+	maxEntries int
+	maxBytes int
+	numBytes int
+	order *list.List // front is the most recently used
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key string
+	png []byte
+}
+
+func newPNGCache(maxEntries, maxBytes int) *pngCache {
+	return &pngCache{
+		maxEntries: maxEntries,
+		maxBytes: maxBytes,
+		order: list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (self *pngCache) Get(key string) ([]byte, bool) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	element, found := self.entries[key]
+	if !found { return nil, false }
+	self.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).png, true
+}
+
+// Adds the PNG to the cache, evicting the least recently used entries
+// if necessary. PNGs bigger than the whole cache are not stored.
+func (self *pngCache) Add(key string, png []byte) {
+	if self.maxEntries <= 0 || len(png) > self.maxBytes { return }
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	element, found := self.entries[key]
+	if found { // concurrent misses for the same key
+		self.order.MoveToFront(element)
+		return
+	}
+
+	self.entries[key] = self.order.PushFront(&cacheEntry{ key, png })
+	self.numBytes += len(png)
+	for len(self.entries) > self.maxEntries || self.numBytes > self.maxBytes {
+		oldest := self.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		self.order.Remove(oldest)
+		delete(self.entries, entry.key)
+		self.numBytes -= len(entry.png)
+	}
+}
+
+// Returns the number of entries and their total size in bytes.
+func (self *pngCache) Stats() (entries, bytes int) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return len(self.entries), self.numBytes
+}
@@ -0,0 +1,139 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "time"
+import "net/http"
+import "strconv"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// HTTP service that renders text with ptxt (cpu path) and returns PNGs,
+// so tools that don't link Go (like web based level editors) can show
+// previews of in-game text:
+//   GET /render?font=jammy&text=HELLO&scale=4&fg=ff7477&bg=171219&align=center&wrap=200&pad=4
+//
+// All parameters except text are optional. Colors are hex RGB or RGBA
+// (transparent background by default), align is left, center or right,
+// and wrap is the max line length in pixels. Only registered fonts can
+// be used (see --font-dir), never arbitrary paths.
+//
+// Rendered PNGs are kept in a LRU cache keyed by the canonical form of
+// the parameters, bounded by entries and total size. Each request gets
+// its own renderer from a per-font pool, and text length, scale and
+// output size are limited. The tests run the service on a local test
+// server and check responses, caching, eviction and concurrency.
+//
+// Usage:
+// > go run -tags cputext . [--addr localhost:8080] [--font jammy] [--font-dir fonts/]
+// > go test -tags cputext .
+
+var fontName = fontreg.Flag(fontreg.Default) // default font for requests, and --font-dir
+var addrFlag = flag.String("addr", "localhost:8080", "address to listen on")
+var cacheEntriesFlag = flag.Int("cache-entries", 256, "max number of cached PNGs (0 disables the cache)")
+var cacheMBFlag = flag.Int("cache-mb", 32, "max total size of cached PNGs, in MiB")
+var maxTextFlag = flag.Int("max-text", 2048, "max text length, in bytes")
+var maxScaleFlag = flag.Int("max-scale", 16, "max text scale")
+var maxPixelsFlag = flag.Int("max-pixels", 4_000_000, "max output image size, in pixels")
+
+func main() {
+	// parse flags
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run -tags cputext . [--addr localhost:8080] [--font jammy] [--font-dir fonts/]\n")
+		os.Exit(1)
+	}
+
+	// load all registered fonts
+	if !fontreg.IsRegistered(*fontName) { log.Fatalf("--font must be a registered font (%q is not)", *fontName) }
+	fonts, err := loadFonts()
+	if err != nil { log.Fatal(err) }
+	lims := limits{ MaxTextBytes: *maxTextFlag, MaxScale: min(*maxScaleFlag, 255), MaxPixels: *maxPixelsFlag }
+	srv, err := newServer(fonts, *fontName, newPNGCache(*cacheEntriesFlag, *cacheMBFlag << 20), lims)
+	if err != nil { log.Fatal(err) }
+
+	// serve
+	httpServer := &http.Server{
+		Addr: *addrFlag,
+		Handler: srv.Handler(),
+		ReadHeaderTimeout: 5*time.Second,
+		ReadTimeout: 10*time.Second,
+		WriteTimeout: 30*time.Second,
+		MaxHeaderBytes: 16 << 10,
+	}
+	fmt.Printf("Fonts loaded: %d (default: %s)\n", len(fonts), *fontName)
+	fmt.Printf("Listening on http://%s/render?text=HELLO\n", *addrFlag)
+	log.Fatal(httpServer.ListenAndServe())
+}
+
+type server struct {
+	fonts *fontSet
+	defaultFont string
+	cache *pngCache
+	lims limits
+}
+
+func newServer(fonts map[string]*ggfnt.Font, defaultFont string, cache *pngCache, lims limits) (*server, error) {
+	set, err := newFontSet(fonts)
+	if err != nil { return nil, err }
+	return &server{ fonts: set, defaultFont: defaultFont, cache: cache, lims: lims }, nil
+}
+
+func (self *server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/render", self.serveRender)
+	return mux
+}
+
+func (self *server) serveRender(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// (text can be percent-encoded, so allow some extra room)
+	if len(r.URL.RawQuery) > self.lims.MaxTextBytes*3 + 512 {
+		http.Error(w, "query too long", http.StatusRequestURITooLong)
+		return
+	}
+
+	params, reqErr := parseParams(r.URL.Query(), self.defaultFont, self.fonts, self.lims)
+	if reqErr != nil {
+		http.Error(w, reqErr.msg, reqErr.status)
+		return
+	}
+
+	key := params.Key()
+	data, cached := self.cache.Get(key)
+	if !cached {
+		data, reqErr = self.fonts.Render(params, self.lims)
+		if reqErr != nil {
+			http.Error(w, reqErr.msg, reqErr.status)
+			return
+		}
+		self.cache.Add(key, data)
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "image/png")
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	header.Set("Cache-Control", "public, max-age=3600")
+	if cached { header.Set("X-Cache", "HIT") } else { header.Set("X-Cache", "MISS") }
+	if r.Method == http.MethodHead { return }
+	_, _ = w.Write(data)
+}
+
+// ---- helpers ----
+
+func loadFonts() (map[string]*ggfnt.Font, error) {
+	fonts := make(map[string]*ggfnt.Font)
+	for _, name := range fontreg.Names() {
+		font, err := fontreg.Resolve(name)
+		if err != nil { return nil, fmt.Errorf("font %q: %w", name, err) }
+		fonts[name] = font
+	}
+	return fonts, nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import "fmt"
+import "sync"
+import "bytes"
+import "image"
+import "image/png"
+import "image/color"
+import "net/url"
+import "strconv"
+import "strings"
+import "unicode/utf8"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+
+// Request size limits.
+type limits struct {
+	MaxTextBytes int
+	MaxScale int
+	MaxPixels int // width*height of the output image
+}
+
+// Validated render parameters. The canonical form of the parameters
+// is used as the cache key, so equivalent requests share entries.
+type renderParams struct {
+	font string
+	text string
+	scale int
+	fg, bg color.RGBA
+	align ptxt.Align // horizontal component only
+	wrap int // max line length in pixels, 0 to disable wrapping
+	pad int
+}
+
+// An error with the HTTP status code to return.
+type requestError struct {
+	status int
+	msg string
+}
+
+func (self *requestError) Error() string { return self.msg }
+
+func badRequest(format string, args ...any) *requestError {
+	return &requestError{ 400, fmt.Sprintf(format, args...) }
+}
+
+func parseParams(query url.Values, defaultFont string, fonts *fontSet, lims limits) (renderParams, *requestError) {
+	params := renderParams{ font: query.Get("font"), text: query.Get("text") }
+	if params.font == "" { params.font = defaultFont }
+	params.font = strings.ToLower(params.font)
+	entry, found := fonts.entries[params.font]
+	if !found { return params, &requestError{ 404, fmt.Sprintf("unknown font %q", params.font) } }
+
+	// text
+	if params.text == "" { return params, badRequest("missing text") }
+	if len(params.text) > lims.MaxTextBytes {
+		return params, &requestError{ 413, fmt.Sprintf("text exceeds %d bytes", lims.MaxTextBytes) }
+	}
+	if !utf8.ValidString(params.text) { return params, badRequest("text is not valid UTF-8") }
+	params.text = strings.ReplaceAll(params.text, "\r\n", "\n")
+	for _, codePoint := range params.text {
+		if codePoint == '\n' { continue }
+		_, found := entry.font.Mapping().Utf8(codePoint, entry.settings)
+		if !found { return params, badRequest("font %q can't map U+%04X %s", params.font, codePoint, strconv.QuoteRune(codePoint)) }
+	}
+
+	// numeric parameters
+	var err *requestError
+	params.scale, err = parseInt(query, "scale", 1, 1, lims.MaxScale)
+	if err != nil { return params, err }
+	params.wrap, err = parseInt(query, "wrap", 0, 0, 1 << 16)
+	if err != nil { return params, err }
+	params.pad, err = parseInt(query, "pad", 0, 0, 256)
+	if err != nil { return params, err }
+
+	// colors and align
+	params.fg, err = parseColor(query, "fg", color.RGBA{255, 255, 255, 255})
+	if err != nil { return params, err }
+	params.bg, err = parseColor(query, "bg", color.RGBA{})
+	if err != nil { return params, err }
+	switch strings.ToLower(query.Get("align")) {
+	case "", "left" : params.align = ptxt.Left
+	case "center"   : params.align = ptxt.HorzCenter
+	case "right"    : params.align = ptxt.Right
+	default:
+		return params, badRequest("invalid align %q (expected left, center or right)", query.Get("align"))
+	}
+	return params, nil
+}
+
+// Returns the canonical form of the parameters.
+func (self *renderParams) Key() string {
+	values := url.Values{}
+	values.Set("font", self.font)
+	values.Set("text", self.text)
+	values.Set("scale", strconv.Itoa(self.scale))
+	values.Set("fg", hexColor(self.fg))
+	values.Set("bg", hexColor(self.bg))
+	values.Set("align", self.align.String())
+	values.Set("wrap", strconv.Itoa(self.wrap))
+	values.Set("pad", strconv.Itoa(self.pad))
+	return values.Encode() // sorted by key
+}
+
+// ---- rendering ----
+
+// The fonts available to the server, each with a pool of renderers.
+// Renderers and strands keep internal buffers and caches, so each
+// request needs its own.
+type fontSet struct {
+	entries map[string]*fontEntry
+}
+
+type fontEntry struct {
+	font *ggfnt.Font
+	settings []uint8 // default settings, read-only
+	pool *sync.Pool
+}
+
+func newFontSet(fonts map[string]*ggfnt.Font) (*fontSet, error) {
+	set := &fontSet{ entries: make(map[string]*fontEntry, len(fonts)) }
+	for name, font := range fonts {
+		strand, err := ptxt.NewStrand(font) // validate once so New can't fail
+		if err != nil { return nil, fmt.Errorf("font %q: %w", name, err) }
+		settings := append([]uint8(nil), strand.UnderlyingSettingsCache().UnsafeSlice()...)
+		set.entries[name] = &fontEntry{
+			font: font,
+			settings: settings,
+			pool: &sync.Pool{
+				New: func() any {
+					strand, err := ptxt.NewStrand(font)
+					if err != nil { panic(err) }
+					renderer := ptxt.NewRenderer()
+					renderer.SetStrand(strand)
+					return renderer
+				},
+			},
+		}
+	}
+	return set, nil
+}
+
+// Renders the text and returns it encoded as a PNG.
+func (self *fontSet) Render(params renderParams, lims limits) ([]byte, *requestError) {
+	pool := self.entries[params.font].pool
+	renderer := pool.Get().(*ptxt.Renderer)
+	defer pool.Put(renderer)
+	renderer.SetScale(uint8(params.scale))
+	renderer.SetColor(params.fg)
+	renderer.SetAlign(ptxt.Top | params.align)
+
+	// measure and check size limits
+	wrap := params.wrap
+	if wrap == 0 { wrap = 1 << 30 }
+	width, height := renderer.MeasureWithWrap(params.text, wrap)
+	width, height = width + params.pad*2, height + params.pad*2
+	if width*height > lims.MaxPixels {
+		return nil, &requestError{ 413, fmt.Sprintf("output image would be %dx%d, exceeding the %d pixels limit", width, height, lims.MaxPixels) }
+	}
+	width, height = max(width, 1), max(height, 1)
+
+	// draw and encode
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(canvas, params.bg)
+	x := params.align.GetHorzAnchor(params.pad, width - params.pad)
+	renderer.DrawWithWrap(canvas, params.text, x, params.pad, wrap)
+	var buffer bytes.Buffer
+	err := png.Encode(&buffer, canvas)
+	if err != nil { return nil, &requestError{ 500, err.Error() } }
+	return buffer.Bytes(), nil
+}
+
+// ---- helpers ----
+
+func parseInt(query url.Values, name string, defaultValue, minValue, maxValue int) (int, *requestError) {
+	str := query.Get(name)
+	if str == "" { return defaultValue, nil }
+	value, err := strconv.Atoi(str)
+	if err != nil { return 0, badRequest("invalid %s %q", name, str) }
+	if value < minValue || value > maxValue {
+		return 0, badRequest("%s must be between %d and %d", name, minValue, maxValue)
+	}
+	return value, nil
+}
+
+// Parses "RRGGBB" or "RRGGBBAA" colors, with an optional leading '#'.
+// The result uses premultiplied alpha, as expected by ptxt.
+func parseColor(query url.Values, name string, defaultColor color.RGBA) (color.RGBA, *requestError) {
+	hex := strings.TrimPrefix(query.Get(name), "#")
+	if hex == "" { return defaultColor, nil }
+	if len(hex) == 6 { hex += "ff" }
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 { return color.RGBA{}, badRequest("invalid %s color %q", name, query.Get(name)) }
+	rgba := color.RGBA{ uint8(value >> 24), uint8(value >> 16), uint8(value >> 8), uint8(value) }
+	rgba.R = uint8(uint32(rgba.R)*uint32(rgba.A)/255)
+	rgba.G = uint8(uint32(rgba.G)*uint32(rgba.A)/255)
+	rgba.B = uint8(uint32(rgba.B)*uint32(rgba.A)/255)
+	return rgba, nil
+}
+
+func hexColor(rgba color.RGBA) string {
+	return fmt.Sprintf("%02x%02x%02x%02x", rgba.R, rgba.G, rgba.B, rgba.A)
+}
+
+func fill(canvas *image.RGBA, rgba color.RGBA) {
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		canvas.Pix[i + 0] = rgba.R
+		canvas.Pix[i + 1] = rgba.G
+		canvas.Pix[i + 2] = rgba.B
+		canvas.Pix[i + 3] = rgba.A
+	}
+}
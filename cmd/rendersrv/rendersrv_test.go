@@ -0,0 +1,169 @@
+//go:build cputext
+
+package main
+
+import "io"
+import "sync"
+import "bytes"
+import "image"
+import "image/png"
+import "net/url"
+import "net/http"
+import "net/http/httptest"
+import "strings"
+import "testing"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+
+// Run with:
+// > go test -tags cputext .
+
+const HelloQuery = "text=HELLO&scale=2&fg=ff0000&bg=000000&pad=3"
+
+var testLimits = limits{ MaxTextBytes: 64, MaxScale: 8, MaxPixels: 40_000 }
+
+func TestRender(t *testing.T) {
+	fonts, baseURL, _ := startServer(t, 3)
+	status, header, data := get(t, baseURL, "/render?" + HelloQuery)
+	if status != 200 { t.Fatalf("basic render status %d", status) }
+	if header.Get("X-Cache") != "MISS" { t.Errorf("first request should miss the cache") }
+	if header.Get("Content-Type") != "image/png" { t.Errorf("content type %q", header.Get("Content-Type")) }
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil { t.Fatalf("PNG decoding failed: %s", err) }
+	width, height := measure(t, fonts["jammy"], "HELLO", 2)
+	want := image.Rect(0, 0, width + 6, height + 6)
+	if img.Bounds() != want { t.Errorf("image bounds %v, expected %v", img.Bounds(), want) }
+	if countColor(img, 0xffff, 0, 0) == 0 { t.Errorf("no text pixels found") }
+	if countColor(img, 0, 0, 0) == 0 { t.Errorf("no background pixels found") }
+}
+
+func TestCacheHits(t *testing.T) {
+	_, baseURL, _ := startServer(t, 3)
+	_, _, data := get(t, baseURL, "/render?" + HelloQuery)
+	_, header, again := get(t, baseURL, "/render?" + HelloQuery)
+	if header.Get("X-Cache") != "HIT" { t.Errorf("repeated request should hit the cache") }
+	if !bytes.Equal(data, again) { t.Errorf("cached PNG differs from the original") }
+	_, header, _ = get(t, baseURL, "/render?pad=3&bg=%23000000FF&fg=FF0000&scale=2&text=HELLO&font=JAMMY&align=left")
+	if header.Get("X-Cache") != "HIT" { t.Errorf("equivalent request should hit the cache") }
+}
+
+func TestErrors(t *testing.T) {
+	_, baseURL, _ := startServer(t, 3)
+	var errorCases = []struct{ query string; status int }{
+		{ "", 400 }, // missing text
+		{ "text=A&font=nope", 404 },
+		{ "text=A&font=../../etc/passwd", 404 },
+		{ "text=A&scale=0", 400 },
+		{ "text=A&scale=9", 400 },
+		{ "text=A&scale=x", 400 },
+		{ "text=A&fg=12345", 400 },
+		{ "text=A&align=middle", 400 },
+		{ "text=" + strings.Repeat("A", 65), 413 },
+		{ "text=" + strings.Repeat("A", 60) + "&scale=8", 413 },
+		{ "text=" + url.QueryEscape("✓"), 400 }, // not mapped by jammy
+		{ "text=%ff", 400 }, // invalid UTF-8
+		{ "text=" + strings.Repeat("%41", 300), 414 },
+	}
+	for _, errorCase := range errorCases {
+		status, _, _ := get(t, baseURL, "/render?" + errorCase.query)
+		if status != errorCase.status {
+			t.Errorf("query %q: status %d, expected %d", errorCase.query, status, errorCase.status)
+		}
+	}
+
+	response, err := http.Post(baseURL + "/render?text=A", "text/plain", nil)
+	if err != nil { t.Fatal(err) }
+	response.Body.Close()
+	if response.StatusCode != 405 { t.Errorf("POST status %d, expected 405", response.StatusCode) }
+}
+
+func TestEviction(t *testing.T) {
+	_, baseURL, srv := startServer(t, 3)
+	for _, query := range []string{ HelloQuery, "text=B", "text=C", HelloQuery, "text=D" } {
+		get(t, baseURL, "/render?" + query)
+	}
+	_, header, _ := get(t, baseURL, "/render?text=B")
+	if header.Get("X-Cache") != "MISS" { t.Errorf("least recently used entry should have been evicted") }
+	_, header, _ = get(t, baseURL, "/render?" + HelloQuery)
+	if header.Get("X-Cache") != "HIT" { t.Errorf("recently used entry shouldn't have been evicted") }
+	entries, _ := srv.cache.Stats()
+	if entries != 3 { t.Errorf("cache has %d entries, expected 3", entries) }
+}
+
+// Concurrent rendering without cache must match sequential rendering.
+func TestConcurrent(t *testing.T) {
+	_, baseURL, _ := startServer(t, 0)
+	queries := []string{
+		"text=HELLO&scale=3", "text=WORLD&scale=1&fg=00ff00", "text=A+B%0AC+D&align=right",
+		"text=JUMP&font=jumpy&scale=2", "text=ptxt+rocks&wrap=30", "text=123&align=center&pad=5",
+	}
+	expected := make(map[string][]byte)
+	for _, query := range queries {
+		status, _, data := get(t, baseURL, "/render?" + query)
+		if status != 200 { t.Fatalf("query %q: status %d", query, status) }
+		expected[query] = data
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 96; i++ {
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+			status, _, data := get(t, baseURL, "/render?" + query)
+			if status != 200 || !bytes.Equal(data, expected[query]) {
+				t.Errorf("concurrent %q differs from sequential render", query)
+			}
+		}(queries[i % len(queries)])
+	}
+	wg.Wait()
+}
+
+// ---- helpers ----
+
+// Starts the service on a local test server with small limits and the
+// given cache size, closed at the end of the test.
+func startServer(t *testing.T, cacheEntries int) (map[string]*ggfnt.Font, string, *server) {
+	t.Helper()
+	fonts, err := loadFonts()
+	if err != nil { t.Fatal(err) }
+	srv, err := newServer(fonts, "jammy", newPNGCache(cacheEntries, 1 << 20), testLimits)
+	if err != nil { t.Fatal(err) }
+	httpServer := httptest.NewServer(srv.Handler())
+	t.Cleanup(httpServer.Close)
+	return fonts, httpServer.URL, srv
+}
+
+// Safe to call from multiple goroutines, as it doesn't use t.Fatal.
+func get(t *testing.T, baseURL, path string) (int, http.Header, []byte) {
+	response, err := http.Get(baseURL + path)
+	if err != nil {
+		t.Errorf("GET %s: %s", path, err)
+		return 0, http.Header{}, nil
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil { t.Errorf("GET %s: %s", path, err) }
+	return response.StatusCode, response.Header, data
+}
+
+func measure(t *testing.T, font *ggfnt.Font, text string, scale int) (int, int) {
+	t.Helper()
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { t.Fatal(err) }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	renderer.SetScale(uint8(scale))
+	return renderer.Measure(text)
+}
+
+func countColor(img image.Image, r, g, b uint32) int {
+	var count int
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pr, pg, pb, _ := img.At(x, y).RGBA()
+			if pr == r && pg == g && pb == b { count += 1 }
+		}
+	}
+	return count
+}
@@ -0,0 +1,184 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "image"
+import "image/png"
+import "image/color"
+import "path/filepath"
+import "strings"
+import "strconv"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// Exports text rendered with ptxt as an SVG, for print materials and
+// other assets that need to scale. Glyph masks are collected along the
+// same layout the renderer uses (through a custom draw function), and
+// the pixels are merged into traced contour paths (the default, one
+// path per opacity level) or rectangles, so the output stays small.
+//
+// With --png, the text is also rendered normally to the given PNG. The
+// tests export a set of texts, fonts, scales and aligns and compare the
+// rasterized SVGs with the PNGs drawn by ptxt.
+//
+// Usage:
+// > go run -tags cputext . [--font jammy|font.ggfnt] [--scale 4] [--fg 000000] [--bg ffffff] [--align center] [--shape paths|rects] [--out text.svg] [--png text.png] "Hello\nworld"
+// > go test -tags cputext .
+//
+// "\n" in arguments is interpreted as a line break.
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var scaleFlag = flag.Int("scale", 4, "text scale (SVG units per font pixel)")
+var fgFlag = flag.String("fg", "000000", "text color, as hex RGB or RGBA")
+var bgFlag = flag.String("bg", "", "background color, as hex RGB or RGBA (empty for transparent)")
+var alignFlag = flag.String("align", "left", "horizontal align for multiple lines: left, center or right")
+var shapeFlag = flag.String("shape", "paths", "shapes for the glyph pixels: paths or rects")
+var padFlag = flag.Int("pad", 0, "padding around the text, in pixels")
+var outFlag = flag.String("out", "ptxt_text.svg", "output SVG path")
+var pngFlag = flag.String("png", "", "also render the text to the given PNG path")
+
+func main() {
+	// parse flags
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Print("Usage: go run -tags cputext . [--font jammy|font.ggfnt] [--scale N] [--fg RRGGBB] [--bg RRGGBB] [--align left|center|right] [--shape paths|rects] [--out text.svg] [--png text.png] text...\n")
+		os.Exit(1)
+	}
+	text := strings.ReplaceAll(strings.Join(flag.Args(), " "), `\n`, "\n")
+	opts, err := parseOptions()
+	if err != nil { log.Fatal(err) }
+
+	// parse font and create strand
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
+	for _, codePoint := range text {
+		if codePoint == '\n' { continue }
+		settings := strand.UnderlyingSettingsCache().UnsafeSlice()
+		_, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { log.Fatalf("font can't map U+%04X %s", codePoint, strconv.QuoteRune(codePoint)) }
+	}
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+
+	// export svg and png
+	svg, grid := exportSVG(renderer, text, opts)
+	writeOutput(*outFlag, []byte(svg))
+	fmt.Printf("SVG size: %d bytes (%dx%d)\n", len(svg), grid.width, grid.height)
+	if *pngFlag != "" {
+		file, err := os.Create(*pngFlag)
+		if err != nil { log.Fatal(err) }
+		err = png.Encode(file, renderPNG(renderer, text, opts))
+		if err != nil { log.Fatal(err) }
+		err = file.Close()
+		if err != nil { log.Fatal(err) }
+		printOutput(*pngFlag)
+	}
+}
+
+// Export options.
+type options struct {
+	scale int
+	fg color.NRGBA
+	bg *color.NRGBA // nil for transparent
+	align ptxt.Align
+	shape Shape
+	pad int
+}
+
+func parseOptions() (options, error) {
+	opts := options{ scale: *scaleFlag, pad: *padFlag }
+	if opts.scale < 1 || opts.scale > 255 { return opts, fmt.Errorf("invalid --scale %d", opts.scale) }
+	if opts.pad < 0 { return opts, fmt.Errorf("invalid --pad %d", opts.pad) }
+	var err error
+	opts.fg, err = parseColor(*fgFlag)
+	if err != nil { return opts, err }
+	if *bgFlag != "" {
+		bg, err := parseColor(*bgFlag)
+		if err != nil { return opts, err }
+		opts.bg = &bg
+	}
+	switch strings.ToLower(*alignFlag) {
+	case "left"   : opts.align = ptxt.Left
+	case "center" : opts.align = ptxt.HorzCenter
+	case "right"  : opts.align = ptxt.Right
+	default:
+		return opts, fmt.Errorf("invalid --align %q (expected left, center or right)", *alignFlag)
+	}
+	switch strings.ToLower(*shapeFlag) {
+	case "paths" : opts.shape = ShapePaths
+	case "rects" : opts.shape = ShapeRects
+	default:
+		return opts, fmt.Errorf("invalid --shape %q (expected paths or rects)", *shapeFlag)
+	}
+	return opts, nil
+}
+
+// Configures the renderer and returns the image size and the drawing
+// coordinates for the text.
+func prepareLayout(renderer *ptxt.Renderer, text string, opts options) (width, height, x, y int) {
+	renderer.SetScale(uint8(opts.scale))
+	renderer.SetColor(premultiply(opts.fg))
+	renderer.SetAlign(ptxt.Top | opts.align)
+	width, height = renderer.Measure(text)
+	width, height = max(width + opts.pad*2, 1), max(height + opts.pad*2, 1)
+	x = opts.align.GetHorzAnchor(opts.pad, width - opts.pad)
+	return width, height, x, opts.pad
+}
+
+func exportSVG(renderer *ptxt.Renderer, text string, opts options) (string, *pixelGrid) {
+	width, height, x, y := prepareLayout(renderer, text, opts)
+	grid := collectPixels(renderer, text, x, y, width, height)
+	var svg strings.Builder
+	writeSVG(&svg, grid, opts.shape, opts.fg, opts.bg)
+	return svg.String(), grid
+}
+
+func renderPNG(renderer *ptxt.Renderer, text string, opts options) *image.RGBA {
+	width, height, x, y := prepareLayout(renderer, text, opts)
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	if opts.bg != nil { fill(canvas, premultiply(*opts.bg)) }
+	renderer.Draw(canvas, text, x, y)
+	return canvas
+}
+
+// ---- helpers ----
+
+// Parses "RRGGBB" or "RRGGBBAA" colors, with an optional leading '#'.
+func parseColor(hex string) (color.NRGBA, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 6 { hex += "ff" }
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 { return color.NRGBA{}, fmt.Errorf("invalid color %q", hex) }
+	return color.NRGBA{ uint8(value >> 24), uint8(value >> 16), uint8(value >> 8), uint8(value) }, nil
+}
+
+func premultiply(nrgba color.NRGBA) color.RGBA {
+	return color.RGBAModel.Convert(nrgba).(color.RGBA)
+}
+
+func fill(canvas *image.RGBA, rgba color.RGBA) {
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		canvas.Pix[i + 0] = rgba.R
+		canvas.Pix[i + 1] = rgba.G
+		canvas.Pix[i + 2] = rgba.B
+		canvas.Pix[i + 3] = rgba.A
+	}
+}
+
+func writeOutput(path string, data []byte) {
+	err := os.WriteFile(path, data, 0644)
+	if err != nil { log.Fatal(err) }
+	printOutput(path)
+}
+
+func printOutput(path string) {
+	absPath, err := filepath.Abs(path)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output: %s\n", absPath)
+}
@@ -0,0 +1,201 @@
+package main
+
+import "fmt"
+import "math"
+import "image"
+import "image/color"
+import "strings"
+import "strconv"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/core"
+
+// Shapes used to represent the glyph pixels.
+type Shape uint8
+const (
+	ShapePaths Shape = iota // one traced contour path per opacity level
+	ShapeRects // merged rectangles
+)
+
+// Alpha values of the glyph pixels drawn by the renderer, at the
+// output resolution.
+type pixelGrid struct {
+	width, height int
+	alpha []uint8
+}
+
+// Draws the text with the renderer, but instead of rasterizing the
+// glyphs, collects their pixels from the glyph masks at the positions
+// the renderer would draw them. Only horizontal text is supported.
+func collectPixels(renderer *ptxt.Renderer, text string, x, y, width, height int) *pixelGrid {
+	grid := &pixelGrid{ width: width, height: height, alpha: make([]uint8, width*height) }
+	renderer.Advanced().SetDrawFunc(func(_ core.Target, glyphIndex ggfnt.GlyphIndex, params ptxt.MaskDrawParameters) {
+		mask := renderer.Advanced().LoadMask(glyphIndex)
+		if mask == nil { return }
+		bounds := mask.Bounds()
+		for sy := bounds.Min.Y; sy < bounds.Max.Y; sy++ {
+			for sx := bounds.Min.X; sx < bounds.Max.X; sx++ {
+				alpha := mask.AlphaAt(sx, sy).A
+				if alpha == 0 { continue }
+				grid.fill(params.X + sx*params.Scale, params.Y + sy*params.Scale, params.Scale, alpha)
+			}
+		}
+	})
+	renderer.Draw(image.NewRGBA(image.Rect(0, 0, 1, 1)), text, x, y)
+	renderer.Advanced().SetDrawFunc(nil)
+	return grid
+}
+
+// Fills a size x size square, keeping the highest alpha where glyphs
+// overlap.
+func (self *pixelGrid) fill(x, y, size int, alpha uint8) {
+	for py := max(y, 0); py < min(y + size, self.height); py++ {
+		for px := max(x, 0); px < min(x + size, self.width); px++ {
+			index := py*self.width + px
+			self.alpha[index] = max(self.alpha[index], alpha)
+		}
+	}
+}
+
+// Returns the distinct alpha values in the grid, in increasing order.
+func (self *pixelGrid) Levels() []uint8 {
+	var used [256]bool
+	for _, alpha := range self.alpha { used[alpha] = true }
+	var levels []uint8
+	for alpha := 1; alpha < 256; alpha++ {
+		if used[alpha] { levels = append(levels, uint8(alpha)) }
+	}
+	return levels
+}
+
+func (self *pixelGrid) Is(x, y int, alpha uint8) bool {
+	if x < 0 || y < 0 || x >= self.width || y >= self.height { return false }
+	return self.alpha[y*self.width + x] == alpha
+}
+
+// ---- svg writing ----
+
+// Writes the SVG document for the grid. Colors are not premultiplied.
+func writeSVG(out *strings.Builder, grid *pixelGrid, shape Shape, fg color.NRGBA, bg *color.NRGBA) {
+	fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">` + "\n",
+		grid.width, grid.height, grid.width, grid.height)
+	if bg != nil {
+		fmt.Fprintf(out, `<rect width="%d" height="%d" fill="%s"%s/>` + "\n", grid.width, grid.height, hexRGB(*bg), opacityAttr(bg.A))
+	}
+	for _, alpha := range grid.Levels() {
+		opacity := opacityAttr(uint8(uint32(fg.A)*uint32(alpha)/255))
+		switch shape {
+		case ShapePaths:
+			fmt.Fprintf(out, `<path fill="%s"%s d="%s"/>` + "\n", hexRGB(fg), opacity, tracePath(grid, alpha))
+		case ShapeRects:
+			fmt.Fprintf(out, `<g fill="%s"%s>` + "\n", hexRGB(fg), opacity)
+			for _, rect := range mergeRects(grid, alpha) {
+				fmt.Fprintf(out, `<rect x="%d" y="%d" width="%d" height="%d"/>` + "\n", rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+			}
+			out.WriteString("</g>\n")
+		default:
+			panic("invalid shape")
+		}
+	}
+	out.WriteString("</svg>\n")
+}
+
+// Merges the pixels with the given alpha into rectangles: horizontal
+// runs on each row, extended downwards while the next rows have runs
+// with the exact same span.
+func mergeRects(grid *pixelGrid, alpha uint8) []image.Rectangle {
+	var rects []image.Rectangle
+	open := make(map[[2]int]int) // run span => index in rects
+	for y := 0; y < grid.height; y++ {
+		nextOpen := make(map[[2]int]int)
+		for x := 0; x < grid.width; x++ {
+			if !grid.Is(x, y, alpha) { continue }
+			start := x
+			for x < grid.width && grid.Is(x, y, alpha) { x += 1 }
+			span := [2]int{ start, x }
+			index, found := open[span]
+			if found {
+				rects[index].Max.Y = y + 1
+			} else {
+				index = len(rects)
+				rects = append(rects, image.Rect(start, y, x, y + 1))
+			}
+			nextOpen[span] = index
+		}
+		open = nextOpen
+	}
+	return rects
+}
+
+// Traces the contours of the pixels with the given alpha. Outer
+// contours go clockwise and holes counter-clockwise (in screen
+// coordinates), so the path can be filled with the default nonzero
+// rule. Only corners are kept, so all segments alternate between
+// horizontal and vertical.
+func tracePath(grid *pixelGrid, alpha uint8) string {
+	// collect boundary edges, each going clockwise around its pixel
+	type point struct{ x, y int }
+	outgoing := make(map[point][]point)
+	var starts []point
+	var addEdge = func(from, to point) {
+		if len(outgoing[from]) == 0 { starts = append(starts, from) }
+		outgoing[from] = append(outgoing[from], to)
+	}
+	for y := 0; y < grid.height; y++ {
+		for x := 0; x < grid.width; x++ {
+			if !grid.Is(x, y, alpha) { continue }
+			if !grid.Is(x, y - 1, alpha) { addEdge(point{x, y}, point{x + 1, y}) }
+			if !grid.Is(x + 1, y, alpha) { addEdge(point{x + 1, y}, point{x + 1, y + 1}) }
+			if !grid.Is(x, y + 1, alpha) { addEdge(point{x + 1, y + 1}, point{x, y + 1}) }
+			if !grid.Is(x - 1, y, alpha) { addEdge(point{x, y + 1}, point{x, y}) }
+		}
+	}
+
+	// chain edges into closed loops
+	var path strings.Builder
+	for _, start := range starts {
+		for len(outgoing[start]) > 0 {
+			loop := []point{ start }
+			current := start
+			for {
+				nexts := outgoing[current]
+				next := nexts[len(nexts) - 1]
+				outgoing[current] = nexts[ : len(nexts) - 1]
+				if next == start { break }
+				loop = append(loop, next)
+				current = next
+			}
+
+			// keep corners only
+			var corners []point
+			for i, pt := range loop {
+				prev, next := loop[(i + len(loop) - 1) % len(loop)], loop[(i + 1) % len(loop)]
+				if (prev.x == pt.x && pt.x == next.x) || (prev.y == pt.y && pt.y == next.y) { continue }
+				corners = append(corners, pt)
+			}
+			fmt.Fprintf(&path, "M%d %d", corners[0].x, corners[0].y)
+			for i := 1; i < len(corners); i++ {
+				if corners[i].x != corners[i - 1].x {
+					fmt.Fprintf(&path, "H%d", corners[i].x)
+				} else {
+					fmt.Fprintf(&path, "V%d", corners[i].y)
+				}
+			}
+			path.WriteString("Z")
+		}
+	}
+	return path.String()
+}
+
+// ---- helpers ----
+
+func hexRGB(rgba color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+func opacityAttr(alpha uint8) string {
+	if alpha == 255 { return "" }
+	opacity := math.Round(float64(alpha)/255.0*10000)/10000
+	return fmt.Sprintf(` fill-opacity="%s"`, strconv.FormatFloat(opacity, 'f', -1, 64))
+}
@@ -0,0 +1,403 @@
+//go:build cputext
+
+package main
+
+import "fmt"
+import "sort"
+import "image"
+import "image/color"
+import "strings"
+import "strconv"
+import "testing"
+import "encoding/xml"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// Run with:
+// > go test -tags cputext .
+//
+// Exported SVGs are rasterized with a minimal rasterizer for the SVG
+// subset written by this program and compared with the PNGs drawn by
+// ptxt. As the rasterizer is also written here, the exact output for
+// small grids and the rasterizer itself are tested separately, and
+// wrong SVGs must fail the comparison.
+
+var black, white = color.NRGBA{0, 0, 0, 255}, color.NRGBA{255, 255, 255, 255}
+var translucent = color.NRGBA{255, 116, 119, 160}
+
+func TestRasterizedMatchesPNG(t *testing.T) {
+	cases := []struct {
+		font string
+		text string
+		opts options
+	}{
+		{ "jammy", "HELLO WORLD", options{ scale: 1, fg: black } },
+		{ "jammy", "Hello, world!\nptxt -> SVG", options{ scale: 4, fg: black, bg: &white, pad: 3 } },
+		{ "jammy", "right\naligned\ntext", options{ scale: 3, fg: translucent, bg: &white, align: ptxt.Right } },
+		{ "jammy", "centered\nlines", options{ scale: 2, fg: white, align: ptxt.HorzCenter, pad: 1 } },
+		{ "jammy", "0O@#%&8B", options{ scale: 5, fg: black, bg: &translucent } },
+		{ "jumpy", "GAME OVER\nPRESS START", options{ scale: 2, fg: translucent, align: ptxt.HorzCenter } },
+	}
+	for _, testCase := range cases {
+		renderer := newRenderer(t, testCase.font)
+		expected := renderPNG(renderer, testCase.text, testCase.opts)
+		for _, shape := range []Shape{ ShapePaths, ShapeRects } {
+			opts := testCase.opts
+			opts.shape = shape
+			svg, _ := exportSVG(renderer, testCase.text, opts)
+			got, err := rasterizeSVG(svg)
+			if err != nil {
+				t.Errorf("%s %q (%s): %s", testCase.font, testCase.text, shapeName(shape), err)
+				continue
+			}
+			diff := compareImages(got, expected)
+			if diff != "" { t.Errorf("%s %q (%s): %s", testCase.font, testCase.text, shapeName(shape), diff) }
+		}
+	}
+}
+
+// Exact output for small grids, worked out by hand: a ring (outer
+// contour clockwise, hole counter-clockwise), two pixels touching at
+// a corner (a single self-touching contour) and two opacity levels.
+func TestKnownOutput(t *testing.T) {
+	const Header = `<svg xmlns="http://www.w3.org/2000/svg" width="3" height="3" viewBox="0 0 3 3" shape-rendering="crispEdges">` + "\n"
+	cases := []struct {
+		name string
+		rows []string // '#' is alpha 255, '+' is alpha 128
+		shape Shape
+		fg color.NRGBA
+		bg *color.NRGBA
+		svg string
+	}{
+		{
+			"ring paths", []string{ "###", "#.#", "###" }, ShapePaths, black, nil,
+			Header + `<path fill="#000000" d="M0 0H3V3H0ZM2 1H1V2H2Z"/>` + "\n</svg>\n",
+		},
+		{
+			"ring rects", []string{ "###", "#.#", "###" }, ShapeRects, black, nil,
+			Header + `<g fill="#000000">` + "\n" +
+				`<rect x="0" y="0" width="3" height="1"/>` + "\n" +
+				`<rect x="0" y="1" width="1" height="1"/>` + "\n" +
+				`<rect x="2" y="1" width="1" height="1"/>` + "\n" +
+				`<rect x="0" y="2" width="3" height="1"/>` + "\n" +
+				"</g>\n</svg>\n",
+		},
+		{
+			"corner paths", []string{ "#..", ".#.", "..." }, ShapePaths, black, nil,
+			Header + `<path fill="#000000" d="M0 0H1V1H2V2H1V1H0Z"/>` + "\n</svg>\n",
+		},
+		{
+			"levels paths", []string{ "++#", "..#", "..." }, ShapePaths, white, &black,
+			Header + `<rect width="3" height="3" fill="#000000"/>` + "\n" +
+				`<path fill="#ffffff" fill-opacity="0.502" d="M0 0H2V1H0Z"/>` + "\n" +
+				`<path fill="#ffffff" d="M2 0H3V2H2Z"/>` + "\n</svg>\n",
+		},
+		{
+			"levels rects", []string{ "++#", "..#", "..." }, ShapeRects, translucent, nil,
+			Header + `<g fill="#ff7477" fill-opacity="0.3137">` + "\n" +
+				`<rect x="0" y="0" width="2" height="1"/>` + "\n" +
+				"</g>\n" +
+				`<g fill="#ff7477" fill-opacity="0.6275">` + "\n" +
+				`<rect x="2" y="0" width="1" height="2"/>` + "\n" +
+				"</g>\n</svg>\n",
+		},
+	}
+	for _, testCase := range cases {
+		var svg strings.Builder
+		writeSVG(&svg, newGrid(testCase.rows), testCase.shape, testCase.fg, testCase.bg)
+		if svg.String() != testCase.svg {
+			t.Errorf("%s: got\n%sexpected\n%s", testCase.name, svg.String(), testCase.svg)
+		}
+	}
+}
+
+// Rasterizes hand-written SVGs with known pixels. With the nonzero
+// rule, a hole is only left when the inner contour goes the opposite
+// way of the outer one.
+func TestRasterizer(t *testing.T) {
+	const Header = `<svg xmlns="http://www.w3.org/2000/svg" width="3" height="3">`
+	cases := []struct {
+		name string
+		svg string
+		rows []string // '#' is opaque black, '.' is transparent
+	}{
+		{ "rect", Header + `<rect x="1" y="0" width="2" height="2" fill="#000000"/></svg>`, []string{ ".##", ".##", "..." } },
+		{ "group", Header + `<g fill="#000000"><rect x="0" y="2" width="3" height="1"/></g></svg>`, []string{ "...", "...", "###" } },
+		{ "hole", Header + `<path fill="#000000" d="M0 0H3V3H0ZM2 1H1V2H2Z"/></svg>`, []string{ "###", "#.#", "###" } },
+		{ "no hole", Header + `<path fill="#000000" d="M0 0H3V3H0ZM1 1H2V2H1Z"/></svg>`, []string{ "###", "###", "###" } },
+		{ "corner", Header + `<path fill="#000000" d="M0 0H1V1H2V2H1V1H0Z"/></svg>`, []string{ "#..", ".#.", "..." } },
+	}
+	for _, testCase := range cases {
+		got, err := rasterizeSVG(testCase.svg)
+		if err != nil { t.Errorf("%s: %s", testCase.name, err); continue }
+		expected := image.NewRGBA(image.Rect(0, 0, 3, 3))
+		for y, row := range testCase.rows {
+			for x, char := range row {
+				if char == '#' { expected.SetRGBA(x, y, color.RGBA{0, 0, 0, 255}) }
+			}
+		}
+		diff := compareImages(got, expected)
+		if diff != "" { t.Errorf("%s: %s", testCase.name, diff) }
+	}
+
+	// translucent fill over an opaque background
+	got, err := rasterizeSVG(Header + `<rect width="3" height="3" fill="#ffffff"/><rect width="1" height="1" fill="#ff0000" fill-opacity="0.5"/></svg>`)
+	if err != nil { t.Fatal(err) }
+	if got.RGBAAt(0, 0) != (color.RGBA{255, 128, 128, 255}) { t.Errorf("translucent pixel is %v", got.RGBAAt(0, 0)) }
+	if got.RGBAAt(1, 0) != (color.RGBA{255, 255, 255, 255}) { t.Errorf("background pixel is %v", got.RGBAAt(1, 0)) }
+}
+
+// Wrong SVGs must not match the PNG, so the comparison can't pass
+// regardless of the output.
+func TestWrongOutputFails(t *testing.T) {
+	renderer := newRenderer(t, "jammy")
+	const Text = "Hello, world!\nptxt -> SVG"
+	opts := options{ scale: 2, fg: translucent, bg: &white, pad: 1 }
+	expected := renderPNG(renderer, Text, opts)
+	opts.shape = ShapePaths
+	paths, _ := exportSVG(renderer, Text, opts)
+	opts.shape = ShapeRects
+	rects, _ := exportSVG(renderer, Text, opts)
+
+	lastSubpath := strings.LastIndex(paths, "M")
+	firstRect := strings.Index(rects, "<rect x=")
+	mutations := []struct {
+		name string
+		svg string
+	}{
+		{ "missing rect", rects[ : firstRect] + rects[strings.Index(rects[firstRect : ], "\n") + firstRect + 1 : ] },
+		{ "missing subpath", paths[ : lastSubpath] + `"/>` + paths[strings.Index(paths[lastSubpath : ], `"`) + lastSubpath + 3 : ] },
+		{ "opaque paths", strings.Replace(paths, ` fill-opacity="0.6275"`, "", 1) },
+		{ "opaque rects", strings.Replace(rects, ` fill-opacity="0.6275"`, "", 1) },
+		{ "shifted rect", strings.Replace(rects, `<rect x="`, `<rect x="1`, 1) },
+		{ "transform", strings.Replace(rects, `<g fill`, `<g transform="translate(1 0)" fill`, 1) },
+		{ "size", strings.Replace(paths, `width="`, `width="1`, 1) },
+	}
+	for _, mutation := range mutations {
+		if mutation.svg == paths || mutation.svg == rects {
+			t.Errorf("%s: mutation didn't change the SVG", mutation.name)
+			continue
+		}
+		got, err := rasterizeSVG(mutation.svg)
+		if err != nil { continue } // rejected by the rasterizer
+		if compareImages(got, expected) == "" {
+			t.Errorf("%s: wrong SVG matches the PNG", mutation.name)
+		}
+	}
+}
+
+// Returns a description of the first difference between the images,
+// or an empty string if they match (allowing for small rounding
+// differences in translucent colors).
+func compareImages(got, expected *image.RGBA) string {
+	if got.Bounds() != expected.Bounds() {
+		return fmt.Sprintf("bounds %v, expected %v", got.Bounds(), expected.Bounds())
+	}
+	for i := 0; i < len(got.Pix); i++ {
+		if abs(int(got.Pix[i]) - int(expected.Pix[i])) <= 2 { continue }
+		pixel := i/4
+		x, y := pixel % got.Rect.Dx(), pixel / got.Rect.Dx()
+		return fmt.Sprintf("pixel (%d, %d) is %v, expected %v", x, y, got.RGBAAt(x, y), expected.RGBAAt(x, y))
+	}
+	return ""
+}
+
+// ---- svg rasterizer ----
+
+// Attributes written by writeSVG(). Others, like transforms, make the
+// rasterizer fail instead of being ignored.
+var knownAttrs = map[string]bool{
+	"xmlns": true, "viewBox": true, "shape-rendering": true, "x": true, "y": true,
+	"width": true, "height": true, "fill": true, "fill-opacity": true, "d": true,
+}
+
+// Rasterizes the subset of SVG written by writeSVG(): rects and paths
+// with only M, H, V and Z commands, filled with the nonzero rule,
+// with fill and fill-opacity attributes that can be inherited from
+// groups. Pixels are sampled at their centers.
+func rasterizeSVG(svg string) (*image.RGBA, error) {
+	var canvas *image.RGBA
+	var fills []map[string]string // attribute stack
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		token, err := decoder.Token()
+		if err != nil && err.Error() == "EOF" { break }
+		if err != nil { return nil, err }
+		switch token := token.(type) {
+		case xml.StartElement:
+			attrs := make(map[string]string)
+			if len(fills) > 0 {
+				for key, value := range fills[len(fills) - 1] { attrs[key] = value }
+			}
+			for _, attr := range token.Attr {
+				if !knownAttrs[attr.Name.Local] { return nil, fmt.Errorf("unsupported attribute %q", attr.Name.Local) }
+				attrs[attr.Name.Local] = attr.Value
+			}
+			fills = append(fills, attrs)
+
+			switch token.Name.Local {
+			case "svg":
+				width, height := atoi(attrs["width"]), atoi(attrs["height"])
+				canvas = image.NewRGBA(image.Rect(0, 0, width, height))
+			case "g":
+				// attributes are inherited through the stack
+			case "rect":
+				if canvas == nil { return nil, fmt.Errorf("rect outside svg") }
+				x, y := atoi(attrs["x"]), atoi(attrs["y"])
+				rect := image.Rect(x, y, x + atoi(attrs["width"]), y + atoi(attrs["height"]))
+				err = fillShape(canvas, attrs, func(px, py int) bool { return image.Pt(px, py).In(rect) })
+				if err != nil { return nil, err }
+			case "path":
+				if canvas == nil { return nil, fmt.Errorf("path outside svg") }
+				winding, err := pathWinding(attrs["d"], canvas.Rect.Dx(), canvas.Rect.Dy())
+				if err != nil { return nil, err }
+				err = fillShape(canvas, attrs, func(px, py int) bool { return winding[py*canvas.Rect.Dx() + px] != 0 })
+				if err != nil { return nil, err }
+			default:
+				return nil, fmt.Errorf("unexpected element <%s>", token.Name.Local)
+			}
+		case xml.EndElement:
+			fills = fills[ : len(fills) - 1]
+		}
+	}
+	if canvas == nil { return nil, fmt.Errorf("missing svg element") }
+	return canvas, nil
+}
+
+// Composites the fill color over the canvas pixels inside the shape.
+func fillShape(canvas *image.RGBA, attrs map[string]string, inside func(x, y int) bool) error {
+	fill, err := parseColor(attrs["fill"])
+	if err != nil { return err }
+	opacity := 1.0
+	if attrs["fill-opacity"] != "" {
+		opacity, err = strconv.ParseFloat(attrs["fill-opacity"], 64)
+		if err != nil { return err }
+	}
+	src := [4]float64{ float64(fill.R)*opacity, float64(fill.G)*opacity, float64(fill.B)*opacity, 255*opacity }
+	for y := 0; y < canvas.Rect.Dy(); y++ {
+		for x := 0; x < canvas.Rect.Dx(); x++ {
+			if !inside(x, y) { continue }
+			offset := canvas.PixOffset(x, y)
+			for i := 0; i < 4; i++ {
+				dst := float64(canvas.Pix[offset + i])
+				canvas.Pix[offset + i] = uint8(src[i] + dst*(1 - opacity) + 0.5)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the winding number of each pixel center for the given path.
+// Only vertical segments contribute to horizontal rays.
+func pathWinding(d string, width, height int) ([]int, error) {
+	type segment struct { x, y0, y1, dir int }
+	var segments []segment
+	var x, y, startX, startY int
+	var addVertical = func(toY int) {
+		if toY > y { segments = append(segments, segment{ x, y, toY, 1 }) }
+		if toY < y { segments = append(segments, segment{ x, toY, y, -1 }) }
+		y = toY
+	}
+	var tokens []string // commands and numbers ("M10 2H5" => "M", "10", "2", "H", "5")
+	for _, field := range strings.FieldsFunc(d, func(r rune) bool { return r == ' ' || r == ',' }) {
+		start := 0
+		for i, r := range field {
+			if !strings.ContainsRune("MHVZ", r) { continue }
+			if i > start { tokens = append(tokens, field[start : i]) }
+			tokens = append(tokens, string(r))
+			start = i + 1
+		}
+		if start < len(field) { tokens = append(tokens, field[start : ]) }
+	}
+	for i := 0; i < len(tokens); i++ {
+		var arg = func() (int, error) {
+			i += 1
+			if i >= len(tokens) { return 0, fmt.Errorf("missing path argument") }
+			return strconv.Atoi(tokens[i])
+		}
+		var err error
+		switch tokens[i] {
+		case "M":
+			x, err = arg()
+			if err != nil { return nil, err }
+			y, err = arg()
+			startX, startY = x, y
+		case "H":
+			x, err = arg()
+		case "V":
+			var toY int
+			toY, err = arg()
+			addVertical(toY)
+		case "Z":
+			if x != startX && y != startY { return nil, fmt.Errorf("diagonal path closing segment") }
+			addVertical(startY)
+			x = startX
+		default:
+			return nil, fmt.Errorf("unexpected path token %q", tokens[i])
+		}
+		if err != nil { return nil, err }
+	}
+
+	// horizontal ray to the left of each pixel center
+	winding := make([]int, width*height)
+	for py := 0; py < height; py++ {
+		var crossings []segment
+		for _, segment := range segments {
+			if segment.y0 <= py && py < segment.y1 { crossings = append(crossings, segment) }
+		}
+		sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+		var sum, next int
+		for px := 0; px < width; px++ {
+			for next < len(crossings) && crossings[next].x <= px {
+				sum += crossings[next].dir
+				next += 1
+			}
+			winding[py*width + px] = sum
+		}
+	}
+	return winding, nil
+}
+
+// ---- helpers ----
+
+func newRenderer(t *testing.T, fontName string) *ptxt.Renderer {
+	t.Helper()
+	font, err := fontreg.Resolve(fontName)
+	if err != nil { t.Fatal(err) }
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { t.Fatal(err) }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	return renderer
+}
+
+// Creates a grid from rows of '#' (alpha 255), '+' (alpha 128) and
+// any other char (empty).
+func newGrid(rows []string) *pixelGrid {
+	grid := &pixelGrid{ width: len(rows[0]), height: len(rows), alpha: make([]uint8, len(rows[0])*len(rows)) }
+	for y, row := range rows {
+		for x, char := range row {
+			switch char {
+			case '#': grid.alpha[y*grid.width + x] = 255
+			case '+': grid.alpha[y*grid.width + x] = 128
+			}
+		}
+	}
+	return grid
+}
+
+func shapeName(shape Shape) string {
+	if shape == ShapeRects { return "rects" }
+	return "paths"
+}
+
+func atoi(str string) int {
+	value, _ := strconv.Atoi(str)
+	return value
+}
+
+func abs(x int) int {
+	if x < 0 { return -x }
+	return x
+}
@@ -0,0 +1,143 @@
+package main
+
+import "os"
+import "fmt"
+import "image"
+import "image/color"
+import "strings"
+
+// Output modes.
+type Mode uint8
+const (
+	ModeTrueColor Mode = iota // 24-bit ANSI colors
+	Mode256 // xterm 256-color palette
+	ModeASCII // '#' and spaces, no escapes
+)
+
+// Pixels with lower alpha are considered transparent when printing.
+const AlphaThreshold = 128
+
+func parseMode(name string) (Mode, error) {
+	switch strings.ToLower(name) {
+	case "auto"      : return detectMode(), nil
+	case "truecolor" : return ModeTrueColor, nil
+	case "256"       : return Mode256, nil
+	case "ascii"     : return ModeASCII, nil
+	default:
+		return 0, fmt.Errorf("invalid --mode %q (expected auto, truecolor, 256 or ascii)", name)
+	}
+}
+
+// Guesses the best mode supported by the terminal.
+func detectMode() Mode {
+	if os.Getenv("NO_COLOR") != "" { return ModeASCII }
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+	if colorTerm == "truecolor" || colorTerm == "24bit" { return ModeTrueColor }
+	term := os.Getenv("TERM")
+	if term == "" || term == "dumb" { return ModeASCII }
+	return Mode256
+}
+
+// Writes the image with '▀' and '▄' characters, each cell covering
+// two vertically adjacent pixels: the top one as the foreground color
+// and the bottom one as the background color. Transparent pixels are
+// left with the terminal's default background. Escapes are only
+// written when the colors change from the previous cell.
+func writeHalfBlocks(out *strings.Builder, img *image.RGBA, escape func(rgba color.RGBA, background bool) string) {
+	const DefaultBack = "\x1b[49m"
+	var fore, back string // current escapes, empty after a reset
+	var setColors = func(newFore, newBack string) {
+		if newFore != "" && newFore != fore { out.WriteString(newFore) }
+		if newBack != back { out.WriteString(newBack) }
+		if newFore != "" { fore = newFore }
+		back = newBack
+	}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top := img.RGBAAt(x, y)
+			var bottom color.RGBA
+			if y + 1 < bounds.Max.Y { bottom = img.RGBAAt(x, y + 1) }
+			topVisible, bottomVisible := top.A >= AlphaThreshold, bottom.A >= AlphaThreshold
+			switch {
+			case topVisible && bottomVisible:
+				setColors(escape(top, false), escape(bottom, true))
+				out.WriteString("▀")
+			case topVisible:
+				setColors(escape(top, false), DefaultBack)
+				out.WriteString("▀")
+			case bottomVisible:
+				setColors(escape(bottom, false), DefaultBack)
+				out.WriteString("▄")
+			default:
+				setColors("", DefaultBack)
+				out.WriteString(" ")
+			}
+		}
+		out.WriteString("\x1b[0m\n")
+		fore, back = "", ""
+	}
+}
+
+// Writes each pixel as two characters ("##" or "  "), as terminal
+// cells are roughly twice as tall as they are wide.
+func writeASCII(out *strings.Builder, img *image.RGBA) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		var line strings.Builder
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.RGBAAt(x, y).A >= AlphaThreshold {
+				line.WriteString("##")
+			} else {
+				line.WriteString("  ")
+			}
+		}
+		out.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+}
+
+func trueColorEscape(rgba color.RGBA, background bool) string {
+	r, g, b := unpremultiply(rgba)
+	if background { return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r, g, b) }
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b)
+}
+
+func color256Escape(rgba color.RGBA, background bool) string {
+	index := xterm256(unpremultiply(rgba))
+	if background { return fmt.Sprintf("\x1b[48;5;%dm", index) }
+	return fmt.Sprintf("\x1b[38;5;%dm", index)
+}
+
+// Returns the closest color in the xterm 256-color palette, considering
+// both the 6x6x6 color cube (16 - 231) and the grayscale ramp (232 - 255).
+func xterm256(r, g, b uint8) int {
+	cubeLevels := [6]int{ 0, 95, 135, 175, 215, 255 }
+	var cubeIndex = func(value uint8) int {
+		if value < 48 { return 0 }
+		if value < 115 { return 1 }
+		return (int(value) - 35)/40
+	}
+	ri, gi, bi := cubeIndex(r), cubeIndex(g), cubeIndex(b)
+	cubeDist := colorDist(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	avg := (int(r) + int(g) + int(b))/3
+	grayIndex := 23
+	if avg < 238 { grayIndex = max(avg - 3, 0)/10 }
+	grayLevel := 8 + grayIndex*10
+	grayDist := colorDist(r, g, b, grayLevel, grayLevel, grayLevel)
+
+	if grayDist < cubeDist { return 232 + grayIndex }
+	return 16 + ri*36 + gi*6 + bi
+}
+
+func colorDist(r, g, b uint8, r2, g2, b2 int) int {
+	dr, dg, db := int(r) - r2, int(g) - g2, int(b) - b2
+	return dr*dr + dg*dg + db*db
+}
+
+func unpremultiply(rgba color.RGBA) (r, g, b uint8) {
+	if rgba.A == 0 || rgba.A == 255 { return rgba.R, rgba.G, rgba.B }
+	a := uint32(rgba.A)
+	return uint8(min(uint32(rgba.R)*255/a, 255)), uint8(min(uint32(rgba.G)*255/a, 255)), uint8(min(uint32(rgba.B)*255/a, 255))
+}
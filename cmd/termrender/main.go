@@ -0,0 +1,193 @@
+package main
+
+import "os"
+import "io"
+import "fmt"
+import "log"
+import "flag"
+import "image"
+import "image/color"
+import "strings"
+import "strconv"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// Renders text with the cpu path and prints it to the terminal, using
+// half-block characters (two pixels per cell) with 24-bit or 256-color
+// ANSI escapes, or plain '#' characters when colors aren't available.
+// Useful to preview fonts and texts over SSH without opening PNGs.
+//
+// The color mode is detected from the COLORTERM, TERM and NO_COLOR
+// environment variables unless set explicitly with --mode. The image
+// is cropped to the drawn pixels, so the vertical align has no visible
+// effect, but the horizontal align still positions lines relative to
+// each other.
+//
+// Usage:
+// > go run -tags cputext . [--font jammy|font.ggfnt] [--scale 2] [--align center] [--fg ff7477] [--bg 171219] "Hello\nworld"
+// > echo "HELLO" | go run -tags cputext . --direction sideways --mode ascii
+//
+// Text is read from stdin when no arguments are given, and "\n" in
+// arguments is interpreted as a line break.
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var scaleFlag = flag.Int("scale", 1, "text scale")
+var alignFlag = flag.String("align", "left", "horizontal align: left, center or right")
+var fgFlag = flag.String("fg", "ff7477", "text color, as hex RGB or RGBA")
+var bgFlag = flag.String("bg", "", "background color, as hex RGB or RGBA (empty for the terminal background, ignored in ascii mode)")
+var directionFlag = flag.String("direction", "horizontal", "text direction: horizontal, sideways or sideways-right")
+var modeFlag = flag.String("mode", "auto", "output mode: auto, truecolor, 256 or ascii")
+var padFlag = flag.Int("pad", 1, "padding around the text, in pixels")
+
+func main() {
+	// parse flags
+	flag.Parse()
+	align, err := parseAlign(*alignFlag)
+	if err != nil { log.Fatal(err) }
+	direction, err := parseDirection(*directionFlag)
+	if err != nil { log.Fatal(err) }
+	fg, err := parseColor(*fgFlag)
+	if err != nil { log.Fatal(err) }
+	var bg color.RGBA
+	if *bgFlag != "" {
+		bg, err = parseColor(*bgFlag)
+		if err != nil { log.Fatal(err) }
+	}
+	mode, err := parseMode(*modeFlag)
+	if err != nil { log.Fatal(err) }
+	if *scaleFlag < 1 || *scaleFlag > 255 { log.Fatalf("invalid --scale %d", *scaleFlag) }
+	if *padFlag < 0 { log.Fatalf("invalid --pad %d", *padFlag) }
+
+	// get text
+	var text string
+	if flag.NArg() == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil { log.Fatal(err) }
+		text = strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	} else {
+		text = strings.ReplaceAll(strings.Join(flag.Args(), " "), `\n`, "\n")
+	}
+	if text == "" {
+		fmt.Print("Usage: go run -tags cputext . [--font jammy|font.ggfnt] [--scale N] [--align left|center|right] [--fg RRGGBB] [--bg RRGGBB] [--direction horizontal|sideways|sideways-right] [--mode auto|truecolor|256|ascii] text...\n")
+		os.Exit(1)
+	}
+
+	// parse font and create strand
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { log.Fatal(err) }
+	for _, codePoint := range text {
+		if codePoint == '\n' { continue }
+		settings := strand.UnderlyingSettingsCache().UnsafeSlice()
+		_, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { log.Fatalf("font %q can't map U+%04X %s", font.Header().Name(), codePoint, strconv.QuoteRune(codePoint)) }
+	}
+
+	// create text renderer, set the main properties
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	renderer.SetAlign(ptxt.VertCenter | align)
+	renderer.SetScale(uint8(*scaleFlag))
+	renderer.SetColor(fg)
+	renderer.SetDirection(direction)
+
+	// draw on a canvas big enough for any direction, then crop
+	width, height := renderer.Measure(text)
+	side := width + height
+	canvas := image.NewRGBA(image.Rect(0, 0, side*2, side*2))
+	renderer.Draw(canvas, text, side, side)
+	bounds := opaqueBounds(canvas)
+	if bounds.Empty() { return } // nothing visible (e.g. only spaces)
+	bounds = bounds.Inset(-*padFlag).Intersect(canvas.Bounds())
+	cropped := canvas.SubImage(bounds).(*image.RGBA)
+
+	// print result (ascii output has no colors and takes the visible
+	// pixels from the text alpha, so the background is ignored)
+	var out strings.Builder
+	switch mode {
+	case ModeTrueColor : writeHalfBlocks(&out, flatten(cropped, bg), trueColorEscape)
+	case Mode256       : writeHalfBlocks(&out, flatten(cropped, bg), color256Escape)
+	case ModeASCII     : writeASCII(&out, flatten(cropped, color.RGBA{}))
+	}
+	_, err = os.Stdout.WriteString(out.String())
+	if err != nil { log.Fatal(err) }
+}
+
+// ---- option parsing ----
+
+func parseAlign(name string) (ptxt.Align, error) {
+	switch strings.ToLower(name) {
+	case "left"   : return ptxt.Left, nil
+	case "center" : return ptxt.HorzCenter, nil
+	case "right"  : return ptxt.Right, nil
+	default:
+		return 0, fmt.Errorf("invalid --align %q (expected left, center or right)", name)
+	}
+}
+
+func parseDirection(name string) (ptxt.Direction, error) {
+	switch strings.ToLower(name) {
+	case "horizontal"     : return ptxt.Horizontal, nil
+	case "sideways"       : return ptxt.Sideways, nil
+	case "sideways-right" : return ptxt.SidewaysRight, nil
+	default:
+		return 0, fmt.Errorf("invalid --direction %q (expected horizontal, sideways or sideways-right)", name)
+	}
+}
+
+// Parses "RRGGBB" or "RRGGBBAA" colors, with an optional leading '#'.
+func parseColor(hex string) (color.RGBA, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 6 { hex += "ff" }
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 { return color.RGBA{}, fmt.Errorf("invalid color %q", hex) }
+	rgba := color.RGBA{ uint8(value >> 24), uint8(value >> 16), uint8(value >> 8), uint8(value) }
+
+	// ptxt expects premultiplied alpha
+	rgba.R = uint8(uint32(rgba.R)*uint32(rgba.A)/255)
+	rgba.G = uint8(uint32(rgba.G)*uint32(rgba.A)/255)
+	rgba.B = uint8(uint32(rgba.B)*uint32(rgba.A)/255)
+	return rgba, nil
+}
+
+// ---- helpers ----
+
+// Returns the bounds of the non transparent pixels.
+func opaqueBounds(canvas *image.RGBA) image.Rectangle {
+	var bounds image.Rectangle
+	for y := canvas.Rect.Min.Y; y < canvas.Rect.Max.Y; y++ {
+		for x := canvas.Rect.Min.X; x < canvas.Rect.Max.X; x++ {
+			if canvas.RGBAAt(x, y).A == 0 { continue }
+			bounds = bounds.Union(image.Rect(x, y, x + 1, y + 1))
+		}
+	}
+	return bounds
+}
+
+// Composites the image over the background color into a new image
+// with its origin at (0, 0). If the background is fully transparent,
+// the image is copied as is.
+func flatten(img *image.RGBA, bg color.RGBA) *image.RGBA {
+	bounds := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			src := img.RGBAAt(bounds.Min.X + x, bounds.Min.Y + y)
+			out.SetRGBA(x, y, over(src, bg))
+		}
+	}
+	return out
+}
+
+// Premultiplied alpha "source over" composition.
+func over(src, dst color.RGBA) color.RGBA {
+	inv := 255 - uint32(src.A)
+	return color.RGBA{
+		uint8(uint32(src.R) + uint32(dst.R)*inv/255),
+		uint8(uint32(src.G) + uint32(dst.G)*inv/255),
+		uint8(uint32(src.B) + uint32(dst.B)*inv/255),
+		uint8(uint32(src.A) + uint32(dst.A)*inv/255),
+	}
+}
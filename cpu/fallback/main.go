@@ -0,0 +1,113 @@
+package main
+
+import "os"
+import "fmt"
+import "flag"
+import "log"
+import "image"
+import "image/png"
+import "image/draw"
+import "image/color"
+import "strings"
+import "path/filepath"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/fallback"
+
+// Draws text with a chain of fonts: jumpy only has uppercase letters
+// and a few punctuation marks, so lowercase letters, digits and other
+// symbols are taken from jammy instead. Fallback glyphs are highlighted.
+//
+// Usage:
+// > go run -tags cputext main.go
+// > go run -tags cputext main.go --font myfont.ggfnt --fallback jammy,jumpy
+
+const Text = "GAME OVER!\nFinal score: 1200 <3\nPRESS START (or 再開)"
+const Scale = 4
+
+var BackColor      = color.RGBA{ 23,  18,  25, 255} // licorice
+var HighlightColor = color.RGBA{ 70,  45,  84, 255} // dark purple
+var TextColor      = color.RGBA{255, 214, 175, 255} // peach
+
+var fontName = fontreg.Flag("jumpy") // --font and --font-dir
+var fallbackFlag = flag.String("fallback", fontreg.Default, "comma-separated list of fallback fonts, in order of preference")
+
+func main() {
+	// parse flags
+	flag.Parse()
+	if flag.NArg() != 0 {
+		msg := "Usage: go run -tags cputext main.go [--font jumpy|myfont.ggfnt] [--fallback jammy,other.ggfnt]\n"
+		fmt.Fprint(os.Stderr, msg)
+		os.Exit(1)
+	}
+
+	// parse fonts and create strands
+	var strands []*strand.Strand
+	for _, name := range append([]string{ *fontName }, strings.Split(*fallbackFlag, ",")...) {
+		font, err := fontreg.Resolve(strings.TrimSpace(name))
+		if err != nil { log.Fatal(err) }
+		fontStrand, err := ptxt.NewStrand(font)
+		if err != nil { log.Fatal(err) }
+		err = fontStrand.Mapping().AutoInitRewriteRules() // for <3
+		if err != nil { log.Fatal(err) }
+		strands = append(strands, fontStrand)
+		fmt.Printf("Font loaded: %s\n", font.Header().Name())
+	}
+
+	// create fallback renderer, set the main properties
+	chain := fallback.New(strands...)
+	chain.Renderer().SetScale(Scale)
+	chain.Renderer().SetColor(TextColor)
+	chain.SetAlign(ptxt.Center)
+
+	// create canvas
+	w, h := chain.Measure(Text)
+	canvas := image.NewRGBA(image.Rect(0, 0, w + Scale*4, h + Scale*4))
+	fill(canvas, BackColor)
+	cx, cy := canvas.Bounds().Dx()/2, canvas.Bounds().Dy()/2
+
+	// highlight and report fallback segments
+	var missing []string
+	for _, segment := range chain.Layout(Text, cx, cy) {
+		switch segment.StrandIndex {
+		case 0: // primary font
+		case fallback.Missing:
+			for _, codePoint := range segment.Text {
+				missing = append(missing, fmt.Sprintf("U+%04X", codePoint))
+			}
+		default:
+			draw.Draw(canvas, segment.Bounds, image.NewUniform(HighlightColor), image.Point{}, draw.Src)
+			fontName := strands[segment.StrandIndex].Font().Header().Name()
+			fmt.Printf("Fallback (%s): %q\n", fontName, segment.Text)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("Note: code points not mapped by any font, skipped: %s\n", strings.Join(missing, " "))
+	}
+
+	// actual drawing
+	chain.Draw(canvas, Text, cx, cy)
+
+	// export result as png
+	filename, err := filepath.Abs("ptxt_examples_cpu_fallback.png")
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output image: %s\n", filename)
+	file, err := os.Create(filename)
+	if err != nil { log.Fatal(err) }
+	err = png.Encode(file, canvas)
+	if err != nil { log.Fatal(err) }
+	err = file.Close()
+	if err != nil { log.Fatal(err) }
+	fmt.Print("Program exited successfully.\n")
+}
+
+func fill(canvas *image.RGBA, rgba color.RGBA) {
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		canvas.Pix[i + 0] = rgba.R
+		canvas.Pix[i + 1] = rgba.G
+		canvas.Pix[i + 2] = rgba.B
+		canvas.Pix[i + 3] = rgba.A
+	}
+}
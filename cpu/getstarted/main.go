@@ -2,6 +2,7 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "log"
 import "image"
 import "image/png"
@@ -9,20 +10,27 @@ import "image/color"
 import "path/filepath"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
 
 // Usage:
-// > go run -tags cputext main.go myfont.ggfnt
+// > go run -tags cputext main.go                       (uses the jammy font)
+// > go run -tags cputext main.go --font myfont.ggfnt
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
 
 func main() {
-	// get font path
-	if len(os.Args) != 2 {
-		msg := "Usage: expects one argument with the path to the font to be used\n"
+	// parse flags
+	flag.Parse()
+	if flag.NArg() != 0 {
+		msg := "Usage: go run -tags cputext main.go [--font jammy|myfont.ggfnt]\n"
 		fmt.Fprint(os.Stderr, msg)
 		os.Exit(1)
 	}
 
 	// parse font and create strand
-	strand, err := ptxt.NewStrand(os.Args[1])
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	strand, err := ptxt.NewStrand(font)
 	if err != nil { log.Fatal(err) }
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
@@ -2,69 +2,79 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "log"
 import "image"
 import "image/png"
 import "image/color"
 import "path/filepath"
+import "strconv"
+import "strings"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
 import "github.com/tinne26/ggfnt"
 
+// Without arguments, this program draws the font's notdef glyph. With
+// --text, it renders the given text file instead, drawing notdef for
+// each code point the font can't map (ptxt would panic on them
+// otherwise), outlining those positions in a warning color and listing
+// them with their line and column.
+//
 // Usage:
-// > go run -tags cputext main.go myfont.ggfnt
+// > go run -tags cputext main.go                       (uses the jammy font)
+// > go run -tags cputext main.go --font myfont.ggfnt
+// > go run -tags cputext main.go --text strings.txt [--font myfont.ggfnt]
 
 var BackColor = color.RGBA{203, 243, 240, 255} // light mint green
 var TextColor = color.RGBA{ 46, 196, 182, 255} // sea green
+var WarnColor = color.RGBA{232,  63,  93, 255} // warning red
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var textFile = flag.String("text", "", "text file to render, highlighting the code points the font can't map")
 
 func main() {
-	// get font path
-	if len(os.Args) != 2 {
-		msg := "Usage: expects one argument with the path to the font to be used\n"
+	// parse flags
+	flag.Parse()
+	if flag.NArg() != 0 {
+		msg := "Usage: go run -tags cputext main.go [--font jammy|myfont.ggfnt] [--text file.txt]\n"
 		fmt.Fprint(os.Stderr, msg)
 		os.Exit(1)
 	}
 
 	// parse font and create strand
-	strand, err := ptxt.NewStrand(os.Args[1])
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	strand, err := ptxt.NewStrand(font)
 	if err != nil { log.Fatal(err) }
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
-	// make sure notdef exists
-	notdef := strand.Font().Glyphs().FindIndexByName("notdef")
-	if notdef == ggfnt.GlyphMissing {
-		fmt.Printf("Font doesn't have a 'notdef' glyph.\n")
-		os.Exit(0)
-	}
-
 	// create text renderer, set the main properties
 	const Scale = 4
 	renderer := ptxt.NewRenderer()
 	renderer.SetStrand(strand)
-	renderer.SetAlign(ptxt.Center)
 	renderer.SetScale(Scale)
 	renderer.SetColor(TextColor)
 
-	// create canvas
-	notdefMask := renderer.Advanced().LoadMask(notdef)
-	canvasBounds := notdefMask.Bounds()
-	canvasBounds.Min.X = canvasBounds.Min.X*Scale - Scale
-	canvasBounds.Max.X = canvasBounds.Max.X*Scale + Scale
-	canvasBounds.Min.Y = canvasBounds.Min.Y*Scale - Scale
-	canvasBounds.Max.Y = canvasBounds.Max.Y*Scale + Scale
-	canvas := image.NewRGBA(canvasBounds)
-	fill(canvas, BackColor)
-
-	// actual drawing
-	var params ptxt.MaskDrawParameters
-	params.X = 0
-	params.Y = 0
-	params.Scale = Scale
-	params.RGBA = [4]float32{
-		float32(TextColor.R)/255.0, float32(TextColor.G)/255.0,
-		float32(TextColor.B)/255.0, float32(TextColor.A)/255.0,
+	// draw notdef alone or the text file
+	var canvas *image.RGBA
+	notdef := strand.Font().Glyphs().FindIndexByName("notdef")
+	if *textFile != "" {
+		data, err := os.ReadFile(*textFile)
+		if err != nil { log.Fatal(err) }
+		text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		if notdef == ggfnt.GlyphMissing {
+			fmt.Printf("Font doesn't have a 'notdef' glyph, empty boxes will be used instead.\n")
+		}
+		canvas = drawReport(renderer, notdef, text)
+	} else {
+		if notdef == ggfnt.GlyphMissing {
+			fmt.Printf("Font doesn't have a 'notdef' glyph.\n")
+			os.Exit(0)
+		}
+		canvas = drawNotdef(renderer, notdef)
 	}
-	renderer.Advanced().DrawMask(canvas, notdefMask, strand, params)
 
 	// export result as png
 	filename, err := filepath.Abs("ptxt_examples_cpu_notdef.png")
@@ -79,6 +89,160 @@ func main() {
 	fmt.Print("Program exited successfully.\n")
 }
 
+func drawNotdef(renderer *ptxt.Renderer, notdef ggfnt.GlyphIndex) *image.RGBA {
+	scale := int(renderer.GetScale())
+
+	// create canvas
+	notdefMask := renderer.Advanced().LoadMask(notdef)
+	canvasBounds := notdefMask.Bounds()
+	canvasBounds.Min.X = canvasBounds.Min.X*scale - scale
+	canvasBounds.Max.X = canvasBounds.Max.X*scale + scale
+	canvasBounds.Min.Y = canvasBounds.Min.Y*scale - scale
+	canvasBounds.Max.Y = canvasBounds.Max.Y*scale + scale
+	canvas := image.NewRGBA(canvasBounds)
+	fill(canvas, BackColor)
+
+	// actual drawing
+	var params ptxt.MaskDrawParameters
+	params.X = 0
+	params.Y = 0
+	params.Scale = scale
+	params.RGBA = rgbaParams(TextColor)
+	renderer.Advanced().DrawMask(canvas, notdefMask, renderer.Strand(), params)
+	return canvas
+}
+
+// ---- missing glyphs report ----
+
+// A piece of a line: either a run of code points the font can map, or
+// a single code point that it can't.
+type piece struct {
+	text string
+	missing bool
+	line, column int // 1-based, in code points
+	x, baseline, width int
+}
+
+func drawReport(renderer *ptxt.Renderer, notdef ggfnt.GlyphIndex, text string) *image.RGBA {
+	// lay out the text and report missing code points
+	pieces, width, height := layoutPieces(renderer, notdef, text)
+	var numMissing int
+	for _, piece := range pieces {
+		if !piece.missing { continue }
+		if numMissing == 0 { fmt.Print("Missing code points:\n") }
+		numMissing += 1
+		codePoint := []rune(piece.text)[0]
+		fmt.Printf("  U+%04X %s at %d:%d\n", codePoint, strconv.QuoteRune(codePoint), piece.line, piece.column)
+	}
+	if numMissing == 0 {
+		fmt.Print("All code points are mapped.\n")
+	} else {
+		fmt.Printf("Total: %d missing code point(s)\n", numMissing)
+	}
+
+	// create canvas
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(canvas, BackColor)
+
+	// draw text and missing code point boxes
+	scale := int(renderer.GetScale())
+	metrics := renderer.Strand().Font().Metrics()
+	ascent, descent := int(metrics.Ascent())*scale, int(metrics.Descent())*scale
+	renderer.SetAlign(ptxt.Left | ptxt.Baseline)
+	for _, piece := range pieces {
+		if !piece.missing {
+			renderer.Draw(canvas, piece.text, piece.x, piece.baseline)
+			continue
+		}
+
+		box := image.Rect(piece.x, piece.baseline - ascent, piece.x + piece.width, piece.baseline + descent)
+		outline(canvas, box.Inset(-scale), max(scale/2, 1), WarnColor)
+		if notdef == ggfnt.GlyphMissing { continue }
+		var params ptxt.MaskDrawParameters
+		params.X = piece.x
+		params.Y = piece.baseline
+		params.Scale = scale
+		params.RGBA = rgbaParams(TextColor)
+		renderer.Advanced().DrawMask(canvas, renderer.Advanced().LoadMask(notdef), renderer.Strand(), params)
+	}
+	return canvas
+}
+
+// Splits the text into pieces and places them, returning also the
+// canvas size required to draw them with some margin.
+func layoutPieces(renderer *ptxt.Renderer, notdef ggfnt.GlyphIndex, text string) ([]piece, int, int) {
+	fontStrand := renderer.Strand()
+	scale := int(renderer.GetScale())
+	metrics := fontStrand.Font().Metrics()
+	ascent, descent := int(metrics.Ascent())*scale, int(metrics.Descent())*scale
+	lineHeight := (metrics.LineHeight() + int(fontStrand.VertInterspacingShift()))*scale
+	interspacing := (int(metrics.HorzInterspacing()) + int(fontStrand.HorzInterspacingShift()))*scale
+	missingWidth := ascent/2
+	if notdef != ggfnt.GlyphMissing {
+		missingWidth = int(fontStrand.Font().Glyphs().Advance(notdef))*scale
+	}
+
+	margin := scale*4
+	var pieces []piece
+	var width int
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		x, baseline := margin, margin + ascent + i*lineHeight
+		var runStart, runColumn int
+		var column int
+		var addPiece = func(p piece) {
+			if x > margin { x += interspacing }
+			p.line, p.x, p.baseline = i + 1, x, baseline
+			pieces = append(pieces, p)
+			x += p.width
+		}
+		for byteIndex, codePoint := range line {
+			column += 1
+			if canMap(fontStrand, codePoint) { continue }
+			if byteIndex > runStart {
+				run := line[runStart : byteIndex]
+				runWidth, _ := renderer.Measure(run)
+				addPiece(piece{ text: run, column: runColumn + 1, width: runWidth })
+			}
+			addPiece(piece{ text: string(codePoint), missing: true, column: column, width: missingWidth })
+			runStart, runColumn = byteIndex + len(string(codePoint)), column
+		}
+		if runStart < len(line) {
+			run := line[runStart : ]
+			runWidth, _ := renderer.Measure(run)
+			addPiece(piece{ text: run, column: runColumn + 1, width: runWidth })
+		}
+		width = max(width, x + margin)
+	}
+	height := margin*2 + ascent + descent + (len(lines) - 1)*lineHeight
+	return pieces, width, height
+}
+
+// ---- helpers ----
+
+func canMap(fontStrand *strand.Strand, codePoint rune) bool {
+	settings := fontStrand.UnderlyingSettingsCache().UnsafeSlice()
+	_, found := fontStrand.Font().Mapping().Utf8(codePoint, settings)
+	return found
+}
+
+func rgbaParams(rgba color.RGBA) [4]float32 {
+	return [4]float32{
+		float32(rgba.R)/255.0, float32(rgba.G)/255.0,
+		float32(rgba.B)/255.0, float32(rgba.A)/255.0,
+	}
+}
+
+func outline(canvas *image.RGBA, rect image.Rectangle, thickness int, rgba color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			inside := x >= rect.Min.X + thickness && x < rect.Max.X - thickness &&
+			          y >= rect.Min.Y + thickness && y < rect.Max.Y - thickness
+			if !inside { canvas.SetRGBA(x, y, rgba) }
+		}
+	}
+}
+
 func fill(canvas *image.RGBA, rgba color.RGBA) {
 	for i := 0; i < len(canvas.Pix); i += 4 {
 		canvas.Pix[i + 0] = rgba.R
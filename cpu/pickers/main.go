@@ -0,0 +1,113 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "image"
+import "image/png"
+import "image/color"
+import "path/filepath"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ptxt-examples/internal/pickers"
+import "github.com/tinne26/ggfnt-fonts/jumpy"
+
+// Usage:
+// > go run -tags cputext .
+//
+// The pickers themselves are in internal/pickers, which can't be
+// imported from outside this repository. Copy them into your project
+// instead.
+
+// The jumpy font maps each uppercase letter to two glyph variants,
+// which makes it a good font to see glyph pickers in action.
+const SampleText = "AAAA BBBB BANANA SAYS HELLO"
+const Scale = 3
+
+var BackColor  = color.RGBA{246, 242, 240, 255}
+var TextColor  = color.RGBA{242, 143,  59, 255}
+var LabelColor = color.RGBA{150, 140, 136, 255}
+
+func main() {
+	font := jumpy.Font()
+	fmt.Printf("Font loaded: %s\n", font.Header().Name())
+
+	// create the pickers and a separate strand for each one
+	// (labels use a strand without glyph pickers, and can only
+	// use the few characters available in jumpy)
+	var alternate pickers.AlternatePicker
+	var blink pickers.BlinkPicker
+	blink.Period = 30
+	word := pickers.NewWordPicker(font, 0xDECAF)
+	type pickerRow struct {
+		label string
+		picker strand.GlyphPicker
+		tick int // only relevant for the blink picker
+	}
+	rows := []pickerRow{
+		{ label: "NO PICKER: ALWAYS FIRST VARIANT" },
+		{ label: "ALTERNATE PICKER", picker: &alternate },
+		{ label: "WORD PICKER", picker: word },
+		{ label: "BLINK PICKER, FIRST STATE", picker: &blink, tick: 0 },
+		{ label: "BLINK PICKER, SECOND STATE", picker: &blink, tick: 30 },
+	}
+	labelStrand, err := ptxt.NewStrand(font)
+	if err != nil { log.Fatal(err) }
+
+	// create text renderer, set the main properties
+	renderer := ptxt.NewRenderer()
+	renderer.SetAlign(ptxt.Baseline | ptxt.Left)
+	renderer.SetScale(Scale)
+
+	// create canvas
+	renderer.SetStrand(labelStrand)
+	lineHeight := font.Metrics().LineHeight()*Scale
+	textWidth, _ := renderer.Measure(SampleText)
+	rowHeight := lineHeight*2 + Scale*6
+	canvas := image.NewRGBA(image.Rect(0, 0, textWidth + Scale*16, rowHeight*len(rows) + Scale*4))
+	fill(canvas, BackColor)
+
+	// actual drawing
+	for i, row := range rows {
+		y := Scale*4 + i*rowHeight + int(font.Metrics().Ascent())*Scale
+		renderer.SetStrand(labelStrand)
+		renderer.SetColor(LabelColor)
+		renderer.Draw(canvas, row.label, Scale*8, y)
+
+		rowStrand, err := ptxt.NewStrand(font)
+		if err != nil { log.Fatal(err) }
+		if row.picker != nil { rowStrand.GlyphPickers().Add(row.picker) }
+		blink.SetTick(row.tick)
+		renderer.SetStrand(rowStrand)
+		renderer.SetColor(TextColor)
+		renderer.Draw(canvas, SampleText, Scale*8, y + lineHeight + Scale*2)
+
+		// print picks for reference. results are deterministic,
+		// so these will be the same on every run
+		if row.picker != nil {
+			fmt.Printf("%-28s %s\n", row.label, pickers.TracePicks(row.picker, font, SampleText))
+		}
+	}
+
+	// export result as png
+	filename, err := filepath.Abs("ptxt_examples_cpu_pickers.png")
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output image: %s\n", filename)
+	file, err := os.Create(filename)
+	if err != nil { log.Fatal(err) }
+	err = png.Encode(file, canvas)
+	if err != nil { log.Fatal(err) }
+	err = file.Close()
+	if err != nil { log.Fatal(err) }
+	fmt.Print("Program exited successfully.\n")
+}
+
+func fill(canvas *image.RGBA, rgba color.RGBA) {
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		canvas.Pix[i + 0] = rgba.R
+		canvas.Pix[i + 1] = rgba.G
+		canvas.Pix[i + 2] = rgba.B
+		canvas.Pix[i + 3] = rgba.A
+	}
+}
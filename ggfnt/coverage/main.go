@@ -0,0 +1,160 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "sort"
+import "strconv"
+import "strings"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/strtable"
+
+// Reports the glyph coverage of one or more fonts for the translated
+// strings of a game, read from JSON, CSV or PO string tables (see
+// strtable.Read() for the details of each format).
+//
+// For each font and locale, prints the percentage of code points used
+// by the locale that the font can map, the code points without glyphs
+// and the keys of the strings affected by them. Code points are mapped
+// with the default settings, and rewrite rules are not considered, like
+// ptxt's RendererAdvanced.AllGlyphsAvailable(). Line breaks are ignored.
+//
+// With --min-coverage, the program exits with status 1 if any locale
+// is below the given percentage, so it can be used in CI.
+//
+// Usage:
+// > go run . [--font jammy,jumpy|font.ggfnt] [--min-coverage 100] [--locale es,fr] table.csv es.json fr.po...
+
+var fontNames = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var minCoverageFlag = flag.Float64("min-coverage", 0, "fail if any font and locale has a lower coverage percentage (0 disables the check)")
+var localeFlag = flag.String("locale", "", "comma-separated list of locales to check; all by default")
+var maxKeysFlag = flag.Int("max-keys", 5, "max string keys listed for each missing code point (0 for all)")
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() == 0 || *minCoverageFlag < 0 || *minCoverageFlag > 100 || *maxKeysFlag < 0 {
+		fmt.Print("Usage: go run . [--font jammy,jumpy|font.ggfnt] [--min-coverage 100] [--locale es,fr] [--max-keys 5] table.csv es.json fr.po...\n")
+		os.Exit(1)
+	}
+
+	// read string tables, group by locale
+	byLocale := make(map[string][]strtable.String)
+	for _, path := range flag.Args() {
+		strs, err := strtable.Read(path)
+		if err != nil { log.Fatalf("%s: %s", path, err) }
+		for _, str := range strs {
+			byLocale[str.Locale] = append(byLocale[str.Locale], str)
+		}
+	}
+	locales := make([]string, 0, len(byLocale))
+	for locale := range byLocale { locales = append(locales, locale) }
+	sort.Strings(locales)
+	if *localeFlag != "" {
+		locales = splitList(*localeFlag)
+		for _, locale := range locales {
+			if _, found := byLocale[locale]; !found {
+				log.Fatalf("locale %q not found in the string tables", locale)
+			}
+		}
+	}
+	if len(locales) == 0 { log.Fatal("no translated strings found") }
+
+	// report coverage for each font and locale
+	var failures []string
+	for _, fontName := range splitList(*fontNames) {
+		font, err := fontreg.Resolve(fontName)
+		if err != nil { log.Fatal(err) }
+		fmt.Printf("Font: %s\n", font.Header().Name())
+		for _, locale := range locales {
+			report := computeCoverage(font, byLocale[locale])
+			report.Print(locale, *maxKeysFlag)
+			if *minCoverageFlag > 0 && report.Percentage() < *minCoverageFlag {
+				failures = append(failures, fmt.Sprintf("%s/%s (%.2f%%)", fontName, locale, report.Percentage()))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		fmt.Printf("Coverage below %g%% for: %s\n", *minCoverageFlag, strings.Join(failures, ", "))
+		os.Exit(1)
+	}
+}
+
+// Coverage results of a font for the strings of a locale.
+type coverageReport struct {
+	numCodePoints int // distinct
+	numStrings int
+	numAffectedStrings int
+	missing []rune // sorted
+	uses map[rune]int
+	keys map[rune][]string // keys of the strings using each missing code point
+}
+
+func computeCoverage(font *ggfnt.Font, strs []strtable.String) *coverageReport {
+	report := &coverageReport{
+		numStrings: len(strs),
+		uses: make(map[rune]int),
+		keys: make(map[rune][]string),
+	}
+
+	settings := make([]uint8, font.Settings().Count())
+	mapped := make(map[rune]bool)
+	for _, str := range strs {
+		var affected bool
+		for _, codePoint := range str.Text {
+			if codePoint == '\n' { continue } // handled by the renderer
+			isMapped, seen := mapped[codePoint]
+			if !seen {
+				_, isMapped = font.Mapping().Utf8(codePoint, settings)
+				mapped[codePoint] = isMapped
+				report.numCodePoints += 1
+				if !isMapped { report.missing = append(report.missing, codePoint) }
+			}
+			if isMapped { continue }
+			report.uses[codePoint] += 1
+			affected = true
+			keys := report.keys[codePoint]
+			if len(keys) == 0 || keys[len(keys) - 1] != str.Key {
+				report.keys[codePoint] = append(keys, str.Key)
+			}
+		}
+		if affected { report.numAffectedStrings += 1 }
+	}
+	sort.Slice(report.missing, func(i, j int) bool { return report.missing[i] < report.missing[j] })
+	return report
+}
+
+// Percentage of distinct code points with glyphs.
+func (self *coverageReport) Percentage() float64 {
+	if self.numCodePoints == 0 { return 100 }
+	return 100*float64(self.numCodePoints - len(self.missing))/float64(self.numCodePoints)
+}
+
+func (self *coverageReport) Print(locale string, maxKeys int) {
+	fmt.Printf("  %s: %.2f%% coverage (%d of %d code points), %d of %d strings affected\n",
+		locale, self.Percentage(), self.numCodePoints - len(self.missing), self.numCodePoints,
+		self.numAffectedStrings, self.numStrings)
+	for _, codePoint := range self.missing {
+		keys := self.keys[codePoint]
+		list := strings.Join(keys, ", ")
+		if maxKeys > 0 && len(keys) > maxKeys {
+			list = strings.Join(keys[ : maxKeys], ", ") + fmt.Sprintf(" (+%d more)", len(keys) - maxKeys)
+		}
+		fmt.Printf("    U+%04X %s x%d: %s\n", codePoint, strconv.QuoteRune(codePoint), self.uses[codePoint], list)
+	}
+}
+
+// ---- helpers ----
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" { items = append(items, item) }
+	}
+	return items
+}
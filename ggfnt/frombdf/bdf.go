@@ -0,0 +1,194 @@
+package main
+
+import "fmt"
+import "bytes"
+import "bufio"
+import "image"
+import "strconv"
+import "strings"
+import "encoding/hex"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// A bitmap font parsed from a BDF or PCF file. Both formats share
+// the same model: XLFD properties and glyphs with integer advances.
+type bitmapFont struct {
+	name string // XLFD name (FONT line)
+	properties map[string]string // integer properties are stored in decimal
+	ascent, descent int
+	defaultChar int // -1 if not defined
+	glyphs []*bitmapGlyph
+}
+
+type bitmapGlyph struct {
+	name string
+	code int // in the font's charset, -1 if unencoded
+	advance int
+	mask *image.Alpha // trimmed, relative to the glyph origin
+}
+
+// Returns the given string property, or the fallback if missing.
+func (self *bitmapFont) property(name, fallback string) string {
+	value, found := self.properties[name]
+	if !found || strings.TrimSpace(value) == "" { return fallback }
+	return strings.TrimSpace(value)
+}
+
+// Returns the given integer property, if present and valid.
+func (self *bitmapFont) intProperty(name string) (int, bool) {
+	value, found := self.properties[name]
+	if !found { return 0, false }
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
+
+// Returns the charset as "registry-encoding" in lowercase, taken from
+// the CHARSET_* properties or the last two fields of the XLFD name.
+func (self *bitmapFont) charset() string {
+	registry := self.property("CHARSET_REGISTRY", "")
+	encoding := self.property("CHARSET_ENCODING", "")
+	if registry == "" || encoding == "" {
+		fields := strings.Split(self.name, "-")
+		if len(fields) != 15 { return "" } // not an XLFD name
+		registry, encoding = fields[13], fields[14]
+	}
+	return strings.ToLower(registry + "-" + encoding)
+}
+
+// Parses a BDF font (Glyph Bitmap Distribution Format 2.1).
+func parseBDF(data []byte) (*bitmapFont, error) {
+	bdf := &bitmapFont{ properties: make(map[string]string), defaultChar: -1 }
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1 << 20)
+	var lineNum int
+	var next = func() ([]string, string, bool) {
+		for scanner.Scan() {
+			lineNum += 1
+			line := strings.TrimSpace(scanner.Text())
+			fields := strings.Fields(line)
+			if len(fields) == 0 || fields[0] == "COMMENT" { continue }
+			return fields, line, true
+		}
+		return nil, "", false
+	}
+	var fail = func(format string, args ...any) error {
+		return fmt.Errorf("bdf line %d: %s", lineNum, fmt.Sprintf(format, args...))
+	}
+
+	fields, _, ok := next()
+	if !ok || fields[0] != "STARTFONT" { return nil, fail("missing STARTFONT") }
+
+	// global section
+	var fontBox [4]int // width, height, x offset, y offset
+	var fontAdvance int
+	var hasFontBox bool
+header:
+	for {
+		fields, line, ok := next()
+		if !ok { return nil, fail("unexpected end of file") }
+		switch fields[0] {
+		case "FONT":
+			bdf.name = strings.TrimSpace(strings.TrimPrefix(line, "FONT"))
+		case "FONTBOUNDINGBOX":
+			values, err := atoiFields(fields, 4)
+			if err != nil { return nil, fail("%s", err) }
+			copy(fontBox[ : ], values)
+			hasFontBox = true
+		case "DWIDTH":
+			values, err := atoiFields(fields, 2)
+			if err != nil { return nil, fail("%s", err) }
+			fontAdvance = values[0]
+		case "STARTPROPERTIES":
+			for {
+				fields, line, ok := next()
+				if !ok { return nil, fail("unexpected end of file") }
+				if fields[0] == "ENDPROPERTIES" { break }
+				value := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+				if strings.HasPrefix(value, `"`) { // quotes are escaped by doubling them
+					value = strings.ReplaceAll(strings.Trim(value, `"`), `""`, `"`)
+				}
+				bdf.properties[fields[0]] = value
+			}
+		case "CHARS":
+			break header
+		case "ENDFONT":
+			return nil, fail("no CHARS section")
+		}
+	}
+
+	// glyphs
+	if !hasFontBox { return nil, fail("missing FONTBOUNDINGBOX") }
+	for {
+		fields, _, ok := next()
+		if !ok { return nil, fail("unexpected end of file") }
+		if fields[0] == "ENDFONT" { break }
+		if fields[0] != "STARTCHAR" { return nil, fail("expected STARTCHAR, found %s", fields[0]) }
+		glyph := &bitmapGlyph{ code: -1, advance: fontAdvance }
+		if len(fields) > 1 { glyph.name = fields[1] }
+		box := fontBox
+		for {
+			fields, _, ok := next()
+			if !ok { return nil, fail("unexpected end of file") }
+			if fields[0] == "BITMAP" { break }
+			switch fields[0] {
+			case "ENCODING":
+				values, err := atoiFields(fields, 1)
+				if err != nil { return nil, fail("%s", err) }
+				glyph.code = max(values[0], -1) // 'ENCODING -1 n' codes are non-standard
+			case "DWIDTH":
+				values, err := atoiFields(fields, 2)
+				if err != nil { return nil, fail("%s", err) }
+				glyph.advance = values[0]
+			case "BBX":
+				values, err := atoiFields(fields, 4)
+				if err != nil { return nil, fail("%s", err) }
+				copy(box[ : ], values)
+			}
+		}
+
+		// bitmap rows, top to bottom, padded to whole bytes
+		width, height, xOffset, yOffset := box[0], box[1], box[2], box[3]
+		if width < 0 || height < 0 { return nil, fail("invalid BBX for glyph %q", glyph.name) }
+		mask := image.NewAlpha(image.Rect(xOffset, -(yOffset + height), xOffset + width, -yOffset))
+		for row := 0; ; row++ {
+			fields, _, ok := next()
+			if !ok { return nil, fail("unexpected end of file") }
+			if fields[0] == "ENDCHAR" {
+				if row != height { return nil, fail("glyph %q has %d rows, expected %d", glyph.name, row, height) }
+				break
+			}
+			if row >= height { return nil, fail("glyph %q has too many rows", glyph.name) }
+			bits, err := hex.DecodeString(fields[0])
+			if err != nil || len(bits)*8 < width { return nil, fail("invalid bitmap row %q", fields[0]) }
+			for x := 0; x < width; x++ {
+				if bits[x >> 3] & (0x80 >> (x & 7)) == 0 { continue }
+				mask.Pix[row*mask.Stride + x] = 255
+			}
+		}
+		glyph.mask = fontconv.Trim(mask)
+		bdf.glyphs = append(bdf.glyphs, glyph)
+	}
+
+	// font ascent and descent, from the bounding box if missing
+	ascent, hasAscent := bdf.intProperty("FONT_ASCENT")
+	descent, hasDescent := bdf.intProperty("FONT_DESCENT")
+	if !hasAscent { ascent = fontBox[1] + fontBox[3] }
+	if !hasDescent { descent = -fontBox[3] }
+	bdf.ascent, bdf.descent = ascent, descent
+	if value, found := bdf.intProperty("DEFAULT_CHAR"); found {
+		bdf.defaultChar = value
+	}
+	return bdf, nil
+}
+
+// Parses the n integer values after the keyword.
+func atoiFields(fields []string, n int) ([]int, error) {
+	if len(fields) < n + 1 { return nil, fmt.Errorf("%s expects %d values", fields[0], n) }
+	values := make([]int, n)
+	for i := 0; i < n; i++ {
+		value, err := strconv.Atoi(fields[i + 1])
+		if err != nil { return nil, fmt.Errorf("%s: invalid value %q", fields[0], fields[i + 1]) }
+		values[i] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import "fmt"
+import "strings"
+import "unicode/utf8"
+
+import "golang.org/x/text/encoding"
+import "golang.org/x/text/encoding/charmap"
+import "golang.org/x/text/encoding/japanese"
+import "golang.org/x/text/encoding/korean"
+import "golang.org/x/text/encoding/simplifiedchinese"
+import "golang.org/x/text/encoding/traditionalchinese"
+
+// Converts glyph codes in the font's charset to unicode code points.
+type charsetDecoder func(code int) (rune, bool)
+
+// Returns the decoder for the given XLFD charset ("registry-encoding",
+// lowercase). Besides unicode, single byte charsets known by x/text and
+// the main CJK double byte charsets are supported.
+func newCharsetDecoder(charset string) (charsetDecoder, error) {
+	registry, _, _ := strings.Cut(charset, "-")
+	switch {
+	case registry == "iso10646" || registry == "unicode":
+		return func(code int) (rune, bool) {
+			return rune(code), code >= 0 && utf8.ValidRune(rune(code))
+		}, nil
+	case charset == "iso8859-1":
+		return func(code int) (rune, bool) { return rune(code), code >= 0 && code <= 0xFF }, nil
+	case registry == "ascii" || strings.HasPrefix(registry, "iso646"):
+		return func(code int) (rune, bool) { return rune(code), code >= 0 && code <= 0x7F }, nil
+	case strings.HasPrefix(registry, "jisx0201"):
+		return decodeJISX0201, nil
+	case strings.HasPrefix(registry, "jisx0208"):
+		return newDoubleByteDecoder(japanese.EUCJP, 0x8080), nil
+	case strings.HasPrefix(registry, "ksc5601") || strings.HasPrefix(registry, "ksx1001"):
+		return newDoubleByteDecoder(korean.EUCKR, 0x8080), nil
+	case strings.HasPrefix(registry, "gb2312"):
+		return newDoubleByteDecoder(simplifiedchinese.GBK, 0x8080), nil
+	case strings.HasPrefix(registry, "big5"):
+		return newDoubleByteDecoder(traditionalchinese.Big5, 0), nil
+	}
+
+	// single byte charsets, by x/text name ("ISO 8859-2", "Windows 1251", ...)
+	key := charset
+	key = strings.Replace(key, "microsoft-cp", "windows", 1)
+	key = strings.Replace(key, "ibm-cp", "ibmcodepage", 1)
+	key = normalizeCharsetName(key)
+	for _, enc := range charmap.All {
+		cmap, isCharmap := enc.(*charmap.Charmap)
+		if !isCharmap || normalizeCharsetName(cmap.String()) != key { continue }
+		return func(code int) (rune, bool) {
+			if code < 0 || code > 0xFF { return 0, false }
+			codePoint := cmap.DecodeByte(byte(code))
+			return codePoint, codePoint != utf8.RuneError
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q (use --charset to override)", charset)
+}
+
+// Lowercases the name and removes spaces and separators.
+func normalizeCharsetName(name string) string {
+	name = strings.ToLower(name)
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '_' || r == '.' { return -1 }
+		return r
+	}, name)
+}
+
+// Decodes double byte codes by converting them to the byte sequence
+// of the given encoding. XLFD charsets like jisx0208 use the 7-bit
+// form of the codes, so EUC encodings need the high bits set.
+func newDoubleByteDecoder(enc encoding.Encoding, highBits int) charsetDecoder {
+	decoder := enc.NewDecoder()
+	return func(code int) (rune, bool) {
+		if code < 0 || code > 0xFFFF { return 0, false }
+		var input []byte
+		if code <= 0x7F {
+			input = []byte{ byte(code) } // ascii range
+		} else {
+			code |= highBits
+			input = []byte{ byte(code >> 8), byte(code) }
+		}
+		output, err := decoder.Bytes(input)
+		if err != nil { return 0, false }
+		codePoint, size := utf8.DecodeRune(output)
+		if codePoint == utf8.RuneError || size != len(output) { return 0, false }
+		return codePoint, true
+	}
+}
+
+// JIS X 0201: roman characters (ascii except for the yen sign and the
+// overline) and halfwidth katakana.
+func decodeJISX0201(code int) (rune, bool) {
+	switch {
+	case code == 0x5C: return '¥', true
+	case code == 0x7E: return '‾', true
+	case code >= 0 && code <= 0x7F: return rune(code), true
+	case code >= 0xA1 && code <= 0xDF: return rune(0xFF61 + code - 0xA1), true
+	default: return 0, false
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "bytes"
+import "unicode"
+import "strings"
+import "strconv"
+import "compress/gzip"
+import "path/filepath"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ggfnt/builder"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Converts X11 bitmap fonts (BDF and PCF, optionally gzipped) to
+// .ggfnt. Glyph bitmaps are converted directly, the metrics are taken
+// from the font properties and glyph codes are converted from the
+// font's charset to unicode.
+//
+// Usage:
+// > go run . [--charset iso8859-1] [--out font.ggfnt] font.bdf
+//
+// A round-trip specimen can be rendered with the cpu pipeline. The
+// converted font is drawn with ptxt and compared against the original
+// bitmaps, failing on any mismatch:
+// > go run -tags cputext . --specimen specimen.png font.pcf.gz
+
+var outFlag = flag.String("out", "", "output .ggfnt path; defaults to the input path with a .ggfnt extension")
+var charsetFlag = flag.String("charset", "", "charset of the glyph codes (e.g. iso10646-1); taken from the font if empty")
+var specimenFlag = flag.String("specimen", "", "output .png path for a round-trip specimen (requires -tags cputext)")
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Print("Usage: go run [-tags cputext] . [--charset iso8859-1] [--out font.ggfnt] [--specimen specimen.png] font.bdf\n")
+		os.Exit(1)
+	}
+	inPath := flag.Arg(0)
+	outPath := *outFlag
+	if outPath == "" {
+		base := strings.TrimSuffix(inPath, ".gz")
+		outPath = strings.TrimSuffix(base, filepath.Ext(base)) + ".ggfnt"
+	}
+
+	// parse bdf or pcf
+	data, err := os.ReadFile(inPath)
+	if err != nil { log.Fatal(err) }
+	if bytes.HasPrefix(data, []byte{0x1F, 0x8B}) { // gzip magic
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil { log.Fatal(err) }
+		var buffer bytes.Buffer
+		_, err = buffer.ReadFrom(reader)
+		if err != nil { log.Fatal(err) }
+		data = buffer.Bytes()
+	}
+	var bdf *bitmapFont
+	if bytes.HasPrefix(data, []byte(PcfMagic)) {
+		bdf, err = parsePCF(data)
+	} else {
+		bdf, err = parseBDF(data)
+	}
+	if err != nil { log.Fatal(err) }
+
+	// convert codes to code points
+	charset := *charsetFlag
+	if charset == "" {
+		charset = bdf.charset()
+		if charset == "" {
+			charset = "iso10646-1"
+			fmt.Print("Note: the font doesn't declare its charset, assuming unicode\n")
+		}
+	}
+	decode, err := newCharsetDecoder(strings.ToLower(charset))
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Source font: %s (%d glyphs, charset %s)\n", bdf.property("FAMILY_NAME", filepath.Base(inPath)), len(bdf.glyphs), charset)
+	mapping, glyphOrder, notes := collectMapping(bdf, decode)
+	for _, note := range notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+	if len(mapping) == 0 { log.Fatal("the font doesn't map any code points") }
+
+	// derive metrics
+	byRune := make(map[rune]*bitmapGlyph, len(mapping))
+	for _, entry := range mapping {
+		byRune[entry.codePoint] = entry.glyph
+	}
+	metrics, notes := deriveMetrics(bdf, byRune, glyphOrder)
+	for _, note := range notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+
+	// notdef glyph, from the default char if it fits
+	notdef := &bitmapGlyph{ name: "notdef", code: -1 }
+	fullAscent, fullDescent := metrics.Ascent + metrics.ExtraAscent, metrics.Descent + metrics.ExtraDescent
+	for _, glyph := range bdf.glyphs {
+		if bdf.defaultChar < 0 || glyph.code != bdf.defaultChar { continue }
+		if fontconv.FitsMetrics(glyph.mask, fullAscent, fullDescent) {
+			notdef.mask, notdef.advance = glyph.mask, glyph.advance
+		}
+		break
+	}
+	if notdef.mask == nil {
+		notdef.mask = fontconv.NotdefMask(metrics.UppercaseAscent)
+		notdef.advance = notdef.mask.Rect.Dx() + 1
+		fmt.Print("Note: no usable DEFAULT_CHAR glyph, notdef replaced with a rectangle\n")
+	}
+	glyphOrder = append([]*bitmapGlyph{ notdef }, glyphOrder...)
+
+	// build ggfnt font
+	fontBuilder, err := buildFont(bdf, inPath, mapping, glyphOrder, metrics)
+	if err != nil { log.Fatal(err) }
+
+	// export and verify
+	var buffer bytes.Buffer
+	err = fontBuilder.Export(&buffer)
+	if err != nil { log.Fatal(err) }
+	err = os.WriteFile(outPath, buffer.Bytes(), 0644)
+	if err != nil { log.Fatal(err) }
+	err = verify(outPath, mapping, glyphOrder)
+	if err != nil { log.Fatalf("verification failed: %s", err) }
+	absPath, err := filepath.Abs(outPath)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output font: %s\n", absPath)
+
+	// round-trip specimen
+	if *specimenFlag != "" {
+		err = renderSpecimen(outPath, *specimenFlag, mapping, metrics)
+		if err != nil { log.Fatalf("specimen: %s", err) }
+		absPath, err := filepath.Abs(*specimenFlag)
+		if err != nil { log.Fatal(err) }
+		fmt.Printf("Specimen: %s (matches the source bitmaps)\n", absPath)
+	}
+}
+
+// A code point to glyph mapping entry.
+type mappingEntry struct {
+	codePoint rune
+	glyph *bitmapGlyph
+}
+
+// Returns the mapped code points, sorted, together with the list of
+// glyphs they use, in order of first use. Glyphs that can't be mapped
+// are skipped and reported through the notes.
+func collectMapping(bdf *bitmapFont, decode charsetDecoder) ([]mappingEntry, []*bitmapGlyph, []string) {
+	var unencoded, undecodable, controls, duplicates int
+	byRune := make(map[rune]*bitmapGlyph)
+	for _, glyph := range bdf.glyphs {
+		if glyph.code < 0 {
+			unencoded += 1
+			continue
+		}
+		codePoint, ok := decode(glyph.code)
+		if !ok {
+			undecodable += 1
+			continue
+		}
+		if unicode.IsControl(codePoint) {
+			controls += 1
+			continue
+		}
+		if byRune[codePoint] != nil {
+			duplicates += 1
+			continue
+		}
+		byRune[codePoint] = glyph
+	}
+
+	var mapping []mappingEntry
+	var glyphOrder []*bitmapGlyph
+	seen := make(map[*bitmapGlyph]bool)
+	for codePoint := rune(0); codePoint <= unicode.MaxRune; codePoint++ {
+		glyph := byRune[codePoint]
+		if glyph == nil { continue }
+		mapping = append(mapping, mappingEntry{ codePoint, glyph })
+		if !seen[glyph] {
+			seen[glyph] = true
+			glyphOrder = append(glyphOrder, glyph)
+		}
+	}
+
+	var notes []string
+	if unencoded > 0 { notes = append(notes, fmt.Sprintf("%d unencoded glyphs skipped", unencoded)) }
+	if undecodable > 0 { notes = append(notes, fmt.Sprintf("%d glyphs without a unicode equivalent skipped", undecodable)) }
+	if controls > 0 { notes = append(notes, fmt.Sprintf("%d control character glyphs skipped", controls)) }
+	if duplicates > 0 { notes = append(notes, fmt.Sprintf("%d glyphs for already mapped code points skipped", duplicates)) }
+	return mapping, glyphOrder, notes
+}
+
+func buildFont(bdf *bitmapFont, inPath string, mapping []mappingEntry, glyphOrder []*bitmapGlyph, metrics fontconv.Metrics) (*builder.Font, error) {
+	fontBuilder := builder.New()
+
+	// header
+	family := cleanText(bdf.property("FAMILY_NAME", filepath.Base(inPath)))
+	name := cleanText(bdf.property("FACE_NAME", ""))
+	if name == "" {
+		name = family
+		weight := cleanText(bdf.property("WEIGHT_NAME", ""))
+		if weight != "" && !strings.EqualFold(weight, "medium") && !strings.EqualFold(weight, "regular") {
+			name += " " + weight
+		}
+		pixelSize, found := bdf.intProperty("PIXEL_SIZE")
+		if found && !strings.Contains(name, strconv.Itoa(pixelSize)) {
+			name += " " + strconv.Itoa(pixelSize) // bitmap fonts often come in several sizes
+		}
+	}
+	author := cleanText(bdf.property("FOUNDRY", "Unknown"))
+	about := "Converted from " + filepath.Base(inPath) + " with ptxt-examples/ggfnt/frombdf."
+	for _, property := range []string{"COPYRIGHT", "NOTICE", "LICENSE"} {
+		value := cleanText(bdf.property(property, ""))
+		if value != "" { about += " " + value }
+	}
+	for _, err := range []error{
+		fontBuilder.SetName(fontconv.Truncate(name, 255)),
+		fontBuilder.SetFamily(fontconv.Truncate(family, 255)),
+		fontBuilder.SetAuthor(fontconv.Truncate(author, 255)),
+		fontBuilder.SetAbout(fontconv.Truncate(about, 65535)),
+	}{
+		if err != nil { return nil, err }
+	}
+
+	// metrics (set before adding glyphs, as they are validated against them)
+	for _, value := range []int{metrics.Ascent, metrics.ExtraAscent, metrics.Descent, metrics.ExtraDescent} {
+		if value > 255 { return nil, fmt.Errorf("metrics exceed 255 pixels") }
+	}
+	fontBuilder.SetAscent(uint8(metrics.Ascent))
+	fontBuilder.SetExtraAscent(uint8(metrics.ExtraAscent))
+	fontBuilder.SetDescent(uint8(metrics.Descent))
+	fontBuilder.SetExtraDescent(uint8(metrics.ExtraDescent))
+	fontBuilder.SetUppercaseAscent(uint8(metrics.UppercaseAscent))
+	fontBuilder.SetMidlineAscent(uint8(metrics.MidlineAscent))
+	fontBuilder.SetHorzInterspacing(0) // bdf advances already include spacing
+	fontBuilder.SetLineGap(0)
+	err := fontBuilder.GetMetricsStatus()
+	if err != nil { return nil, err }
+
+	// glyphs
+	uids := make(map[*bitmapGlyph]uint64, len(glyphOrder))
+	for _, glyph := range glyphOrder {
+		if glyph.advance < 0 || glyph.advance > 255 {
+			return nil, fmt.Errorf("glyph %q advance (%d) out of range", glyph.name, glyph.advance)
+		}
+		uid, err := fontBuilder.AddGlyph(glyph.mask)
+		if err != nil { return nil, fmt.Errorf("glyph %q: %w", glyph.name, err) }
+		err = fontBuilder.SetGlyphPlacement(uid, ggfnt.GlyphPlacement{
+			Advance: uint8(glyph.advance),
+			TopAdvance: uint8(metrics.Ascent),
+			BottomAdvance: uint8(metrics.Descent),
+			HorzCenter: uint8(glyph.advance/2),
+		})
+		if err != nil { return nil, err }
+		uids[glyph] = uid
+	}
+	err = fontBuilder.SetGlyphName(uids[glyphOrder[0]], "notdef")
+	if err != nil { return nil, err }
+
+	// mapping
+	for _, entry := range mapping {
+		err = fontBuilder.Map(entry.codePoint, uids[entry.glyph])
+		if err != nil { return nil, fmt.Errorf("mapping U+%04X: %w", entry.codePoint, err) }
+	}
+	return fontBuilder, nil
+}
+
+// Parses the exported font and checks that glyph masks, advances
+// and mappings match the source bitmaps.
+func verify(path string, mapping []mappingEntry, glyphOrder []*bitmapGlyph) error {
+	parsed, err := fontconv.ParseExported(path)
+	if err != nil { return err }
+	err = fontconv.VerifyGlyphCount(parsed, len(glyphOrder))
+	if err != nil { return err }
+	indices := make(map[*bitmapGlyph]ggfnt.GlyphIndex, len(glyphOrder))
+	for i, glyph := range glyphOrder {
+		index := ggfnt.GlyphIndex(i)
+		indices[glyph] = index
+		err = fontconv.VerifyGlyph(parsed, index, glyph.mask, glyph.advance)
+		if err != nil { return fmt.Errorf("glyph %q %w", glyph.name, err) }
+	}
+	for _, entry := range mapping {
+		err = fontconv.VerifyMapping(parsed, entry.codePoint, indices[entry.glyph])
+		if err != nil { return err }
+	}
+
+	metrics := parsed.Metrics()
+	fmt.Printf("Font: %s (%d glyphs, %d mapped code points)\n", parsed.Header().Name(), parsed.Glyphs().Count(), len(mapping))
+	fmt.Printf("Metrics: ascent %d (+%d), cap line %d, midline %d, descent %d (+%d)\n",
+		metrics.Ascent(), metrics.ExtraAscent(), metrics.UppercaseAscent(), metrics.MidlineAscent(),
+		metrics.Descent(), metrics.ExtraDescent())
+	return nil
+}
+
+// ---- helpers ----
+
+// Replaces line breaks and other control characters, which ggfnt
+// doesn't allow in header strings, with spaces.
+func cleanText(text string) string {
+	return strings.Join(strings.FieldsFunc(text, unicode.IsControl), " ")
+}
+
@@ -0,0 +1,57 @@
+package main
+
+import "image"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Maps the font properties to ggfnt metrics. FONT_ASCENT and
+// FONT_DESCENT are used as the ascent and descent, and CAP_HEIGHT and
+// X_HEIGHT as the uppercase and midline ascents, measuring sample
+// glyphs when missing. Extra ascent and descent are set so that all
+// glyphs fit. BDF line height is ascent + descent, so there's no gap.
+func deriveMetrics(bdf *bitmapFont, glyphs map[rune]*bitmapGlyph, allGlyphs []*bitmapGlyph) (fontconv.Metrics, []string) {
+	masks := make(map[rune]*image.Alpha, len(glyphs))
+	for codePoint, glyph := range glyphs {
+		masks[codePoint] = glyph.mask
+	}
+	allMasks := make([]*image.Alpha, len(allGlyphs))
+	for i, glyph := range allGlyphs {
+		allMasks[i] = glyph.mask
+	}
+
+	var notes []string
+	var metrics fontconv.Metrics
+	metrics.Ascent = max(bdf.ascent, 1)
+	metrics.Descent = max(bdf.descent, 1) // ggfnt requires descent > extra descent
+
+	capHeight, found := bdf.intProperty("CAP_HEIGHT")
+	if !found {
+		capHeight, found = fontconv.FirstTop(masks, fontconv.CapSamples)
+		if !found {
+			capHeight = metrics.Ascent
+			notes = append(notes, "no CAP_HEIGHT nor uppercase samples, using the ascent")
+		}
+	}
+	if capHeight > metrics.Ascent {
+		capHeight = metrics.Ascent
+		notes = append(notes, "cap height exceeds FONT_ASCENT, clamped")
+	}
+	midline, found := bdf.intProperty("X_HEIGHT")
+	if !found {
+		midline, found = fontconv.FirstTop(masks, fontconv.MidlineSamples)
+		if !found {
+			midline = capHeight
+			notes = append(notes, "no X_HEIGHT nor lowercase samples, using the cap height")
+		}
+	}
+	if midline > capHeight {
+		midline = capHeight
+		notes = append(notes, "x-height exceeds the cap height, clamped")
+	}
+	metrics.UppercaseAscent = max(capHeight, 0)
+	metrics.MidlineAscent = max(midline, 0)
+
+	// make room for accents and other tall or deep glyphs
+	notes = append(notes, fontconv.FitExtras(&metrics, allMasks)...)
+	return metrics, notes
+}
@@ -0,0 +1,304 @@
+package main
+
+import "fmt"
+import "image"
+import "strconv"
+import "encoding/binary"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// PCF table types.
+const (
+	pcfProperties      = 1 << 0
+	pcfAccelerators    = 1 << 1
+	pcfMetrics         = 1 << 2
+	pcfBitmaps         = 1 << 3
+	pcfBdfEncodings    = 1 << 5
+	pcfGlyphNames      = 1 << 7
+	pcfBdfAccelerators = 1 << 8
+)
+
+// PCF format flags.
+const (
+	pcfGlyphPadMask      = 0b0011
+	pcfByteOrderMSB      = 0b0100
+	pcfBitOrderMSB       = 0b1000
+	pcfScanUnitMask      = 0b0011_0000
+	pcfCompressedMetrics = 0x100
+)
+
+const PcfMagic = "\x01fcp"
+
+// Parses a PCF font (X11 Portable Compiled Format), as produced by
+// bdftopcf. Only the tables needed for the conversion are read.
+func parsePCF(data []byte) (*bitmapFont, error) {
+	if len(data) < 8 || string(data[ : 4]) != PcfMagic { return nil, fmt.Errorf("pcf: invalid magic") }
+
+	// table of contents (always little endian)
+	tables := make(map[uint32][]byte)
+	numTables := binary.LittleEndian.Uint32(data[4 : ])
+	if numTables > 64 || 8 + int(numTables)*16 > len(data) { return nil, fmt.Errorf("pcf: invalid table count") }
+	for i := 0; i < int(numTables); i++ {
+		entry := data[8 + i*16 : ]
+		tableType := binary.LittleEndian.Uint32(entry[0 : ])
+		size := binary.LittleEndian.Uint32(entry[8 : ])
+		offset := binary.LittleEndian.Uint32(entry[12 : ])
+		if uint64(offset) + uint64(size) > uint64(len(data)) {
+			return nil, fmt.Errorf("pcf: table %d out of bounds", tableType)
+		}
+		tables[tableType] = data[offset : offset + size]
+	}
+	for _, required := range []uint32{pcfProperties, pcfMetrics, pcfBitmaps, pcfBdfEncodings} {
+		if tables[required] == nil { return nil, fmt.Errorf("pcf: missing table %d", required) }
+	}
+
+	pcf := &bitmapFont{ properties: make(map[string]string), defaultChar: -1 }
+	err := pcfReadProperties(pcf, tables[pcfProperties])
+	if err != nil { return nil, err }
+	metrics, err := pcfReadMetrics(tables[pcfMetrics])
+	if err != nil { return nil, err }
+	masks, err := pcfReadBitmaps(tables[pcfBitmaps], metrics)
+	if err != nil { return nil, err }
+	codes, defaultChar, err := pcfReadEncodings(tables[pcfBdfEncodings], len(metrics))
+	if err != nil { return nil, err }
+	var names []string
+	if tables[pcfGlyphNames] != nil {
+		names, err = pcfReadGlyphNames(tables[pcfGlyphNames], len(metrics))
+		if err != nil { return nil, err }
+	}
+
+	// glyphs
+	for i, metric := range metrics {
+		glyph := &bitmapGlyph{ code: codes[i], advance: metric.advance, mask: fontconv.Trim(masks[i]) }
+		if names != nil { glyph.name = names[i] }
+		pcf.glyphs = append(pcf.glyphs, glyph)
+	}
+
+	// font ascent and descent, from the accelerators if missing
+	pcf.name = pcf.property("FONT", "")
+	ascent, hasAscent := pcf.intProperty("FONT_ASCENT")
+	descent, hasDescent := pcf.intProperty("FONT_DESCENT")
+	if !hasAscent || !hasDescent {
+		accelerators := tables[pcfBdfAccelerators]
+		if accelerators == nil { accelerators = tables[pcfAccelerators] }
+		if accelerators == nil { return nil, fmt.Errorf("pcf: missing font ascent and descent") }
+		reader, err := newPcfReader(accelerators)
+		if err != nil { return nil, err }
+		reader.skip(8) // flags
+		ascent, descent = int(int32(reader.u32())), int(int32(reader.u32()))
+		if reader.err != nil { return nil, reader.err }
+	}
+	pcf.ascent, pcf.descent = ascent, descent
+	pcf.defaultChar = defaultChar
+	if value, found := pcf.intProperty("DEFAULT_CHAR"); found {
+		pcf.defaultChar = value
+	}
+	return pcf, nil
+}
+
+type pcfMetric struct {
+	left, right, advance, ascent, descent int
+}
+
+func pcfReadProperties(pcf *bitmapFont, table []byte) error {
+	reader, err := newPcfReader(table)
+	if err != nil { return err }
+	numProps := int(reader.u32())
+	if reader.err != nil || numProps > len(table)/9 { return fmt.Errorf("pcf: invalid properties table") }
+	type property struct {
+		name uint32
+		isString bool
+		value uint32
+	}
+	props := make([]property, numProps)
+	for i := range props {
+		props[i].name = reader.u32()
+		props[i].isString = reader.u8() != 0
+		props[i].value = reader.u32()
+	}
+	if numProps & 3 != 0 { reader.skip(4 - numProps & 3) }
+	stringsSize := int(reader.u32())
+	stringsData := reader.bytes(stringsSize)
+	if reader.err != nil { return fmt.Errorf("pcf: invalid properties table") }
+	for _, prop := range props {
+		name, err := pcfString(stringsData, prop.name)
+		if err != nil { return err }
+		if prop.isString {
+			value, err := pcfString(stringsData, prop.value)
+			if err != nil { return err }
+			pcf.properties[name] = value
+		} else {
+			pcf.properties[name] = strconv.Itoa(int(int32(prop.value)))
+		}
+	}
+	return nil
+}
+
+func pcfReadMetrics(table []byte) ([]pcfMetric, error) {
+	reader, err := newPcfReader(table)
+	if err != nil { return nil, err }
+	var metrics []pcfMetric
+	if reader.format & pcfCompressedMetrics != 0 {
+		count := int(reader.u16())
+		if count*5 > len(table) { return nil, fmt.Errorf("pcf: invalid metrics table") }
+		metrics = make([]pcfMetric, count)
+		for i := range metrics {
+			var values [5]int
+			for j := range values { values[j] = int(reader.u8()) - 0x80 }
+			metrics[i] = pcfMetric{ values[0], values[1], values[2], values[3], values[4] }
+		}
+	} else {
+		count := int(reader.u32())
+		if count*12 > len(table) { return nil, fmt.Errorf("pcf: invalid metrics table") }
+		metrics = make([]pcfMetric, count)
+		for i := range metrics {
+			var values [5]int
+			for j := range values { values[j] = int(int16(reader.u16())) }
+			reader.skip(2) // attributes
+			metrics[i] = pcfMetric{ values[0], values[1], values[2], values[3], values[4] }
+		}
+	}
+	if reader.err != nil { return nil, fmt.Errorf("pcf: invalid metrics table") }
+	return metrics, nil
+}
+
+// Reads the glyph bitmaps as masks relative to the glyph origins.
+func pcfReadBitmaps(table []byte, metrics []pcfMetric) ([]*image.Alpha, error) {
+	reader, err := newPcfReader(table)
+	if err != nil { return nil, err }
+	count := int(reader.u32())
+	if count != len(metrics) { return nil, fmt.Errorf("pcf: bitmap count doesn't match metrics") }
+	offsets := make([]int, count)
+	for i := range offsets { offsets[i] = int(reader.u32()) }
+	var sizes [4]int
+	for i := range sizes { sizes[i] = int(reader.u32()) }
+	padIndex := reader.format & pcfGlyphPadMask
+	bitmapData := reader.bytes(sizes[padIndex])
+	if reader.err != nil { return nil, fmt.Errorf("pcf: invalid bitmaps table") }
+
+	// normalize to most significant bit first, bytes in reading order
+	bitmapData = append([]byte(nil), bitmapData...)
+	msbBits := reader.format & pcfBitOrderMSB != 0
+	msbBytes := reader.format & pcfByteOrderMSB != 0
+	if !msbBits {
+		for i, b := range bitmapData {
+			b = (b & 0xF0) >> 4 | (b & 0x0F) << 4
+			b = (b & 0xCC) >> 2 | (b & 0x33) << 2
+			bitmapData[i] = (b & 0xAA) >> 1 | (b & 0x55) << 1
+		}
+	}
+	scanUnit := 1 << ((reader.format & pcfScanUnitMask) >> 4)
+	if msbBits != msbBytes && scanUnit > 1 {
+		for i := 0; i + scanUnit <= len(bitmapData); i += scanUnit {
+			unit := bitmapData[i : i + scanUnit]
+			for a, b := 0, scanUnit - 1; a < b; a, b = a + 1, b - 1 {
+				unit[a], unit[b] = unit[b], unit[a]
+			}
+		}
+	}
+
+	// rows are padded to 1, 2, 4 or 8 bytes
+	pad := 1 << padIndex
+	masks := make([]*image.Alpha, count)
+	for i, metric := range metrics {
+		width, height := metric.right - metric.left, metric.ascent + metric.descent
+		if width < 0 || height < 0 { return nil, fmt.Errorf("pcf: invalid metrics for glyph %d", i) }
+		rowSize := ((width + 7)/8 + pad - 1)/pad*pad
+		if offsets[i] < 0 || offsets[i] + rowSize*height > len(bitmapData) {
+			return nil, fmt.Errorf("pcf: glyph %d bitmap out of bounds", i)
+		}
+		mask := image.NewAlpha(image.Rect(metric.left, -metric.ascent, metric.right, metric.descent))
+		for y := 0; y < height; y++ {
+			row := bitmapData[offsets[i] + y*rowSize : ]
+			for x := 0; x < width; x++ {
+				if row[x >> 3] & (0x80 >> (x & 7)) == 0 { continue }
+				mask.Pix[y*mask.Stride + x] = 255
+			}
+		}
+		masks[i] = mask
+	}
+	return masks, nil
+}
+
+// Returns the code of each glyph (-1 if unencoded) and the default
+// char. Codes are (byte1 << 8) | byte2, as in BDF ENCODING values.
+func pcfReadEncodings(table []byte, numGlyphs int) ([]int, int, error) {
+	reader, err := newPcfReader(table)
+	if err != nil { return nil, 0, err }
+	minByte2, maxByte2 := int(reader.u16()), int(reader.u16())
+	minByte1, maxByte1 := int(reader.u16()), int(reader.u16())
+	defaultChar := int(reader.u16())
+	if reader.err != nil || minByte2 > maxByte2 || minByte1 > maxByte1 || maxByte2 > 255 || maxByte1 > 255 {
+		return nil, 0, fmt.Errorf("pcf: invalid encodings table")
+	}
+	codes := make([]int, numGlyphs)
+	for i := range codes { codes[i] = -1 }
+	for byte1 := minByte1; byte1 <= maxByte1; byte1++ {
+		for byte2 := minByte2; byte2 <= maxByte2; byte2++ {
+			index := int(reader.u16())
+			if index == 0xFFFF { continue }
+			if index >= numGlyphs { return nil, 0, fmt.Errorf("pcf: invalid glyph index %d", index) }
+			if codes[index] == -1 { codes[index] = byte1 << 8 | byte2 }
+		}
+	}
+	if reader.err != nil { return nil, 0, fmt.Errorf("pcf: invalid encodings table") }
+	if defaultChar == 0xFFFF { defaultChar = -1 }
+	return codes, defaultChar, nil
+}
+
+func pcfReadGlyphNames(table []byte, numGlyphs int) ([]string, error) {
+	reader, err := newPcfReader(table)
+	if err != nil { return nil, err }
+	if int(reader.u32()) != numGlyphs { return nil, fmt.Errorf("pcf: glyph name count doesn't match metrics") }
+	offsets := make([]uint32, numGlyphs)
+	for i := range offsets { offsets[i] = reader.u32() }
+	stringsData := reader.bytes(int(reader.u32()))
+	if reader.err != nil { return nil, fmt.Errorf("pcf: invalid glyph names table") }
+	names := make([]string, numGlyphs)
+	for i, offset := range offsets {
+		names[i], err = pcfString(stringsData, offset)
+		if err != nil { return nil, err }
+	}
+	return names, nil
+}
+
+// Returns the zero-terminated string at the given offset.
+func pcfString(data []byte, offset uint32) (string, error) {
+	if int(offset) >= len(data) { return "", fmt.Errorf("pcf: string offset out of bounds") }
+	for end := int(offset); end < len(data); end++ {
+		if data[end] == 0 { return string(data[offset : end]), nil }
+	}
+	return "", fmt.Errorf("pcf: unterminated string")
+}
+
+// Reads the contents of a table in the byte order given by its
+// format. Reading past the end sets the error and returns zeros.
+type pcfReader struct {
+	data []byte
+	index int
+	format uint32
+	order binary.ByteOrder
+	err error
+}
+
+func newPcfReader(table []byte) (*pcfReader, error) {
+	if len(table) < 4 { return nil, fmt.Errorf("pcf: truncated table") }
+	format := binary.LittleEndian.Uint32(table) // the format itself is always little endian
+	reader := &pcfReader{ data: table, index: 4, format: format, order: binary.LittleEndian }
+	if format & pcfByteOrderMSB != 0 { reader.order = binary.BigEndian }
+	return reader, nil
+}
+
+func (self *pcfReader) bytes(n int) []byte {
+	if self.err != nil || n < 0 || self.index + n > len(self.data) {
+		self.err = fmt.Errorf("pcf: truncated table")
+		return make([]byte, max(n, 0))
+	}
+	self.index += n
+	return self.data[self.index - n : self.index]
+}
+
+func (self *pcfReader) skip(n int) { self.bytes(n) }
+func (self *pcfReader) u8() uint8 { return self.bytes(1)[0] }
+func (self *pcfReader) u16() uint16 { return self.order.Uint16(self.bytes(2)) }
+func (self *pcfReader) u32() uint32 { return self.order.Uint32(self.bytes(4)) }
@@ -0,0 +1,154 @@
+//go:build cputext
+
+package main
+
+import "os"
+import "fmt"
+import "image"
+import "image/png"
+import "image/color"
+import "strings"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+const SpecimenPangram = "The quick brown fox jumps over the lazy dog"
+const SpecimenLineLen = 32
+const SpecimenMaxLines = 24
+const SpecimenMargin = 8
+
+var specimenBackground = color.RGBA{23, 18, 25, 255} // licorice
+var specimenText = color.RGBA{242, 240, 229, 255} // off-white
+var specimenMismatch = color.RGBA{255, 0, 0, 255}
+
+// Draws sample lines with the converted font through ptxt and the cpu
+// pipeline, and then draws the same lines directly from the source
+// bitmaps. The image shows the ptxt render on top and the reference
+// below, with mismatched pixels highlighted in red.
+func renderSpecimen(fontPath string, pngPath string, mapping []mappingEntry, metrics fontconv.Metrics) error {
+	// load converted font
+	file, err := os.Open(fontPath)
+	if err != nil { return err }
+	font, err := ggfnt.Parse(file)
+	closeErr := file.Close()
+	if err != nil { return err }
+	if closeErr != nil { return closeErr }
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { return err }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	renderer.SetAlign(ptxt.Left | ptxt.Baseline)
+	renderer.SetColor(specimenText)
+
+	// layout
+	byRune := make(map[rune]*bitmapGlyph, len(mapping))
+	for _, entry := range mapping {
+		byRune[entry.codePoint] = entry.glyph
+	}
+	lines := specimenLines(mapping, byRune)
+	lineHeight := metrics.Ascent + metrics.ExtraAscent + metrics.Descent + metrics.ExtraDescent + 1
+	panelWidth, panelHeight := 0, SpecimenMargin*2 + lineHeight*len(lines)
+	for _, line := range lines {
+		panelWidth = max(panelWidth, lineBounds(line, byRune).Max.X)
+	}
+	panelWidth += SpecimenMargin*2
+	canvas := image.NewRGBA(image.Rect(0, 0, panelWidth, panelHeight*2))
+	fill(canvas, specimenBackground)
+	ptxtPanel := canvas.SubImage(image.Rect(0, 0, panelWidth, panelHeight)).(*image.RGBA)
+
+	// draw with ptxt (top) and from the source bitmaps (bottom)
+	for i, line := range lines {
+		x := SpecimenMargin
+		y := SpecimenMargin + metrics.Ascent + metrics.ExtraAscent + i*lineHeight
+		renderer.Draw(ptxtPanel, line, x, y)
+		for _, codePoint := range line {
+			glyph := byRune[codePoint]
+			mask := glyph.mask
+			for my := mask.Rect.Min.Y; my < mask.Rect.Max.Y; my++ {
+				for mx := mask.Rect.Min.X; mx < mask.Rect.Max.X; mx++ {
+					if mask.AlphaAt(mx, my).A == 0 { continue }
+					canvas.SetRGBA(x + mx, panelHeight + y + my, specimenText)
+				}
+			}
+			x += glyph.advance
+		}
+	}
+
+	// compare panels
+	var mismatches int
+	for y := 0; y < panelHeight; y++ {
+		for x := 0; x < panelWidth; x++ {
+			if canvas.RGBAAt(x, y) == canvas.RGBAAt(x, panelHeight + y) { continue }
+			canvas.SetRGBA(x, y, specimenMismatch)
+			mismatches += 1
+		}
+	}
+
+	// export
+	outFile, err := os.Create(pngPath)
+	if err != nil { return err }
+	err = png.Encode(outFile, canvas)
+	closeErr = outFile.Close()
+	if err != nil { return err }
+	if closeErr != nil { return closeErr }
+	if mismatches > 0 {
+		return fmt.Errorf("%d pixels differ from the source bitmaps (highlighted in %s)", mismatches, pngPath)
+	}
+	return nil
+}
+
+// Returns a pangram, if the font has all its glyphs, followed by
+// lines with all the mapped code points, up to SpecimenMaxLines.
+func specimenLines(mapping []mappingEntry, byRune map[rune]*bitmapGlyph) []string {
+	var lines []string
+	for _, pangram := range []string{SpecimenPangram, strings.ToUpper(SpecimenPangram)} {
+		if hasAll(pangram, byRune) {
+			lines = append(lines, pangram)
+			break
+		}
+	}
+	var line []rune
+	for _, entry := range mapping {
+		if len(lines) == SpecimenMaxLines { break }
+		line = append(line, entry.codePoint)
+		if len(line) == SpecimenLineLen {
+			lines = append(lines, string(line))
+			line = line[ : 0]
+		}
+	}
+	if len(line) > 0 && len(lines) < SpecimenMaxLines {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// Returns the bounds of the line's glyphs when drawn at the origin,
+// including the advances.
+func lineBounds(line string, byRune map[rune]*bitmapGlyph) image.Rectangle {
+	var bounds image.Rectangle
+	var x int
+	for _, codePoint := range line {
+		glyph := byRune[codePoint]
+		bounds = bounds.Union(glyph.mask.Rect.Add(image.Pt(x, 0)))
+		x += glyph.advance
+		bounds.Max.X = max(bounds.Max.X, x)
+	}
+	return bounds
+}
+
+func hasAll(text string, byRune map[rune]*bitmapGlyph) bool {
+	for _, codePoint := range text {
+		if byRune[codePoint] == nil { return false }
+	}
+	return true
+}
+
+func fill(canvas *image.RGBA, rgba color.RGBA) {
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		canvas.Pix[i + 0] = rgba.R
+		canvas.Pix[i + 1] = rgba.G
+		canvas.Pix[i + 2] = rgba.B
+		canvas.Pix[i + 3] = rgba.A
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !cputext
+
+package main
+
+import "errors"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Round-trip specimens are drawn with the cpu pipeline, see specimen_cpu.go.
+func renderSpecimen(fontPath string, pngPath string, mapping []mappingEntry, metrics fontconv.Metrics) error {
+	return errors.New("specimens require building with -tags cputext")
+}
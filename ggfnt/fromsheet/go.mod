@@ -0,0 +1,10 @@
+module github.com/tinne26/ptxt-examples/ggfnt/fromsheet
+
+go 1.22.2
+
+require (
+	github.com/tinne26/ggfnt v0.0.0-20240705120847-d849d4c6e12d
+	github.com/tinne26/ptxt-examples/internal v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/tinne26/ptxt-examples/internal => ../../internal
@@ -0,0 +1,327 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "bytes"
+import "image"
+import "image/png"
+import "unicode"
+import "strings"
+import "path/filepath"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ggfnt/builder"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Converts a PNG sprite sheet with one glyph per cell to .ggfnt. Cells
+// are read left to right, top to bottom, and assigned to the listed
+// characters in order. Ink is taken from the alpha channel, or from
+// any color different from the top-left pixel if the sheet is opaque.
+// A notdef glyph is added, and the metrics are derived from sample
+// glyphs.
+//
+// Usage:
+// > go run . --cell 8x10 --baseline 7 --chars "ABC..." [--trim ink] sheet.png
+// > go run . --cell 8x10 --baseline 7 --chars-file chars.txt sheet.png
+//
+// The baseline is the last row of the glyph bodies within each cell,
+// counting from 0 at the top. Rows below it are for descenders.
+//
+// The result can be checked with the other examples. Each example is
+// its own module, so run them from their own folders, passing the
+// path to the converted font:
+// > cd ../../cpu/notdef && go run -tags cputext . --font <path/to/sheet.ggfnt>
+// > cd ../../gpu/glyphs && go run . --font <path/to/sheet.ggfnt>
+
+// Trim modes.
+const (
+	TrimNone  = "none"  // glyphs keep their position in the cell, advance is the cell width
+	TrimInk   = "ink"   // glyphs start at the origin, advance is the ink width plus spacing
+	TrimRight = "right" // glyphs keep their left offset, advance ends at the ink plus spacing
+)
+
+var cellFlag = flag.String("cell", "", "cell size, as WIDTHxHEIGHT (e.g. 8x10)")
+var gapFlag = flag.Int("gap", 0, "pixels between cells")
+var charsFlag = flag.String("chars", "", "characters in cell order")
+var charsFileFlag = flag.String("chars-file", "", "file with the characters in cell order; line breaks are ignored")
+var baselineFlag = flag.Int("baseline", -1, "last row of the glyph bodies within each cell, from 0 at the top")
+var trimFlag = flag.String("trim", TrimNone, "per-glyph width trimming: " + TrimNone + ", " + TrimInk + " or " + TrimRight)
+var spacingFlag = flag.Int("spacing", 1, "pixels after the ink of each glyph, for trimmed glyphs")
+var lineGapFlag = flag.Int("line-gap", -1, "line gap in pixels; if negative, line height matches the cell height")
+var nameFlag = flag.String("name", "", "font name; defaults to the sheet filename")
+var authorFlag = flag.String("author", "Unknown", "font author")
+var outFlag = flag.String("out", "", "output .ggfnt path; defaults to the input path with a .ggfnt extension")
+var listFlag = flag.Int("list", 16, "max number of cells to list on each warning")
+
+func main() {
+	// usage check
+	flag.Parse()
+	cellWidth, cellHeight, cellErr := parseSize(*cellFlag)
+	trimMode := strings.ToLower(*trimFlag)
+	validTrim := trimMode == TrimNone || trimMode == TrimInk || trimMode == TrimRight
+	if flag.NArg() != 1 || cellErr != nil || !validTrim || *baselineFlag < 0 || *baselineFlag >= cellHeight ||
+		(*charsFlag == "") == (*charsFileFlag == "") || *gapFlag < 0 || *spacingFlag < 0 {
+		fmt.Print("Usage: go run . --cell 8x10 --baseline 7 --chars \"ABC...\"|--chars-file chars.txt [--trim none|ink|right] [--out font.ggfnt] sheet.png\n")
+		os.Exit(1)
+	}
+	inPath := flag.Arg(0)
+	outPath := *outFlag
+	if outPath == "" {
+		outPath = strings.TrimSuffix(inPath, filepath.Ext(inPath)) + ".ggfnt"
+	}
+
+	// read characters and sheet
+	chars := *charsFlag
+	if *charsFileFlag != "" {
+		data, err := os.ReadFile(*charsFileFlag)
+		if err != nil { log.Fatal(err) }
+		chars = strings.NewReplacer("\r", "", "\n", "").Replace(string(data))
+	}
+	codePoints := []rune(chars)
+	file, err := os.Open(inPath)
+	if err != nil { log.Fatal(err) }
+	sheet, err := png.Decode(file)
+	closeErr := file.Close()
+	if err != nil { log.Fatal(err) }
+	if closeErr != nil { log.Fatal(closeErr) }
+	grid, notes, err := newSheetGrid(sheet, cellWidth, cellHeight, *gapFlag)
+	if err != nil { log.Fatal(err) }
+	for _, note := range notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+	fmt.Printf("Sheet: %d cells (%dx%d), %d characters\n", grid.NumCells(), grid.cols, grid.rows, len(codePoints))
+	if len(codePoints) > grid.NumCells() {
+		log.Fatalf("%d characters listed, but the sheet only has %d cells", len(codePoints), grid.NumCells())
+	}
+
+	// extract glyphs
+	glyphs, warnings := extractGlyphs(grid, codePoints, trimMode)
+	for _, warning := range warnings {
+		warning.print()
+	}
+	if len(glyphs) == 0 { log.Fatal("no glyphs to convert") }
+
+	// derive metrics
+	metrics, notes := deriveMetrics(glyphs, cellHeight, *baselineFlag, *lineGapFlag)
+	for _, note := range notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+
+	// build ggfnt font, with the notdef glyph first
+	notdef := &sheetGlyph{ mask: fontconv.NotdefMask(metrics.UppercaseAscent) }
+	notdef.advance = notdef.mask.Rect.Dx() + 1
+	if trimMode == TrimNone { notdef.advance = max(notdef.advance, cellWidth) }
+	glyphOrder := append([]*sheetGlyph{ notdef }, glyphs...)
+	fontBuilder, err := buildFont(inPath, glyphOrder, metrics)
+	if err != nil { log.Fatal(err) }
+
+	// export and verify
+	var buffer bytes.Buffer
+	err = fontBuilder.Export(&buffer)
+	if err != nil { log.Fatal(err) }
+	err = os.WriteFile(outPath, buffer.Bytes(), 0644)
+	if err != nil { log.Fatal(err) }
+	err = verify(outPath, glyphOrder)
+	if err != nil { log.Fatalf("verification failed: %s", err) }
+	absPath, err := filepath.Abs(outPath)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output font: %s\n", absPath)
+}
+
+// A glyph taken from a sheet cell.
+type sheetGlyph struct {
+	codePoint rune
+	cell int
+	mask *image.Alpha // trimmed, relative to the glyph origin
+	advance int
+}
+
+// A list of cells with the same problem, printed together.
+type cellWarning struct {
+	message string
+	cells []string
+}
+
+func (self *cellWarning) add(cell int, codePoint rune, detail string) {
+	description := fmt.Sprintf("cell %d", cell)
+	if codePoint >= 0 { description += fmt.Sprintf(" (%q)", codePoint) }
+	if detail != "" { description += ": " + detail }
+	self.cells = append(self.cells, description)
+}
+
+func (self *cellWarning) print() {
+	if len(self.cells) == 0 { return }
+	fmt.Printf("Warning: %s: %d\n", self.message, len(self.cells))
+	for i, description := range self.cells {
+		if i == *listFlag {
+			fmt.Printf("  ... and %d more\n", len(self.cells) - i)
+			break
+		}
+		fmt.Printf("  %s\n", description)
+	}
+}
+
+// Converts the cells assigned to the given code points into glyphs,
+// and collects warnings for empty cells (except for spaces), duplicate
+// characters, glyphs touching the cell edges and unassigned cells
+// with ink.
+func extractGlyphs(grid *sheetGrid, codePoints []rune, trimMode string) ([]*sheetGlyph, []*cellWarning) {
+	empty := &cellWarning{ message: "empty cells (mapped to blank glyphs)" }
+	duplicates := &cellWarning{ message: "duplicate characters (only the first cell is used)" }
+	edges := &cellWarning{ message: "glyphs touching the cell edge (might be cut or bleeding into other cells)" }
+	controls := &cellWarning{ message: "control characters (skipped)" }
+	unassigned := &cellWarning{ message: "cells with ink after the last character (ignored)" }
+
+	var glyphs []*sheetGlyph
+	firstCell := make(map[rune]int, len(codePoints))
+	baselineOffset := *baselineFlag + 1 // rows from the cell top to the glyph origin
+	for cell, codePoint := range codePoints {
+		if unicode.IsControl(codePoint) {
+			controls.add(cell, codePoint, "")
+			continue
+		}
+		if first, found := firstCell[codePoint]; found {
+			duplicates.add(cell, codePoint, fmt.Sprintf("also in cell %d", first))
+			continue
+		}
+		firstCell[codePoint] = cell
+
+		cellMask := grid.CellMask(cell)
+		touched := touchedEdges(cellMask)
+		if len(touched) > 0 { edges.add(cell, codePoint, strings.Join(touched, ", ")) }
+		ink := fontconv.Trim(cellMask)
+		glyph := &sheetGlyph{ codePoint: codePoint, cell: cell }
+		if ink.Rect.Empty() {
+			if !unicode.IsSpace(codePoint) { empty.add(cell, codePoint, "") }
+			glyph.mask = ink
+			glyph.advance = grid.cellWidth
+			if trimMode != TrimNone { glyph.advance = max(grid.cellWidth/2, 1) }
+			glyphs = append(glyphs, glyph)
+			continue
+		}
+
+		// place relative to the origin, at the left of the cell on the baseline
+		var shiftX int
+		switch trimMode {
+		case TrimNone:
+			glyph.advance = grid.cellWidth
+		case TrimInk:
+			shiftX = -ink.Rect.Min.X
+			glyph.advance = ink.Rect.Dx() + *spacingFlag
+		case TrimRight:
+			glyph.advance = ink.Rect.Max.X + *spacingFlag
+		}
+		glyph.mask = translate(ink, shiftX, -baselineOffset)
+		glyphs = append(glyphs, glyph)
+	}
+
+	for cell := len(codePoints); cell < grid.NumCells(); cell++ {
+		if fontconv.Trim(grid.CellMask(cell)).Rect.Empty() { continue }
+		unassigned.add(cell, -1, "")
+	}
+	return glyphs, []*cellWarning{ controls, duplicates, empty, edges, unassigned }
+}
+
+func buildFont(inPath string, glyphOrder []*sheetGlyph, metrics fontconv.Metrics) (*builder.Font, error) {
+	fontBuilder := builder.New()
+
+	// header
+	name := *nameFlag
+	if name == "" { name = strings.TrimSuffix(filepath.Base(inPath), filepath.Ext(inPath)) }
+	about := "Converted from " + filepath.Base(inPath) + " with ptxt-examples/ggfnt/fromsheet."
+	for _, err := range []error{
+		fontBuilder.SetName(name),
+		fontBuilder.SetFamily(name),
+		fontBuilder.SetAuthor(*authorFlag),
+		fontBuilder.SetAbout(about),
+	}{
+		if err != nil { return nil, err }
+	}
+
+	// metrics (set before adding glyphs, as they are validated against them)
+	for _, value := range []int{metrics.Ascent, metrics.ExtraAscent, metrics.Descent, metrics.ExtraDescent, metrics.LineGap} {
+		if value > 255 { return nil, fmt.Errorf("metrics exceed 255 pixels") }
+	}
+	fontBuilder.SetAscent(uint8(metrics.Ascent))
+	fontBuilder.SetExtraAscent(uint8(metrics.ExtraAscent))
+	fontBuilder.SetDescent(uint8(metrics.Descent))
+	fontBuilder.SetExtraDescent(uint8(metrics.ExtraDescent))
+	fontBuilder.SetUppercaseAscent(uint8(metrics.UppercaseAscent))
+	fontBuilder.SetMidlineAscent(uint8(metrics.MidlineAscent))
+	fontBuilder.SetHorzInterspacing(0) // spacing is already part of the advances
+	fontBuilder.SetLineGap(uint8(metrics.LineGap))
+	err := fontBuilder.GetMetricsStatus()
+	if err != nil { return nil, err }
+
+	// glyphs and mapping
+	for i, glyph := range glyphOrder {
+		if glyph.advance > 255 { return nil, fmt.Errorf("cell %d advance (%d) out of range", glyph.cell, glyph.advance) }
+		uid, err := fontBuilder.AddGlyph(glyph.mask)
+		if err != nil { return nil, fmt.Errorf("cell %d: %w", glyph.cell, err) }
+		err = fontBuilder.SetGlyphPlacement(uid, ggfnt.GlyphPlacement{
+			Advance: uint8(glyph.advance),
+			TopAdvance: uint8(metrics.Ascent),
+			BottomAdvance: uint8(metrics.Descent),
+			HorzCenter: uint8(glyph.advance/2),
+		})
+		if err != nil { return nil, err }
+		if i == 0 {
+			err = fontBuilder.SetGlyphName(uid, "notdef")
+		} else {
+			err = fontBuilder.Map(glyph.codePoint, uid)
+		}
+		if err != nil { return nil, fmt.Errorf("cell %d: %w", glyph.cell, err) }
+	}
+	return fontBuilder, nil
+}
+
+// Parses the exported font and checks that the notdef glyph, glyph
+// masks, advances and mappings match the sheet data.
+func verify(path string, glyphOrder []*sheetGlyph) error {
+	parsed, err := fontconv.ParseExported(path)
+	if err != nil { return err }
+	err = fontconv.VerifyGlyphCount(parsed, len(glyphOrder))
+	if err != nil { return err }
+	if parsed.Glyphs().FindIndexByName("notdef") != 0 {
+		return fmt.Errorf("notdef glyph not found")
+	}
+	for i, glyph := range glyphOrder {
+		index := ggfnt.GlyphIndex(i)
+		err = fontconv.VerifyGlyph(parsed, index, glyph.mask, glyph.advance)
+		if err != nil { return fmt.Errorf("cell %d %w", glyph.cell, err) }
+		if i == 0 { continue }
+		err = fontconv.VerifyMapping(parsed, glyph.codePoint, index)
+		if err != nil { return err }
+	}
+
+	metrics := parsed.Metrics()
+	fmt.Printf("Font: %s (%d glyphs, including notdef)\n", parsed.Header().Name(), parsed.Glyphs().Count())
+	fmt.Printf("Metrics: ascent %d (+%d), cap line %d, midline %d, descent %d (+%d), line gap %d\n",
+		metrics.Ascent(), metrics.ExtraAscent(), metrics.UppercaseAscent(), metrics.MidlineAscent(),
+		metrics.Descent(), metrics.ExtraDescent(), metrics.LineGap())
+	return nil
+}
+
+// ---- helpers ----
+
+// Parses a "WIDTHxHEIGHT" size with positive values.
+func parseSize(size string) (int, int, error) {
+	var width, height int
+	_, err := fmt.Sscanf(strings.ToLower(size), "%dx%d", &width, &height)
+	if err != nil { return 0, 0, err }
+	if width <= 0 || height <= 0 { return 0, 0, fmt.Errorf("invalid size %q", size) }
+	return width, height, nil
+}
+
+// Returns a copy of the mask moved by the given offset.
+func translate(mask *image.Alpha, dx, dy int) *image.Alpha {
+	return &image.Alpha{
+		Pix: append([]uint8(nil), mask.Pix...),
+		Stride: mask.Stride,
+		Rect: mask.Rect.Add(image.Pt(dx, dy)),
+	}
+}
+
@@ -0,0 +1,27 @@
+package main
+
+import "image"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Derives the vertical metrics from the sample glyphs, falling back
+// to the cell layout when samples are missing. If the line gap is
+// negative, it's set so that the line height matches the cell height.
+func deriveMetrics(glyphs []*sheetGlyph, cellHeight, baseline, lineGap int) (fontconv.Metrics, []string) {
+	masks := make(map[rune]*image.Alpha, len(glyphs))
+	allMasks := make([]*image.Alpha, len(glyphs))
+	for i, glyph := range glyphs {
+		masks[glyph.codePoint] = glyph.mask
+		allMasks[i] = glyph.mask
+	}
+	metrics, notes := fontconv.DeriveMetrics(masks, allMasks, fontconv.Fallback{
+		CapHeight: baseline + 1, // baseline row included
+		Descent: cellHeight - baseline - 1,
+		Source: "the cell layout",
+	})
+	if lineGap < 0 {
+		lineGap = max(cellHeight - (metrics.Ascent + metrics.Descent), 0)
+	}
+	metrics.LineGap = lineGap
+	return metrics, notes
+}
@@ -0,0 +1,92 @@
+package main
+
+import "fmt"
+import "image"
+import "image/color"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// A sprite sheet divided in a grid of equally sized cells, separated
+// by an optional gap. Cells are indexed left to right, top to bottom.
+type sheetGrid struct {
+	sheet image.Image
+	cellWidth, cellHeight, gap int
+	cols, rows int
+	ink func(c color.Color) bool
+}
+
+// Creates the grid and determines how to detect ink: sheets with
+// transparency use the alpha channel, and opaque sheets consider any
+// color different from the top-left pixel to be ink.
+func newSheetGrid(sheet image.Image, cellWidth, cellHeight, gap int) (*sheetGrid, []string, error) {
+	var notes []string
+	bounds := sheet.Bounds()
+	grid := &sheetGrid{ sheet: sheet, cellWidth: cellWidth, cellHeight: cellHeight, gap: gap }
+	grid.cols = (bounds.Dx() + gap)/(cellWidth + gap)
+	grid.rows = (bounds.Dy() + gap)/(cellHeight + gap)
+	if grid.cols == 0 || grid.rows == 0 {
+		return nil, nil, fmt.Errorf("cell size %dx%d is bigger than the sheet (%dx%d)", cellWidth, cellHeight, bounds.Dx(), bounds.Dy())
+	}
+	leftoverX := bounds.Dx() - (grid.cols*(cellWidth + gap) - gap)
+	leftoverY := bounds.Dy() - (grid.rows*(cellHeight + gap) - gap)
+	if leftoverX != 0 || leftoverY != 0 {
+		notes = append(notes, fmt.Sprintf("sheet size isn't a multiple of the cell size, ignoring %d pixels on the right and %d on the bottom", leftoverX, leftoverY))
+	}
+
+	var transparent bool
+	for y := bounds.Min.Y; y < bounds.Max.Y && !transparent; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			_, _, _, a := sheet.At(x, y).RGBA()
+			if a != 0xFFFF {
+				transparent = true
+				break
+			}
+		}
+	}
+	if transparent {
+		grid.ink = func(c color.Color) bool {
+			_, _, _, a := c.RGBA()
+			return a >= 0x8000
+		}
+	} else {
+		background := color.RGBA64Model.Convert(sheet.At(bounds.Min.X, bounds.Min.Y))
+		grid.ink = func(c color.Color) bool {
+			return color.RGBA64Model.Convert(c) != background
+		}
+		notes = append(notes, "the sheet is opaque, using the top-left pixel color as the background")
+	}
+	return grid, notes, nil
+}
+
+func (self *sheetGrid) NumCells() int {
+	return self.cols*self.rows
+}
+
+// Returns the ink of the given cell as a mask with the cell's top-left
+// corner at (0, 0).
+func (self *sheetGrid) CellMask(index int) *image.Alpha {
+	col, row := index % self.cols, index / self.cols
+	origin := self.sheet.Bounds().Min.Add(image.Pt(col*(self.cellWidth + self.gap), row*(self.cellHeight + self.gap)))
+	mask := image.NewAlpha(image.Rect(0, 0, self.cellWidth, self.cellHeight))
+	for y := 0; y < self.cellHeight; y++ {
+		for x := 0; x < self.cellWidth; x++ {
+			if self.ink(self.sheet.At(origin.X + x, origin.Y + y)) {
+				mask.Pix[y*mask.Stride + x] = 255
+			}
+		}
+	}
+	return mask
+}
+
+// Returns the cell edges touched by the ink in the mask, if any.
+func touchedEdges(mask *image.Alpha) []string {
+	inkBounds := fontconv.Trim(mask).Rect
+	if inkBounds.Empty() { return nil }
+	var edges []string
+	if inkBounds.Min.Y == mask.Rect.Min.Y { edges = append(edges, "top") }
+	if inkBounds.Max.Y == mask.Rect.Max.Y { edges = append(edges, "bottom") }
+	if inkBounds.Min.X == mask.Rect.Min.X { edges = append(edges, "left") }
+	if inkBounds.Max.X == mask.Rect.Max.X { edges = append(edges, "right") }
+	return edges
+}
+
@@ -0,0 +1,15 @@
+module github.com/tinne26/ptxt-examples/ggfnt/fromttf
+
+go 1.22.2
+
+require (
+	github.com/tinne26/ggfnt v0.0.0-20240705120847-d849d4c6e12d
+	golang.org/x/image v0.12.0
+)
+
+require (
+	github.com/tinne26/ptxt-examples/internal v0.0.0-00010101000000-000000000000
+	golang.org/x/text v0.13.0 // indirect
+)
+
+replace github.com/tinne26/ptxt-examples/internal => ../../internal
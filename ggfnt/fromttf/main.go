@@ -0,0 +1,350 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "math"
+import "bytes"
+import "errors"
+import "unicode"
+import "strings"
+import "path/filepath"
+
+import "golang.org/x/image/font"
+import "golang.org/x/image/font/sfnt"
+import "golang.org/x/image/font/opentype"
+import "golang.org/x/image/math/fixed"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ggfnt/builder"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Converts TrueType and OpenType pixel fonts to .ggfnt. Glyphs are
+// rasterized at the native pixel size of the font and thresholded to
+// 1-bit masks, and the metrics are derived from sample glyphs.
+//
+// Usage:
+// > go run . [--size 8] [--threshold 128] [--out font.ggfnt] font.ttf
+//
+// The result can be checked with the other examples. Each example is
+// its own module, so run them from their own folders, passing the
+// path to the converted font:
+// > cd ../metrics && go run . --font <path/to/font.ggfnt>
+// > cd ../../gpu/glyphs && go run . --font <path/to/font.ggfnt>
+
+// Kerning pairs are checked for all glyph combinations, which
+// is too slow for big fonts.
+const MaxKerningGlyphs = 2048
+
+var sizeFlag = flag.Float64("size", 0, "native pixel size (pixels per em); detected automatically if 0")
+var thresholdFlag = flag.Int("threshold", 128, "minimum coverage (1-255) for a pixel to be considered set")
+var outFlag = flag.String("out", "", "output .ggfnt path; defaults to the input path with a .ggfnt extension")
+var listFlag = flag.Int("list", 16, "max number of non pixel-aligned glyphs to list")
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() != 1 || *thresholdFlag < 1 || *thresholdFlag > 255 {
+		fmt.Print("Usage: go run . [--size 8] [--threshold 128] [--out font.ggfnt] font.ttf\n")
+		os.Exit(1)
+	}
+	inPath := flag.Arg(0)
+	outPath := *outFlag
+	if outPath == "" {
+		outPath = strings.TrimSuffix(inPath, filepath.Ext(inPath)) + ".ggfnt"
+	}
+
+	// parse ttf
+	data, err := os.ReadFile(inPath)
+	if err != nil { log.Fatal(err) }
+	ttf, err := opentype.Parse(data)
+	if err != nil { log.Fatal(err) }
+
+	// find mapped code points and glyphs (.notdef is always first)
+	mapping, glyphOrder, err := collectMapping(ttf)
+	if err != nil { log.Fatal(err) }
+	if len(mapping) == 0 { log.Fatal("the font doesn't map any code points") }
+
+	// determine native size
+	var size fixed.Int26_6
+	if *sizeFlag > 0 {
+		size = fixed.Int26_6(math.Round(*sizeFlag*64))
+	} else {
+		size, err = detectNativeSize(ttf, glyphOrder[1 : ]) // .notdef often has its own design
+		if err != nil { log.Fatal(err) }
+	}
+	fmt.Printf("Native size: %s pixels per em\n", fmtFixed(size))
+
+	// rasterize glyphs
+	threshold := uint8(*thresholdFlag)
+	rasterized := make(map[sfnt.GlyphIndex]*rasterGlyph, len(glyphOrder))
+	allGlyphs := make([]*rasterGlyph, 0, len(glyphOrder))
+	for _, glyphIndex := range glyphOrder {
+		glyph, err := rasterize(ttf, glyphIndex, size, threshold)
+		if err != nil { log.Fatalf("glyph %d: %s", glyphIndex, err) }
+		rasterized[glyphIndex] = &glyph
+		allGlyphs = append(allGlyphs, &glyph)
+	}
+	byRune := make(map[rune]*rasterGlyph, len(mapping))
+	for _, entry := range mapping {
+		byRune[entry.codePoint] = rasterized[entry.glyph]
+	}
+
+	// derive metrics
+	ttfMetrics, err := ttf.Metrics(nil, size, font.HintingNone)
+	if err != nil { log.Fatal(err) }
+	metrics, notes := deriveMetrics(byRune, allGlyphs[1 : ], ttfMetrics)
+	for _, note := range notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+
+	// build ggfnt font
+	fontBuilder, stats, err := buildFont(ttf, inPath, mapping, glyphOrder, rasterized, metrics, size)
+	if err != nil { log.Fatal(err) }
+	if stats.kerningSkipped {
+		fmt.Printf("Note: too many glyphs, kerning pairs skipped (max %d)\n", MaxKerningGlyphs)
+	}
+	if stats.notdefSynthesized {
+		fmt.Print("Note: .notdef glyph is empty or too big, replaced with a rectangle\n")
+	}
+
+	// report glyphs that aren't pixel-aligned
+	reportMisaligned(mapping, rasterized, stats.misalignedKernings)
+
+	// export and verify
+	var buffer bytes.Buffer
+	err = fontBuilder.Export(&buffer)
+	if err != nil { log.Fatal(err) }
+	err = os.WriteFile(outPath, buffer.Bytes(), 0644)
+	if err != nil { log.Fatal(err) }
+	err = verify(outPath, mapping, glyphOrder, rasterized, stats.kernings)
+	if err != nil { log.Fatalf("verification failed: %s", err) }
+	absPath, err := filepath.Abs(outPath)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output font: %s\n", absPath)
+}
+
+// A code point to glyph mapping entry.
+type mappingEntry struct {
+	codePoint rune
+	glyph sfnt.GlyphIndex
+}
+
+// Scans all non-control code points and returns the mapped ones, in
+// order, together with the list of unique glyphs they use, in order of
+// first use. Glyph zero (.notdef) is always included first.
+func collectMapping(ttf *opentype.Font) ([]mappingEntry, []sfnt.GlyphIndex, error) {
+	var buffer sfnt.Buffer
+	var mapping []mappingEntry
+	glyphOrder := []sfnt.GlyphIndex{ 0 }
+	seen := map[sfnt.GlyphIndex]bool{ 0: true }
+	for codePoint := rune(0); codePoint <= unicode.MaxRune; codePoint++ {
+		if codePoint == 0xD800 { codePoint = 0xE000 } // skip surrogates
+		if unicode.IsControl(codePoint) { continue }
+		glyphIndex, err := ttf.GlyphIndex(&buffer, codePoint)
+		if err != nil { return nil, nil, err }
+		if glyphIndex == 0 { continue }
+		mapping = append(mapping, mappingEntry{ codePoint, glyphIndex })
+		if !seen[glyphIndex] {
+			seen[glyphIndex] = true
+			glyphOrder = append(glyphOrder, glyphIndex)
+		}
+	}
+	return mapping, glyphOrder, nil
+}
+
+// Font building statistics.
+type buildStats struct {
+	notdefSynthesized bool
+	kerningSkipped bool
+	kernings map[[2]sfnt.GlyphIndex]int8
+	misalignedKernings int
+}
+
+func buildFont(ttf *opentype.Font, inPath string, mapping []mappingEntry, glyphOrder []sfnt.GlyphIndex, rasterized map[sfnt.GlyphIndex]*rasterGlyph, metrics fontconv.Metrics, size fixed.Int26_6) (*builder.Font, buildStats, error) {
+	stats := buildStats{ kernings: make(map[[2]sfnt.GlyphIndex]int8) }
+	fontBuilder := builder.New()
+
+	// header
+	var buffer sfnt.Buffer
+	name := ttfName(ttf, &buffer, sfnt.NameIDFull, filepath.Base(inPath))
+	family := ttfName(ttf, &buffer, sfnt.NameIDFamily, name)
+	author := ttfName(ttf, &buffer, sfnt.NameIDDesigner, ttfName(ttf, &buffer, sfnt.NameIDManufacturer, "Unknown"))
+	about := "Converted from " + filepath.Base(inPath) + " with ptxt-examples/ggfnt/fromttf."
+	copyright := ttfName(ttf, &buffer, sfnt.NameIDCopyright, "")
+	if copyright != "" { about += " " + copyright }
+	license := ttfName(ttf, &buffer, sfnt.NameIDLicense, "")
+	if license != "" { about += " " + license }
+	for _, err := range []error{
+		fontBuilder.SetName(fontconv.Truncate(name, 255)),
+		fontBuilder.SetFamily(fontconv.Truncate(family, 255)),
+		fontBuilder.SetAuthor(fontconv.Truncate(author, 255)),
+		fontBuilder.SetAbout(fontconv.Truncate(about, 65535)),
+	}{
+		if err != nil { return nil, stats, err }
+	}
+
+	// metrics (set before adding glyphs, as they are validated against them)
+	for _, value := range []int{metrics.Ascent, metrics.ExtraAscent, metrics.Descent, metrics.ExtraDescent, metrics.LineGap} {
+		if value > 255 { return nil, stats, fmt.Errorf("metrics exceed 255 pixels, is the size right?") }
+	}
+	fontBuilder.SetAscent(uint8(metrics.Ascent))
+	fontBuilder.SetExtraAscent(uint8(metrics.ExtraAscent))
+	fontBuilder.SetDescent(uint8(metrics.Descent))
+	fontBuilder.SetExtraDescent(uint8(metrics.ExtraDescent))
+	fontBuilder.SetUppercaseAscent(uint8(metrics.UppercaseAscent))
+	fontBuilder.SetMidlineAscent(uint8(metrics.MidlineAscent))
+	fontBuilder.SetHorzInterspacing(0) // ttf advances already include spacing
+	fontBuilder.SetLineGap(uint8(metrics.LineGap))
+	err := fontBuilder.GetMetricsStatus()
+	if err != nil { return nil, stats, err }
+
+	// glyphs
+	fullAscent, fullDescent := metrics.Ascent + metrics.ExtraAscent, metrics.Descent + metrics.ExtraDescent
+	if !fontconv.FitsMetrics(rasterized[0].mask, fullAscent, fullDescent) {
+		notdef := fontconv.NotdefMask(metrics.UppercaseAscent)
+		rasterized[0].mask = notdef
+		rasterized[0].advance = fixed.I(notdef.Rect.Dx() + 1)
+		stats.notdefSynthesized = true
+	}
+	uids := make(map[sfnt.GlyphIndex]uint64, len(glyphOrder))
+	for _, glyphIndex := range glyphOrder {
+		glyph := rasterized[glyphIndex]
+		advance := glyph.advance.Round()
+		if advance < 0 || advance > 255 {
+			return nil, stats, fmt.Errorf("glyph %d advance (%d) out of range", glyphIndex, advance)
+		}
+		uid, err := fontBuilder.AddGlyph(glyph.mask)
+		if err != nil { return nil, stats, fmt.Errorf("glyph %d: %w", glyphIndex, err) }
+		err = fontBuilder.SetGlyphPlacement(uid, ggfnt.GlyphPlacement{
+			Advance: uint8(advance),
+			TopAdvance: uint8(metrics.Ascent),
+			BottomAdvance: uint8(metrics.Descent),
+			HorzCenter: uint8(advance/2),
+		})
+		if err != nil { return nil, stats, err }
+		uids[glyphIndex] = uid
+	}
+	err = fontBuilder.SetGlyphName(uids[0], "notdef")
+	if err != nil { return nil, stats, err }
+
+	// mapping
+	for _, entry := range mapping {
+		err = fontBuilder.Map(entry.codePoint, uids[entry.glyph])
+		if err != nil { return nil, stats, fmt.Errorf("mapping U+%04X: %w", entry.codePoint, err) }
+	}
+
+	// kerning (GPOS pair adjustments or 'kern' table)
+	if len(glyphOrder) > MaxKerningGlyphs {
+		stats.kerningSkipped = true
+		return fontBuilder, stats, nil
+	}
+	for _, first := range glyphOrder[1 : ] {
+		for _, second := range glyphOrder[1 : ] {
+			kern, err := ttf.Kern(&buffer, first, second, size, font.HintingNone)
+			if errors.Is(err, sfnt.ErrNotFound) { continue } // no GPOS data for the pair
+			if err != nil { return nil, stats, err }
+			if kern == 0 { continue }
+			if kern & 63 != 0 { stats.misalignedKernings += 1 }
+			value := int8(max(min(kern.Round(), 127), -128))
+			fontBuilder.SetKerningPair(uids[first], uids[second], value)
+			if value != 0 { stats.kernings[[2]sfnt.GlyphIndex{first, second}] = value }
+		}
+	}
+	return fontBuilder, stats, nil
+}
+
+// Lists the glyphs that had partially covered pixels or fractional
+// advances, which means the thresholded result might not match the
+// original design exactly.
+func reportMisaligned(mapping []mappingEntry, rasterized map[sfnt.GlyphIndex]*rasterGlyph, misalignedKernings int) {
+	var misaligned []string
+	reported := make(map[sfnt.GlyphIndex]bool)
+	for _, entry := range mapping {
+		glyph := rasterized[entry.glyph]
+		if glyph.pixelAligned() || reported[entry.glyph] { continue }
+		reported[entry.glyph] = true
+		var problems []string
+		if glyph.partialPixels > 0 {
+			problems = append(problems, fmt.Sprintf("%d partial pixels", glyph.partialPixels))
+		}
+		if glyph.advance & 63 != 0 {
+			problems = append(problems, "fractional advance " + fmtFixed(glyph.advance))
+		}
+		description := fmt.Sprintf("U+%04X %q: %s", entry.codePoint, entry.codePoint, strings.Join(problems, ", "))
+		misaligned = append(misaligned, description)
+	}
+
+	if len(misaligned) == 0 && misalignedKernings == 0 {
+		fmt.Printf("All %d glyphs are pixel-aligned.\n", len(rasterized))
+		return
+	}
+	if len(misaligned) > 0 {
+		fmt.Printf("Glyphs not pixel-aligned: %d of %d (thresholded, check the results)\n", len(misaligned), len(rasterized))
+		for i, description := range misaligned {
+			if i == *listFlag {
+				fmt.Printf("  ... and %d more\n", len(misaligned) - i)
+				break
+			}
+			fmt.Printf("  %s\n", description)
+		}
+	}
+	if misalignedKernings > 0 {
+		fmt.Printf("Kerning pairs with fractional values: %d (rounded)\n", misalignedKernings)
+	}
+}
+
+// Parses the exported font and checks that glyph masks, advances,
+// mappings and kerning pairs match the converted data.
+func verify(path string, mapping []mappingEntry, glyphOrder []sfnt.GlyphIndex, rasterized map[sfnt.GlyphIndex]*rasterGlyph, kernings map[[2]sfnt.GlyphIndex]int8) error {
+	parsed, err := fontconv.ParseExported(path)
+	if err != nil { return err }
+	err = fontconv.VerifyGlyphCount(parsed, len(glyphOrder))
+	if err != nil { return err }
+	indices := make(map[sfnt.GlyphIndex]ggfnt.GlyphIndex, len(glyphOrder))
+	for i, glyphIndex := range glyphOrder {
+		index := ggfnt.GlyphIndex(i)
+		indices[glyphIndex] = index
+		glyph := rasterized[glyphIndex]
+		err = fontconv.VerifyGlyph(parsed, index, glyph.mask, glyph.advance.Round())
+		if err != nil { return fmt.Errorf("glyph %d %w", glyphIndex, err) }
+	}
+	for _, entry := range mapping {
+		err = fontconv.VerifyMapping(parsed, entry.codePoint, indices[entry.glyph])
+		if err != nil { return err }
+	}
+
+	if int(parsed.Kerning().NumPairs()) != len(kernings) {
+		return fmt.Errorf("expected %d kerning pairs, found %d", len(kernings), parsed.Kerning().NumPairs())
+	}
+	for pair, value := range kernings {
+		if parsed.Kerning().Get(indices[pair[0]], indices[pair[1]]) != value {
+			return fmt.Errorf("glyphs %d, %d kerning mismatch", pair[0], pair[1])
+		}
+	}
+
+	metrics := parsed.Metrics()
+	fmt.Printf("Font: %s (%d glyphs, %d mapped code points, %d kerning pairs)\n", parsed.Header().Name(), parsed.Glyphs().Count(), len(mapping), len(kernings))
+	fmt.Printf("Metrics: ascent %d (+%d), cap line %d, midline %d, descent %d (+%d), line gap %d\n",
+		metrics.Ascent(), metrics.ExtraAscent(), metrics.UppercaseAscent(), metrics.MidlineAscent(),
+		metrics.Descent(), metrics.ExtraDescent(), metrics.LineGap())
+	return nil
+}
+
+// ---- helpers ----
+
+func ttfName(ttf *opentype.Font, buffer *sfnt.Buffer, id sfnt.NameID, fallback string) string {
+	name, err := ttf.Name(buffer, id)
+	if err != nil { return fallback }
+	name = strings.Join(strings.FieldsFunc(name, unicode.IsControl), " ") // ggfnt doesn't allow line breaks
+	name = strings.TrimSpace(name)
+	if name == "" { return fallback }
+	return name
+}
+
+func fmtFixed(value fixed.Int26_6) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", float64(value)/64), "0"), ".")
+}
+
@@ -0,0 +1,29 @@
+package main
+
+import "image"
+
+import "golang.org/x/image/font"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Derives the vertical metrics from the sample glyphs, using the
+// ttf metrics only as a fallback. The ttf line height includes the
+// line gap.
+func deriveMetrics(glyphs map[rune]*rasterGlyph, allGlyphs []*rasterGlyph, ttfMetrics font.Metrics) (fontconv.Metrics, []string) {
+	masks := make(map[rune]*image.Alpha, len(glyphs))
+	for codePoint, glyph := range glyphs {
+		masks[codePoint] = glyph.mask
+	}
+	allMasks := make([]*image.Alpha, len(allGlyphs))
+	for i, glyph := range allGlyphs {
+		allMasks[i] = glyph.mask
+	}
+	metrics, notes := fontconv.DeriveMetrics(masks, allMasks, fontconv.Fallback{
+		CapHeight: ttfMetrics.CapHeight.Round(),
+		Midline: ttfMetrics.XHeight.Round(),
+		Descent: ttfMetrics.Descent.Round(),
+		Source: "the ttf metrics",
+	})
+	metrics.LineGap = max(ttfMetrics.Height.Round() - (metrics.Ascent + metrics.Descent), 0)
+	return metrics, notes
+}
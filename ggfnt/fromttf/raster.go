@@ -0,0 +1,167 @@
+package main
+
+import "fmt"
+import "math"
+import "image"
+import "image/draw"
+
+import "golang.org/x/image/font"
+import "golang.org/x/image/font/sfnt"
+import "golang.org/x/image/font/opentype"
+import "golang.org/x/image/math/fixed"
+import "golang.org/x/image/vector"
+
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Sizes above this are not considered pixel sizes when
+// detecting the native size of a font.
+const MaxNativeSize = 128
+
+// Ratio of glyphs that must be aligned to the pixel grid when
+// detecting the native size of a font.
+const MinOnGridRatio = 0.95
+
+// Coverage values within this distance of 0 or 255 are not
+// considered partial, as rasterizers can have rounding errors.
+const CoverageTolerance = 2
+
+// A glyph rasterized at the font's native pixel size.
+type rasterGlyph struct {
+	index sfnt.GlyphIndex
+	mask *image.Alpha // thresholded, relative to the glyph origin
+	advance fixed.Int26_6
+	partialPixels int // pixels with coverage that's neither empty nor full
+}
+
+func (self *rasterGlyph) pixelAligned() bool {
+	return self.partialPixels == 0 && self.advance & 63 == 0
+}
+
+// Finds the pixel size at which the outline points and advances of
+// the given glyphs land on whole pixels, in 26.6 fixed point. The grid
+// is the biggest divisor shared by the coordinates of most glyphs, so
+// a few misaligned glyphs don't make the detected size explode. Those
+// glyphs will be reported later, when rasterizing.
+func detectNativeSize(ttf *opentype.Font, glyphs []sfnt.GlyphIndex) (fixed.Int26_6, error) {
+	// compute the divisor of each glyph's coordinates, in font units * 64
+	var buffer sfnt.Buffer
+	unitsPerEm := ttf.UnitsPerEm()
+	ppem := fixed.I(int(unitsPerEm))
+	divisors := make([]int32, 0, len(glyphs))
+	for _, glyphIndex := range glyphs {
+		var divisor int32
+		var accumulate = func(value fixed.Int26_6) {
+			if value != 0 { divisor = gcd(divisor, abs(int32(value))) }
+		}
+		advance, err := ttf.GlyphAdvance(&buffer, glyphIndex, ppem, font.HintingNone)
+		if err != nil { return 0, err }
+		accumulate(advance)
+		segments, err := ttf.LoadGlyph(&buffer, glyphIndex, ppem, nil)
+		if err != nil { return 0, err }
+		for _, segment := range segments {
+			for _, point := range segment.Args[ : segmentNumArgs(segment.Op)] {
+				accumulate(point.X)
+				accumulate(point.Y)
+			}
+		}
+		if divisor != 0 { divisors = append(divisors, divisor) }
+	}
+	if len(divisors) == 0 { return 0, fmt.Errorf("can't detect native size, all glyphs are empty") }
+
+	// pick the biggest candidate that divides enough glyph divisors
+	minMatches := int(math.Ceil(float64(len(divisors))*MinOnGridRatio))
+	var pixel int32
+	for _, candidate := range divisors {
+		if candidate <= pixel { continue }
+		var matches int
+		for _, divisor := range divisors {
+			if divisor % candidate == 0 { matches += 1 }
+		}
+		if matches >= minMatches { pixel = candidate }
+	}
+	if pixel == 0 {
+		return 0, fmt.Errorf("can't detect native size (outlines don't share a pixel grid), use --size")
+	}
+
+	// one pixel is pixel/64 font units, so ppem = unitsPerEm*64/pixel
+	size := fixed.Int26_6((int64(unitsPerEm)*64*64 + int64(pixel)/2)/int64(pixel))
+	if size > fixed.I(MaxNativeSize) || size <= 0 {
+		return 0, fmt.Errorf("can't detect native size (outlines don't share a pixel grid), use --size")
+	}
+	return size, nil
+}
+
+// Rasterizes the given glyph at the given size, thresholding the
+// coverage to a 1-bit mask.
+func rasterize(ttf *opentype.Font, glyphIndex sfnt.GlyphIndex, size fixed.Int26_6, threshold uint8) (rasterGlyph, error) {
+	var buffer sfnt.Buffer
+	bounds, advance, err := ttf.GlyphBounds(&buffer, glyphIndex, size, font.HintingNone)
+	if err != nil { return rasterGlyph{}, err }
+	glyph := rasterGlyph{ index: glyphIndex, advance: advance }
+	rect := image.Rect(bounds.Min.X.Floor(), bounds.Min.Y.Floor(), bounds.Max.X.Ceil(), bounds.Max.Y.Ceil())
+	if rect.Empty() {
+		glyph.mask = image.NewAlpha(image.Rectangle{})
+		return glyph, nil
+	}
+
+	// draw outline segments, relative to the rect's top-left corner
+	segments, err := ttf.LoadGlyph(&buffer, glyphIndex, size, nil)
+	if err != nil { return rasterGlyph{}, err }
+	rasterizer := vector.NewRasterizer(rect.Dx(), rect.Dy())
+	rasterizer.DrawOp = draw.Src
+	ox, oy := float32(rect.Min.X), float32(rect.Min.Y)
+	var pt = func(point fixed.Point26_6) (float32, float32) {
+		return float32(point.X)/64 - ox, float32(point.Y)/64 - oy
+	}
+	for _, segment := range segments {
+		args := segment.Args
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			rasterizer.ClosePath() // (no-op if already closed)
+			rasterizer.MoveTo(pt(args[0]))
+		case sfnt.SegmentOpLineTo:
+			rasterizer.LineTo(pt(args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x1, y1 := pt(args[0])
+			x2, y2 := pt(args[1])
+			rasterizer.QuadTo(x1, y1, x2, y2)
+		case sfnt.SegmentOpCubeTo:
+			x1, y1 := pt(args[0])
+			x2, y2 := pt(args[1])
+			x3, y3 := pt(args[2])
+			rasterizer.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	rasterizer.ClosePath()
+	coverage := image.NewAlpha(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	rasterizer.Draw(coverage, coverage.Bounds(), image.Opaque, image.Point{})
+
+	// threshold and count partial pixels
+	glyph.mask = image.NewAlpha(rect)
+	for i, value := range coverage.Pix {
+		if value > CoverageTolerance && value < 255 - CoverageTolerance {
+			glyph.partialPixels += 1
+		}
+		if value >= threshold { glyph.mask.Pix[i] = 255 }
+	}
+	glyph.mask = fontconv.Trim(glyph.mask)
+	return glyph, nil
+}
+
+func segmentNumArgs(op sfnt.SegmentOp) int {
+	switch op {
+	case sfnt.SegmentOpQuadTo: return 2
+	case sfnt.SegmentOpCubeTo: return 3
+	default: return 1
+	}
+}
+
+func gcd(a, b int32) int32 {
+	for b != 0 { a, b = b, a % b }
+	return a
+}
+
+func abs(value int32) int32 {
+	if value < 0 { return -value }
+	return value
+}
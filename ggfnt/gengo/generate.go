@@ -0,0 +1,180 @@
+package main
+
+import "fmt"
+import "strings"
+import "go/token"
+import "go/format"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/ggfntraw"
+
+// Data for the generated package.
+type fontPackage struct {
+	name string // package name
+	fontFile string // embedded file name
+	font *ggfnt.Font
+	consts []constGroup
+}
+
+// A block of related constants, separated by blank lines in the
+// generated code.
+type constGroup struct {
+	comment string
+	lines []constLine
+}
+
+type constLine struct {
+	ident string
+	value string
+}
+
+func newFontPackage(name, fontFile string, font *ggfnt.Font) (*fontPackage, error) {
+	pkg := &fontPackage{ name: name, fontFile: fontFile, font: font }
+	idents := make(map[string]string) // ident => origin, to detect collisions
+	var addConst = func(group *constGroup, ident, value, origin string) error {
+		prevOrigin, taken := idents[ident]
+		if taken { return fmt.Errorf("%s and %s both map to the Go identifier %s", prevOrigin, origin, ident) }
+		idents[ident] = origin
+		group.lines = append(group.lines, constLine{ ident, value })
+		return nil
+	}
+	for _, reserved := range []string{ "Font", "Release" } {
+		idents[reserved] = "the " + reserved + "() function"
+	}
+
+	// settings and their options
+	var err error
+	font.Settings().Each(func(key ggfnt.SettingKey, name string) {
+		if err != nil { return }
+		ident := goIdent(name)
+		origin := fmt.Sprintf("setting %q", name)
+		group := constGroup{ comment: fmt.Sprintf("Setting %q.", name) }
+		err = addConst(&group, ident + "SettingKey", fmt.Sprintf("ggfnt.SettingKey(%d)", key), origin)
+		if err != nil { return }
+		err = addConst(&group, ident + "SettingName", fmt.Sprintf("%q", name), origin)
+		if err != nil { return }
+		for option := uint8(0); option < font.Settings().GetNumOptions(key); option++ {
+			optionName := font.Settings().GetOptionName(key, option)
+			optionOrigin := fmt.Sprintf("setting %q option %q", name, optionName)
+			err = addConst(&group, ident + "Option" + goIdent(optionName), fmt.Sprintf("uint8(%d)", option), optionOrigin)
+			if err != nil { return }
+		}
+		pkg.consts = append(pkg.consts, group)
+	})
+	if err != nil { return nil, err }
+
+	// named glyphs, with their code points when unconditionally mapped
+	runes := unconditionalRunes(font)
+	group := constGroup{ comment: "Named glyphs, and the code points that map to them." }
+	for _, glyph := range namedGlyphs(font) {
+		ident := goIdent(glyph.name)
+		origin := fmt.Sprintf("glyph %q", glyph.name)
+		err = addConst(&group, ident, fmt.Sprintf("ggfnt.GlyphIndex(%d)", glyph.index), origin)
+		if err != nil { return nil, err }
+		codePoint, found := runes[glyph.index]
+		if !found { continue }
+		err = addConst(&group, ident + "Rune", fmt.Sprintf("%q", codePoint), origin)
+		if err != nil { return nil, err }
+	}
+	if len(group.lines) > 0 { pkg.consts = append(pkg.consts, group) }
+	return pkg, nil
+}
+
+// Returns the gofmt formatted source code of the package.
+func (self *fontPackage) Source(sourceName string) ([]byte, error) {
+	var code strings.Builder
+	header := self.font.Header()
+	fmt.Fprintf(&code, "// Code generated by ggfnt/gengo from %s; DO NOT EDIT.\n\n", sourceName)
+	fmt.Fprintf(&code, "// Package %s embeds the %q font", self.name, header.Name())
+	if header.Author() != "" { fmt.Fprintf(&code, " by %s", header.Author()) }
+	fmt.Fprintf(&code, " (v%d.%d).\n", header.VersionMajor(), header.VersionMinor())
+	fmt.Fprintf(&code, "package %s\n\n", self.name)
+	code.WriteString("import (\n\t\"bytes\"\n\t_ \"embed\"\n\n\t\"github.com/tinne26/ggfnt\"\n)\n\n")
+	fmt.Fprintf(&code, "//go:embed %s\nvar data []byte\n\n", self.fontFile)
+	code.WriteString("var cachedFont *ggfnt.Font\n\n")
+
+	for _, group := range self.consts {
+		fmt.Fprintf(&code, "// %s\nconst (\n", group.comment)
+		for _, line := range group.lines {
+			fmt.Fprintf(&code, "\t%s = %s\n", line.ident, line.value)
+		}
+		code.WriteString(")\n\n")
+	}
+
+	code.WriteString("// Returns the font, parsing it on the first call.\n")
+	code.WriteString("func Font() *ggfnt.Font {\n")
+	code.WriteString("\tif cachedFont == nil {\n")
+	code.WriteString("\t\tfont, err := ggfnt.Parse(bytes.NewReader(data))\n")
+	code.WriteString("\t\tif err != nil {\n\t\t\tpanic(err)\n\t\t}\n")
+	code.WriteString("\t\tcachedFont = font\n\t}\n\treturn cachedFont\n}\n\n")
+	code.WriteString("// Releases the parsed font. Font() will parse it again if needed.\n")
+	code.WriteString("func Release() { cachedFont = nil }\n")
+	return format.Source([]byte(code.String()))
+}
+
+// ---- raw data access ----
+
+type namedGlyph struct {
+	name string
+	index ggfnt.GlyphIndex
+}
+
+// Returns the named glyphs, in the font's order (sorted by name).
+func namedGlyphs(font *ggfnt.Font) []namedGlyph {
+	var glyphs []namedGlyph
+	ggfntraw.EachNamedGlyph(font, func(glyphIndex ggfnt.GlyphIndex, name string) {
+		glyphs = append(glyphs, namedGlyph{ name: name, index: glyphIndex })
+	})
+	return glyphs
+}
+
+// Returns the lowest code point mapped unconditionally to each glyph.
+func unconditionalRunes(font *ggfnt.Font) map[ggfnt.GlyphIndex]rune {
+	runes := make(map[ggfnt.GlyphIndex]rune)
+	ggfntraw.EachMappingEntry(font, func(entry ggfntraw.MappingEntry) {
+		if entry.SwitchType() != ggfntraw.SwitchUnconditional { return }
+		glyphIndex := entry.GlyphGroups()[0].Glyphs[0]
+		prev, found := runes[glyphIndex]
+		if !found || entry.CodePoint < prev { runes[glyphIndex] = entry.CodePoint }
+	})
+	return runes
+}
+
+// ---- helpers ----
+
+// Converts a ggfnt name ("zero-disambiguation-mark") to an exported
+// Go identifier ("ZeroDisambiguationMark"). ggfnt names are ascii and
+// start with a letter, but anything else is also handled.
+func goIdent(name string) string {
+	var ident strings.Builder
+	upperNext := true
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+			if upperNext { r -= 'a' - 'A' }
+			ident.WriteRune(r)
+			upperNext = false
+		case r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			ident.WriteRune(r)
+			upperNext = false
+		default: // separators
+			upperNext = true
+		}
+	}
+	if ident.Len() == 0 || !(ident.String()[0] >= 'A' && ident.String()[0] <= 'Z') {
+		return "X" + ident.String()
+	}
+	return ident.String()
+}
+
+// Converts the font name to a valid package name ("Jammy 5D2" => "jammy5d2").
+func packageName(fontName string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' { return r }
+		if r >= 'A' && r <= 'Z' { return r + ('a' - 'A') }
+		return -1
+	}, fontName)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') { return "font" + name }
+	if token.IsKeyword(name) { return name + "font" }
+	return name
+}
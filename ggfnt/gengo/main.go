@@ -0,0 +1,94 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "bytes"
+import "path/filepath"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// Generates a Go package that embeds a ggfnt font, like the packages
+// in ggfnt-fonts (github.com/tinne26/ggfnt-fonts/jammy). The package
+// includes a Font() accessor and constants for:
+//  - Each setting key and name (NumericStyleSettingKey and
+//    NumericStyleSettingName) and each of the setting options
+//    (NumericStyleOptionCompact), to be used with strand.SetSetting().
+//  - Each named glyph (Notdef), and its code point if the glyph is
+//    unconditionally mapped (NotdefRune).
+//
+// The output is gofmt formatted and deterministic: regenerating the
+// package from the same font produces the same files, so it can be
+// checked into version control and regenerated on CI.
+//
+// Usage:
+// > go run . --font myfont.ggfnt [--package myfont] [--out path/to/myfont]
+//
+// This writes path/to/myfont/font.go and path/to/myfont/myfont.ggfnt.
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var packageFlag = flag.String("package", "", "package name; defaults to the lowercase font name")
+var outFlag = flag.String("out", "", "output directory; defaults to the package name")
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run . --font myfont.ggfnt [--package myfont] [--out path/to/myfont]\n")
+		os.Exit(1)
+	}
+
+	// load font
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Font loaded: %s\n", font.Header().Name())
+	name := *packageFlag
+	if name == "" { name = packageName(font.Header().Name()) }
+	if packageName(name) != name { log.Fatalf("invalid package name %q (try %q)", name, packageName(name)) }
+	outDir := *outFlag
+	if outDir == "" { outDir = name }
+
+	// export the font and make sure the embedded data parses
+	var buffer bytes.Buffer
+	err = font.Export(&buffer)
+	if err != nil { log.Fatal(err) }
+	fontData := buffer.Bytes()
+	_, err = ggfnt.Parse(bytes.NewReader(fontData))
+	if err != nil { log.Fatalf("exported font failed to parse: %s", err) }
+
+	// generate code
+	fontFile := name + fontreg.Ext
+	pkg, err := newFontPackage(name, fontFile, font)
+	if err != nil { log.Fatal(err) }
+	source, err := pkg.Source(sourceName(*fontName))
+	if err != nil { log.Fatalf("invalid generated code: %s", err) }
+
+	// write files
+	err = os.MkdirAll(outDir, 0755)
+	if err != nil { log.Fatal(err) }
+	writeOutput(filepath.Join(outDir, fontFile), fontData)
+	writeOutput(filepath.Join(outDir, "font.go"), source)
+	var numConsts int
+	for _, group := range pkg.consts { numConsts += len(group.lines) }
+	fmt.Printf("Package %s: %d constants\n", name, numConsts)
+}
+
+// ---- helpers ----
+
+// Name of the font source for the generated code header. File paths
+// are reduced to their base name, so the output doesn't depend on
+// the directory the generator was run from.
+func sourceName(nameOrPath string) string {
+	if fontreg.IsRegistered(nameOrPath) { return nameOrPath }
+	return filepath.Base(nameOrPath)
+}
+
+func writeOutput(path string, data []byte) {
+	err := os.WriteFile(path, data, 0644)
+	if err != nil { log.Fatal(err) }
+	absPath, err := filepath.Abs(path)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output: %s\n", absPath)
+}
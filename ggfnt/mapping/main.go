@@ -0,0 +1,266 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "strconv"
+import "strings"
+import "encoding/json"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/ggfntraw"
+
+// Lists how the code points of a font map to glyphs: glyph indices,
+// glyph names and, for conditional mappings, the glyphs selected for
+// each combination of the settings the mapping depends on.
+//
+// Conditional mappings have to evaluate their settings on every lookup
+// (unless the switch is cached), which is what ptxt's
+// strand.Mapping().ConfigureCache() helps with. See gpu/settingmap.
+//
+// Usage:
+// > go run . [--font jammy|font.ggfnt] [--setting numeric-style=compact] [--json]
+//
+// --setting can be repeated, and takes an option name or index. Only the
+// combinations matching the given settings are listed.
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var jsonFlag = flag.Bool("json", false, "output JSON instead of text")
+var settingFlags settingList
+
+func init() {
+	flag.Var(&settingFlags, "setting", "only list mappings for the given setting value, as key=value (can be repeated)")
+}
+
+type settingList []string
+func (self *settingList) String() string { return strings.Join(*self, ", ") }
+func (self *settingList) Set(value string) error {
+	*self = append(*self, value)
+	return nil
+}
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run . [--font jammy|font.ggfnt] [--setting key=value]... [--json]\n")
+		os.Exit(1)
+	}
+
+	// load font and parse setting filters
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	filter, err := parseSettingFilter(font, settingFlags)
+	if err != nil { log.Fatal(err) }
+
+	// resolve mappings
+	switches := readSwitches(font)
+	glyphNames := readGlyphNames(font)
+	entries := readMapping(font)
+	mappings := make([]codePointMapping, 0, len(entries))
+	var numConditional int
+	usedSwitches := make(map[uint8]bool)
+	for _, entry := range entries {
+		mapping, err := resolveMapping(font, entry, switches, filter)
+		if err != nil { log.Fatalf("U+%04X: %s", entry.codePoint, err) }
+		mappings = append(mappings, mapping)
+		if len(mapping.settings) > 0 {
+			numConditional += 1
+			usedSwitches[entry.switchType] = true
+		}
+	}
+
+	if *jsonFlag {
+		printJSON(mappings, glyphNames)
+		return
+	}
+	fmt.Printf("Font: %s, %d glyphs, %d mapped code points (%d conditional, using %d switch types)\n",
+		font.Header().Name(), font.Glyphs().Count(), len(entries), numConditional, len(usedSwitches))
+	for _, mapping := range mappings {
+		mapping.Print(glyphNames)
+	}
+}
+
+// A code point and the glyphs it maps to for each combination of the
+// relevant settings (only one case for unconditional mappings).
+type codePointMapping struct {
+	codePoint rune
+	settings []ggfnt.SettingKey // relevant settings
+	settingNames []string
+	cases []mappingCase
+}
+
+type mappingCase struct {
+	options []string // "key=option", for each relevant setting
+	optionNames map[string]string // setting name => option name
+	group ggfntraw.GlyphGroup
+}
+
+func resolveMapping(font *ggfnt.Font, entry mappingEntry, switches [][]ggfnt.SettingKey, filter map[ggfnt.SettingKey]uint8) (codePointMapping, error) {
+	mapping := codePointMapping{ codePoint: entry.codePoint }
+	if entry.switchType >= 254 {
+		if len(entry.groups) != 1 { return mapping, fmt.Errorf("expected 1 glyph group, found %d", len(entry.groups)) }
+		mapping.cases = []mappingCase{ { group: entry.groups[0] } }
+		return mapping, nil
+	}
+	if int(entry.switchType) >= len(switches) { return mapping, fmt.Errorf("invalid switch type %d", entry.switchType) }
+
+	// iterate all combinations of the switch settings, with the last
+	// setting changing the fastest, which matches the case order
+	mapping.settings = switches[entry.switchType]
+	numOptions := make([]uint8, len(mapping.settings))
+	numCases := 1
+	for i, key := range mapping.settings {
+		mapping.settingNames = append(mapping.settingNames, settingName(font, key))
+		numOptions[i] = font.Settings().GetNumOptions(key)
+		numCases *= int(numOptions[i])
+	}
+	if numCases != len(entry.groups) {
+		return mapping, fmt.Errorf("switch type %d has %d cases, but %d glyph groups were found", entry.switchType, numCases, len(entry.groups))
+	}
+
+	options := make([]uint8, len(mapping.settings))
+	for caseIndex := 0; caseIndex < numCases; caseIndex++ {
+		remaining := caseIndex
+		for i := len(options) - 1; i >= 0; i-- {
+			options[i] = uint8(remaining % int(numOptions[i]))
+			remaining /= int(numOptions[i])
+		}
+		if !matchesFilter(mapping.settings, options, filter) { continue }
+
+		mcase := mappingCase{ group: entry.groups[caseIndex], optionNames: make(map[string]string) }
+		for i, key := range mapping.settings {
+			optionName := font.Settings().GetOptionName(key, options[i])
+			mcase.options = append(mcase.options, mapping.settingNames[i] + "=" + optionName)
+			mcase.optionNames[mapping.settingNames[i]] = optionName
+		}
+		mapping.cases = append(mapping.cases, mcase)
+	}
+	return mapping, nil
+}
+
+func (self *codePointMapping) Print(glyphNames map[ggfnt.GlyphIndex]string) {
+	codePoint := fmt.Sprintf("U+%04X %s", self.codePoint, strconv.QuoteRune(self.codePoint))
+	if len(self.settings) == 0 {
+		fmt.Printf("  %-12s -> %s\n", codePoint, formatGroup(self.cases[0].group, glyphNames))
+		return
+	}
+	fmt.Printf("  %-12s depends on %s\n", codePoint, strings.Join(self.settingNames, ", "))
+	for _, mcase := range self.cases {
+		fmt.Printf("      %s -> %s\n", strings.Join(mcase.options, ", "), formatGroup(mcase.group, glyphNames))
+	}
+}
+
+// ---- json ----
+
+type jsonMapping struct {
+	CodePoint string `json:"codePoint"`
+	Char string `json:"char"`
+	Settings []string `json:"settings,omitempty"`
+	Cases []jsonCase `json:"cases"`
+}
+
+type jsonCase struct {
+	Settings map[string]string `json:"settings,omitempty"`
+	Glyphs []jsonGlyph `json:"glyphs"`
+	AnimFlags uint8 `json:"animFlags,omitempty"`
+}
+
+type jsonGlyph struct {
+	Index ggfnt.GlyphIndex `json:"index"`
+	Name string `json:"name,omitempty"`
+}
+
+func printJSON(mappings []codePointMapping, glyphNames map[ggfnt.GlyphIndex]string) {
+	out := make([]jsonMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		jmapping := jsonMapping{
+			CodePoint: fmt.Sprintf("U+%04X", mapping.codePoint),
+			Char: string(mapping.codePoint),
+			Settings: mapping.settingNames,
+		}
+		for _, mcase := range mapping.cases {
+			jcase := jsonCase{ AnimFlags: mcase.group.Flags }
+			if len(mcase.optionNames) > 0 { jcase.Settings = mcase.optionNames }
+			for _, glyphIndex := range mcase.group.Glyphs {
+				jcase.Glyphs = append(jcase.Glyphs, jsonGlyph{ glyphIndex, glyphNames[glyphIndex] })
+			}
+			jmapping.Cases = append(jmapping.Cases, jcase)
+		}
+		out = append(out, jmapping)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "\t")
+	err := encoder.Encode(out)
+	if err != nil { log.Fatal(err) }
+}
+
+// ---- helpers ----
+
+// Parses "key=value" settings, where the key is a setting name, and
+// the value is an option name or index.
+func parseSettingFilter(font *ggfnt.Font, settings []string) (map[ggfnt.SettingKey]uint8, error) {
+	filter := make(map[ggfnt.SettingKey]uint8)
+	for _, setting := range settings {
+		name, value, found := strings.Cut(setting, "=")
+		if !found { return nil, fmt.Errorf("invalid --setting %q, expected key=value", setting) }
+		key, found := findSetting(font, strings.TrimSpace(name))
+		if !found { return nil, fmt.Errorf("font has no setting %q", name) }
+		option, found := findOption(font, key, strings.TrimSpace(value))
+		if !found { return nil, fmt.Errorf("setting %q has no option %q", name, value) }
+		filter[key] = option
+	}
+	return filter, nil
+}
+
+func findSetting(font *ggfnt.Font, name string) (ggfnt.SettingKey, bool) {
+	var key ggfnt.SettingKey
+	var found bool
+	font.Settings().Each(func(settingKey ggfnt.SettingKey, settingName string) {
+		if settingName == name { key, found = settingKey, true }
+	})
+	return key, found
+}
+
+func findOption(font *ggfnt.Font, key ggfnt.SettingKey, value string) (uint8, bool) {
+	numOptions := font.Settings().GetNumOptions(key)
+	for option := uint8(0); option < numOptions; option++ {
+		if font.Settings().GetOptionName(key, option) == value { return option, true }
+	}
+	index, err := strconv.Atoi(value)
+	if err == nil && index >= 0 && index < int(numOptions) { return uint8(index), true }
+	return 0, false
+}
+
+func settingName(font *ggfnt.Font, key ggfnt.SettingKey) string {
+	var name string
+	font.Settings().Each(func(settingKey ggfnt.SettingKey, settingName string) {
+		if settingKey == key { name = settingName }
+	})
+	return name
+}
+
+func matchesFilter(keys []ggfnt.SettingKey, options []uint8, filter map[ggfnt.SettingKey]uint8) bool {
+	for i, key := range keys {
+		option, filtered := filter[key]
+		if filtered && option != options[i] { return false }
+	}
+	return true
+}
+
+// Formats a glyph group as "27 (name)", or "{27 28 29} (anim flags 0b0001)"
+// for groups with multiple glyphs.
+func formatGroup(group ggfntraw.GlyphGroup, glyphNames map[ggfnt.GlyphIndex]string) string {
+	glyphs := make([]string, 0, len(group.Glyphs))
+	for _, glyphIndex := range group.Glyphs {
+		glyph := strconv.Itoa(int(glyphIndex))
+		name, found := glyphNames[glyphIndex]
+		if found { glyph += " (" + name + ")" }
+		glyphs = append(glyphs, glyph)
+	}
+	if len(glyphs) == 1 { return glyphs[0] }
+	return fmt.Sprintf("{%s} (anim flags 0b%04b)", strings.Join(glyphs, " "), group.Flags)
+}
@@ -0,0 +1,60 @@
+package main
+
+import "encoding/binary"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/ggfntraw"
+
+// Raw data access. ggfnt only resolves mappings for a specific set of
+// settings, and misreads range glyph groups on conditional mappings,
+// so we read the mapping and switch tables directly from the font data.
+
+// A mapping entry. Switch type 255 is an unconditional mapping, 254 a
+// single glyph group without settings, and anything else is a switch
+// with one glyph group per case.
+type mappingEntry struct {
+	codePoint rune
+	switchType uint8
+	groups []ggfntraw.GlyphGroup
+}
+
+func readMapping(font *ggfnt.Font) []mappingEntry {
+	var entries []mappingEntry
+	ggfntraw.EachMappingEntry(font, func(entry ggfntraw.MappingEntry) {
+		entries = append(entries, mappingEntry{
+			codePoint: entry.CodePoint,
+			switchType: entry.SwitchType(),
+			groups: entry.GlyphGroups(),
+		})
+	})
+	return entries
+}
+
+// Returns the setting keys of each switch type. Switch cases are
+// indexed like mixed radix numbers, with the last setting as the
+// least significant digit (see ggfnt's FontMapping.EvaluateSwitch()).
+func readSwitches(font *ggfnt.Font) [][]ggfnt.SettingKey {
+	data := font.Data[font.OffsetToMappingSwitches : ]
+	numSwitches := int(data[0])
+	endOffsets := data[1 : ]
+	keys := endOffsets[numSwitches*2 : ]
+	switches := make([][]ggfnt.SettingKey, numSwitches)
+	var start int
+	for i := 0; i < numSwitches; i++ {
+		end := int(binary.LittleEndian.Uint16(endOffsets[i*2 : ]))
+		for _, key := range keys[start : end] {
+			switches[i] = append(switches[i], ggfnt.SettingKey(key))
+		}
+		start = end
+	}
+	return switches
+}
+
+// Returns the glyph names by glyph index.
+func readGlyphNames(font *ggfnt.Font) map[ggfnt.GlyphIndex]string {
+	glyphNames := make(map[ggfnt.GlyphIndex]string)
+	ggfntraw.EachNamedGlyph(font, func(glyphIndex ggfnt.GlyphIndex, name string) {
+		glyphNames[glyphIndex] = name
+	})
+	return glyphNames
+}
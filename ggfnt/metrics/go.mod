@@ -2,4 +2,12 @@ module github.com/tinne26/ptxt-examples/ggfnt/metrics
 
 go 1.22.2
 
-require github.com/tinne26/ggfnt v0.0.0-20240701093853-0332791c25f2
+require github.com/tinne26/ptxt-examples/internal v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/tinne26/ggfnt v0.0.0-20240705120847-d849d4c6e12d // indirect
+	github.com/tinne26/ggfnt-fonts/jammy v0.0.0-20240704182610-77ae6be48ecf // indirect
+	github.com/tinne26/ggfnt-fonts/jumpy v0.0.0-20240702174359-a662e6ba4b03 // indirect
+)
+
+replace github.com/tinne26/ptxt-examples/internal => ../../internal
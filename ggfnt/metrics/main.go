@@ -2,21 +2,22 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 
-import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
 
 func main() {
 	// usage check
-	if len(os.Args) != 2 {
-		fmt.Print("Usage: go run main.go font.ggfnt\n")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run main.go [--font jammy|font.ggfnt]\n")
 		os.Exit(1)
 	}
 
 	// parse font
-	file, err := os.Open(os.Args[1])
-	if err != nil { panic(err) }
-	defer file.Close()
-	font, err := ggfnt.Parse(file)
+	font, err := fontreg.Resolve(*fontName)
 	if err != nil { panic(err) }
 	
 	// print font info
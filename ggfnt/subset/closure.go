@@ -0,0 +1,235 @@
+package main
+
+import "encoding/binary"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/ggfntraw"
+
+// The glyphs and code points required to render a corpus.
+type subsetClosure struct {
+	codePoints map[rune]bool
+	glyphs []bool // indexed by glyph index
+	numUtf8Rules int // rules that can be triggered
+	numGlyphRules int
+}
+
+// Computes the code points and glyphs that may be needed to render
+// the given text with any combination of settings. This includes all
+// the glyphs of conditional mappings (switch cases and glyph groups),
+// and the outputs of any rewrite rule whose input elements can all
+// appear in the text. Rules are applied until no more elements are
+// added. Conditions are ignored, as settings can change at runtime.
+//
+// The result can include glyphs that will never be used in practice
+// (e.g. rules that require elements in an order that never appears),
+// but it never misses a glyph that could be.
+func computeClosure(font *ggfnt.Font, text string, keepNamed bool) *subsetClosure {
+	closure := &subsetClosure{
+		codePoints: make(map[rune]bool),
+		glyphs: make([]bool, font.Glyphs().Count()),
+	}
+	for _, codePoint := range text {
+		if codePoint == '\n' { continue } // handled by the renderer
+		closure.codePoints[codePoint] = true
+	}
+	if notdef := font.Glyphs().FindIndexByName("notdef"); notdef != ggfnt.GlyphMissing {
+		closure.addGlyph(notdef)
+	}
+	if keepNamed {
+		ggfntraw.EachNamedGlyph(font, func(glyphIndex ggfnt.GlyphIndex, _ string) {
+			closure.addGlyph(glyphIndex)
+		})
+	}
+
+	mapping := newRawMapping(font)
+	utf8Rules := make([]rawRule, font.Rewrites().NumUTF8Rules())
+	for i := range utf8Rules {
+		rule := font.Rewrites().GetUtf8Rule(uint16(i))
+		utf8Rules[i] = parseRule(rule.Data, 4)
+	}
+	glyphRules := make([]rawRule, font.Rewrites().NumGlyphRules())
+	for i := range glyphRules {
+		rule := font.Rewrites().GetGlyphRule(uint16(i))
+		glyphRules[i] = parseRule(rule.Data, 2)
+	}
+	utf8Sets := make([]rawSet, font.Rewrites().NumUTF8Sets())
+	for i := range utf8Sets {
+		set := font.Rewrites().GetUtf8Set(uint8(i))
+		utf8Sets[i] = parseSet(set.Data, 4)
+	}
+	glyphSets := make([]rawSet, font.Rewrites().NumGlyphSets())
+	for i := range glyphSets {
+		set := font.Rewrites().GetGlyphSet(uint8(i))
+		glyphSets[i] = parseSet(set.Data, 2)
+	}
+
+	// iterate until nothing changes
+	mappedCodePoints := make(map[rune]bool)
+	utf8Fired := make([]bool, len(utf8Rules))
+	glyphFired := make([]bool, len(glyphRules))
+	hasRune := func(value int) bool { return closure.codePoints[rune(value)] }
+	hasGlyph := func(value int) bool { return value < len(closure.glyphs) && closure.glyphs[value] }
+	for changed := true; changed; {
+		changed = false
+		for codePoint := range closure.codePoints {
+			if mappedCodePoints[codePoint] { continue }
+			mappedCodePoints[codePoint] = true
+			mapping.EachGlyph(codePoint, closure.addGlyph)
+			changed = true // new glyphs might enable rules
+		}
+		for i, rule := range utf8Rules {
+			if utf8Fired[i] || !rule.CanMatch(utf8Sets, hasRune) { continue }
+			utf8Fired[i], changed = true, true
+			closure.numUtf8Rules += 1
+			for _, codePoint := range rule.output {
+				closure.codePoints[rune(codePoint)] = true
+			}
+		}
+		for i, rule := range glyphRules {
+			if glyphFired[i] || !rule.CanMatch(glyphSets, hasGlyph) { continue }
+			glyphFired[i], changed = true, true
+			closure.numGlyphRules += 1
+			for _, glyphIndex := range rule.output {
+				closure.addGlyph(ggfnt.GlyphIndex(glyphIndex))
+			}
+		}
+	}
+	return closure
+}
+
+// Control glyph indices (zilch, new line, etc.) have no data, so
+// they are ignored.
+func (self *subsetClosure) addGlyph(glyphIndex ggfnt.GlyphIndex) {
+	if int(glyphIndex) >= len(self.glyphs) { return }
+	self.glyphs[glyphIndex] = true
+}
+
+func (self *subsetClosure) NumGlyphs() int {
+	var count int
+	for _, needed := range self.glyphs {
+		if needed { count += 1 }
+	}
+	return count
+}
+
+// ---- raw data parsing ----
+
+// The mapping table, indexed by code point. The ggfnt API only
+// gives access to the glyphs of the currently active switch case,
+// so we read all the cases directly from the font data.
+type rawMapping struct {
+	entries map[rune]ggfntraw.MappingEntry
+}
+
+func newRawMapping(font *ggfnt.Font) *rawMapping {
+	numEntries := int(font.Mapping().NumEntries())
+	mapping := &rawMapping{ entries: make(map[rune]ggfntraw.MappingEntry, numEntries) }
+	ggfntraw.EachMappingEntry(font, func(entry ggfntraw.MappingEntry) {
+		mapping.entries[entry.CodePoint] = entry
+	})
+	return mapping
+}
+
+// Calls the given function for every glyph in any switch case of the
+// code point's mapping entry.
+func (self *rawMapping) EachGlyph(codePoint rune, each func(ggfnt.GlyphIndex)) {
+	entry, found := self.entries[codePoint]
+	if !found { return }
+	for _, group := range entry.GlyphGroups() {
+		for _, glyphIndex := range group.Glyphs {
+			each(glyphIndex)
+		}
+	}
+}
+
+// A rewrite rule's output and input elements. Values are glyph
+// indices or code points, depending on the rule type.
+type rawRule struct {
+	output []int
+	inSets []uint8
+	inValues []int
+}
+
+// Parses glyph and utf8 rewrite rules, using elemSize 2 and 4 for
+// their elements respectively.
+func parseRule(data []byte, elemSize int) rawRule {
+	var rule rawRule
+	readElem := func(index int) int {
+		if elemSize == 2 { return int(binary.LittleEndian.Uint16(data[index : ])) }
+		return int(int32(binary.LittleEndian.Uint32(data[index : ])))
+	}
+	index := 5
+	for i := 0; i < int(data[4]); i++ {
+		rule.output = append(rule.output, readElem(index))
+		index += elemSize
+	}
+	for _, blockLen := range data[1 : 4] { // head, body, tail
+		if blockLen == 0 {
+			index += 1 // zero fragment
+			continue
+		}
+		for count := 0; count < int(blockLen); {
+			numSets, numValues := int(data[index] >> 4), int(data[index] & 0x0F)
+			index += 1
+			rule.inSets = append(rule.inSets, data[index : index + numSets]...)
+			index += numSets
+			for i := 0; i < numValues; i++ {
+				rule.inValues = append(rule.inValues, readElem(index))
+				index += elemSize
+			}
+			count += numSets + numValues
+		}
+	}
+	return rule
+}
+
+// Reports whether every input element of the rule can be matched
+// by some of the available values.
+func (self *rawRule) CanMatch(sets []rawSet, available func(int) bool) bool {
+	for _, value := range self.inValues {
+		if !available(value) { return false }
+	}
+	for _, set := range self.inSets {
+		if int(set) >= len(sets) || !sets[set].Any(available) { return false }
+	}
+	return true
+}
+
+// A rewrite rule set, with inclusive ranges and a list of values.
+type rawSet struct {
+	ranges [][2]int
+	list []int
+}
+
+func parseSet(data []byte, elemSize int) rawSet {
+	var set rawSet
+	readElem := func(index int) int {
+		if elemSize == 2 { return int(binary.LittleEndian.Uint16(data[index : ])) }
+		return int(int32(binary.LittleEndian.Uint32(data[index : ])))
+	}
+	index := 1
+	for i := 0; i < int(data[0]); i++ {
+		first := readElem(index)
+		set.ranges = append(set.ranges, [2]int{ first, first + int(data[index + elemSize]) })
+		index += elemSize + 1
+	}
+	numElems := int(data[index])
+	index += 1
+	for i := 0; i < numElems; i++ {
+		set.list = append(set.list, readElem(index))
+		index += elemSize
+	}
+	return set
+}
+
+func (self *rawSet) Any(available func(int) bool) bool {
+	for _, value := range self.list {
+		if available(value) { return true }
+	}
+	for _, valueRange := range self.ranges {
+		for value := valueRange[0]; value <= valueRange[1]; value++ {
+			if available(value) { return true }
+		}
+	}
+	return false
+}
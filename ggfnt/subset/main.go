@@ -0,0 +1,220 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "bytes"
+import "image"
+import "image/png"
+import "image/color"
+import "strings"
+import "unicode/utf8"
+import "path/filepath"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// Creates a subset of a ggfnt font that only keeps the glyphs needed
+// to render a text corpus, like all the strings used in a game. Useful
+// to reduce the size of WASM builds and other bundles.
+//
+// Glyphs reached through rewrite rules and setting dependent mappings
+// are also kept, so the subset renders the corpus exactly like the
+// original font with any combination of settings. This is verified
+// after writing the subset: the corpus is rendered with both fonts,
+// for the default settings and each setting option, and the PNGs are
+// compared.
+//
+// Usage:
+// > go run -tags cputext . --corpus strings.txt [--font jammy|font.ggfnt] [--out subset.ggfnt]
+//
+// Use --png to also save the renders of the corpus with both fonts.
+
+const RenderMargin = 8
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var corpusFlag = flag.String("corpus", "", "text file with all the strings to render (UTF-8)")
+var outFlag = flag.String("out", "", "output path; defaults to <font-name>-subset.ggfnt")
+var keepNamedFlag = flag.Bool("keep-named", false, "keep all named glyphs, even if the corpus doesn't use them")
+var pngFlag = flag.Bool("png", false, "save the corpus renders as <out>-before.png and <out>-after.png")
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() != 0 || *corpusFlag == "" {
+		fmt.Print("Usage: go run -tags cputext . --corpus strings.txt [--font jammy|font.ggfnt] [--out subset.ggfnt] [--keep-named] [--png]\n")
+		os.Exit(1)
+	}
+
+	// load font and corpus
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Font loaded: %s\n", font.Header().Name())
+	corpusData, err := os.ReadFile(*corpusFlag)
+	if err != nil { log.Fatal(err) }
+	if !utf8.Valid(corpusData) { log.Fatalf("%s is not valid UTF-8", *corpusFlag) }
+	corpus := strings.ReplaceAll(string(corpusData), "\r\n", "\n")
+	outPath := *outFlag
+	if outPath == "" { outPath = fileName(font.Header().Name()) + "-subset.ggfnt" }
+
+	// compute required glyphs and create the subset
+	closure := computeClosure(font, corpus, *keepNamedFlag)
+	var unmapped []string
+	seen := make(map[rune]bool)
+	settings := make([]uint8, font.Settings().Count())
+	for _, codePoint := range corpus {
+		if codePoint == '\n' || seen[codePoint] { continue }
+		seen[codePoint] = true
+		_, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { unmapped = append(unmapped, fmt.Sprintf("U+%04X", codePoint)) }
+	}
+	if len(unmapped) > 0 {
+		fmt.Printf("Note: %d code points in the corpus are not mapped by the font, skipped in the renders: %s\n", len(unmapped), truncateList(unmapped, 8))
+	}
+	data, err := subsetData(font, closure)
+	if err != nil { log.Fatal(err) }
+	subset, err := ggfnt.Parse(bytes.NewReader(exportFont(&ggfnt.Font{ Data: data })))
+	if err != nil { log.Fatalf("subset font failed to parse: %s", err) }
+
+	// export and report savings
+	originalData, subsetFileData := exportFont(font), exportFont(subset)
+	writeOutput(outPath, subsetFileData)
+	fmt.Printf("Glyphs: %d of %d kept", closure.NumGlyphs(), font.Glyphs().Count())
+	if closure.numUtf8Rules + closure.numGlyphRules > 0 {
+		fmt.Printf(" (%d utf8 rules and %d glyph rules can be triggered)", closure.numUtf8Rules, closure.numGlyphRules)
+	}
+	fmt.Printf("\nMapping: %d of %d code points kept\n", subset.Mapping().NumEntries(), font.Mapping().NumEntries())
+	fmt.Printf("Size: %d -> %d bytes (%.1f%% smaller), uncompressed %d -> %d bytes\n",
+		len(originalData), len(subsetFileData), savings(len(originalData), len(subsetFileData)),
+		font.RawSize(), subset.RawSize())
+
+	// render the corpus with both fonts and compare
+	renderText := strings.Map(func(codePoint rune) rune {
+		if codePoint == '\n' { return codePoint }
+		_, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { return -1 } // ptxt panics on missing glyphs
+		return codePoint
+	}, corpus)
+	numPasses, err := verify(font, subset, renderText, outPath)
+	if err != nil { log.Fatalf("verification failed: %s", err) }
+	fmt.Printf("Verification: corpus renders are identical for %d setting combinations.\n", numPasses)
+}
+
+// Renders the corpus with both fonts for the default settings and
+// each individual setting option, and compares the encoded PNGs.
+// Returns the number of setting combinations checked.
+func verify(original, subset *ggfnt.Font, corpus string, outPath string) (int, error) {
+	originalRenderer, err := newCorpusRenderer(original)
+	if err != nil { return 0, err }
+	subsetRenderer, err := newCorpusRenderer(subset)
+	if err != nil { return 0, err }
+
+	var numPasses int
+	var compare = func(description string) error {
+		numPasses += 1
+		before, err := renderCorpus(originalRenderer, corpus)
+		if err != nil { return err }
+		after, err := renderCorpus(subsetRenderer, corpus)
+		if err != nil { return err }
+		if numPasses == 1 && *pngFlag {
+			basePath := strings.TrimSuffix(outPath, filepath.Ext(outPath))
+			writeOutput(basePath + "-before.png", before)
+			writeOutput(basePath + "-after.png", after)
+		}
+		if !bytes.Equal(before, after) {
+			return fmt.Errorf("renders differ with %s", description)
+		}
+		return nil
+	}
+
+	err = compare("the default settings")
+	if err != nil { return numPasses, err }
+	var settingsErr error
+	original.Settings().Each(func(key ggfnt.SettingKey, name string) {
+		if settingsErr != nil { return }
+		defaultOption := originalRenderer.Strand().GetSetting(key)
+		for option := uint8(0); option < original.Settings().GetNumOptions(key); option++ {
+			if option == defaultOption { continue }
+			originalRenderer.Strand().SetSetting(key, option)
+			subsetRenderer.Strand().SetSetting(key, option)
+			optionName := original.Settings().GetOptionName(key, option)
+			settingsErr = compare(fmt.Sprintf("setting %s = %s", name, optionName))
+			if settingsErr != nil { return }
+		}
+		originalRenderer.Strand().SetSetting(key, defaultOption)
+		subsetRenderer.Strand().SetSetting(key, defaultOption)
+	})
+	return numPasses, settingsErr
+}
+
+func newCorpusRenderer(font *ggfnt.Font) (*ptxt.Renderer, error) {
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { return nil, err }
+	err = strand.Mapping().AutoInitRewriteRules()
+	if err != nil { return nil, err }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	renderer.SetAlign(ptxt.Left | ptxt.Top)
+	renderer.SetColor(color.RGBA{242, 240, 229, 255}) // off-white
+	return renderer, nil
+}
+
+// Draws the corpus and returns the encoded PNG.
+func renderCorpus(renderer *ptxt.Renderer, corpus string) ([]byte, error) {
+	width, height := renderer.Measure(corpus)
+	canvas := image.NewRGBA(image.Rect(0, 0, width + RenderMargin*2, height + RenderMargin*2))
+	fill(canvas, color.RGBA{23, 18, 25, 255}) // licorice
+	renderer.Draw(canvas, corpus, RenderMargin, RenderMargin)
+	var buffer bytes.Buffer
+	err := png.Encode(&buffer, canvas)
+	return buffer.Bytes(), err
+}
+
+// ---- helpers ----
+
+func exportFont(font *ggfnt.Font) []byte {
+	var buffer bytes.Buffer
+	err := font.Export(&buffer)
+	if err != nil { log.Fatal(err) }
+	return buffer.Bytes()
+}
+
+func writeOutput(path string, data []byte) {
+	err := os.WriteFile(path, data, 0644)
+	if err != nil { log.Fatal(err) }
+	absPath, err := filepath.Abs(path)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Output: %s\n", absPath)
+}
+
+func savings(before, after int) float64 {
+	if before == 0 { return 0 }
+	return 100*float64(before - after)/float64(before)
+}
+
+// Converts the font name to a lowercase file name.
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' { return r }
+		if r >= 'A' && r <= 'Z' { return r + ('a' - 'A') }
+		return '-'
+	}, name)
+	if name == "" { return "font" }
+	return name
+}
+
+func truncateList(items []string, maxItems int) string {
+	if len(items) <= maxItems { return strings.Join(items, " ") }
+	return strings.Join(items[ : maxItems], " ") + fmt.Sprintf(" (+%d more)", len(items) - maxItems)
+}
+
+func fill(canvas *image.RGBA, rgba color.RGBA) {
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		canvas.Pix[i + 0] = rgba.R
+		canvas.Pix[i + 1] = rgba.G
+		canvas.Pix[i + 2] = rgba.B
+		canvas.Pix[i + 3] = rgba.A
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import "errors"
+import "crypto/rand"
+import "encoding/binary"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/ggfntraw"
+
+// Creates the raw data for the subset font. Glyph indices are
+// preserved, so glyph names, mapping switches, rewrite rules and
+// rewrite sets can be copied as they are, but:
+//  - Glyphs that are not needed keep their placement (advance), but
+//    their raster operations are removed.
+//  - Mapping entries for code points that are not needed are removed.
+//  - Kerning pairs with glyphs that are not needed are removed.
+//  - The font gets a new ID, as renderers cache glyph masks by font ID
+//    and the subset can't be used in place of the full font.
+func subsetData(font *ggfnt.Font, closure *subsetClosure) ([]byte, error) {
+	data := font.Data
+	out := make([]byte, 0, len(data))
+	out = append(out, data[ : font.OffsetToGlyphMasks]...) // header, metrics, color, glyph names
+	out = appendGlyphMasks(out, font, closure.glyphs)
+	out = append(out, data[font.OffsetToWords : font.OffsetToMapping]...) // settings, mapping switches
+	out = appendMapping(out, font, closure.codePoints)
+	out = append(out, data[font.OffsetToRewriteConditions : font.OffsetToHorzKernings]...) // rewrite rules
+	out = appendKerningPairs(out, data[font.OffsetToHorzKernings : font.OffsetToVertKernings], closure.glyphs)
+	out = appendKerningPairs(out, data[font.OffsetToVertKernings : ], closure.glyphs)
+	return out, setRandomFontID(out)
+}
+
+// Same approach as the ggfnt builder: IDs with low entropy are
+// rejected by the parser, so we reroll them.
+func setRandomFontID(data []byte) error {
+	const MaxRerolls = 8
+	for i := 0; i < MaxRerolls; i++ {
+		_, err := rand.Read(data[4 : 12]) // FontID, after FormatVersion
+		if err != nil { return err }
+		header := (&ggfnt.Font{ Data: data }).Header()
+		if header.Validate(ggfnt.FmtDefault) == nil { return nil }
+	}
+	return errors.New("failed to generate font ID with sufficient entropy")
+}
+
+func appendGlyphMasks(out []byte, font *ggfnt.Font, needed []bool) []byte {
+	numGlyphs := int(font.Glyphs().Count())
+	placementSize := 1
+	if font.Metrics().HasVertLayout() { placementSize = 4 }
+	endOffsets := font.Data[font.OffsetToGlyphMasks : ]
+	masks := endOffsets[numGlyphs*3 : ]
+
+	offsetsIndex := len(out)
+	out = append(out, make([]byte, numGlyphs*3)...)
+	var start, offset int
+	for i := 0; i < numGlyphs; i++ {
+		end := ggfntraw.ReadUint24(endOffsets[i*3 : ])
+		glyphData := masks[start : end]
+		if !needed[i] { glyphData = glyphData[ : placementSize] }
+		out = append(out, glyphData...)
+		offset += len(glyphData)
+		putUint24(out[offsetsIndex + i*3 : ], offset)
+		start = end
+	}
+	return out
+}
+
+func appendMapping(out []byte, font *ggfnt.Font, codePoints map[rune]bool) []byte {
+	var keptCodePoints []rune
+	var keptData [][]byte
+	ggfntraw.EachMappingEntry(font, func(entry ggfntraw.MappingEntry) {
+		if !codePoints[entry.CodePoint] { return }
+		keptCodePoints = append(keptCodePoints, entry.CodePoint)
+		keptData = append(keptData, entry.Data)
+	})
+
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(keptCodePoints)))
+	for _, codePoint := range keptCodePoints {
+		out = binary.LittleEndian.AppendUint32(out, uint32(codePoint))
+	}
+	var offset int
+	for _, data := range keptData {
+		offset += len(data)
+		out = appendUint24(out, offset)
+	}
+	for _, data := range keptData {
+		out = append(out, data...)
+	}
+	return out
+}
+
+// Filters a kerning section: uint24 count, uint32 pairs (two uint16
+// glyph indices) and int8 values.
+func appendKerningPairs(out []byte, section []byte, needed []bool) []byte {
+	numPairs := ggfntraw.ReadUint24(section)
+	pairs := section[3 : 3 + numPairs*4]
+	values := section[3 + numPairs*4 : ]
+
+	var keptPairs []uint32
+	var keptValues []byte
+	for i := 0; i < numPairs; i++ {
+		pair := binary.LittleEndian.Uint32(pairs[i*4 : ])
+		first, second := int(pair >> 16), int(pair & 0xFFFF)
+		if !needed[first] || !needed[second] { continue }
+		keptPairs = append(keptPairs, pair)
+		keptValues = append(keptValues, values[i])
+	}
+
+	out = appendUint24(out, len(keptPairs))
+	for _, pair := range keptPairs {
+		out = binary.LittleEndian.AppendUint32(out, pair)
+	}
+	return append(out, keptValues...)
+}
+
+// ---- helpers ----
+
+func putUint24(data []byte, value int) {
+	data[0], data[1], data[2] = byte(value), byte(value >> 8), byte(value >> 16)
+}
+
+func appendUint24(data []byte, value int) []byte {
+	return append(data, byte(value), byte(value >> 8), byte(value >> 16))
+}
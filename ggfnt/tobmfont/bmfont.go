@@ -0,0 +1,180 @@
+package main
+
+import "io"
+import "fmt"
+import "bufio"
+import "strconv"
+import "strings"
+import "encoding/xml"
+
+// An AngelCode BMFont descriptor. The struct tags define the XML
+// variant, and the text variant uses the same tags and attribute names.
+// See https://www.angelcode.com/products/bmfont/doc/file_format.html.
+type bmFont struct {
+	XMLName xml.Name `xml:"font"`
+	Info bmInfo `xml:"info"`
+	Common bmCommon `xml:"common"`
+	Pages []bmPage `xml:"pages>page"`
+	Chars bmChars `xml:"chars"`
+	Kernings *bmKernings `xml:"kernings,omitempty"`
+}
+
+type bmInfo struct {
+	Face string `xml:"face,attr"`
+	Size int `xml:"size,attr"`
+	Bold int `xml:"bold,attr"`
+	Italic int `xml:"italic,attr"`
+	Charset string `xml:"charset,attr"`
+	Unicode int `xml:"unicode,attr"`
+	StretchH int `xml:"stretchH,attr"`
+	Smooth int `xml:"smooth,attr"`
+	AA int `xml:"aa,attr"`
+	Padding string `xml:"padding,attr"`
+	Spacing string `xml:"spacing,attr"`
+	Outline int `xml:"outline,attr"`
+}
+
+type bmCommon struct {
+	LineHeight int `xml:"lineHeight,attr"`
+	Base int `xml:"base,attr"`
+	ScaleW int `xml:"scaleW,attr"`
+	ScaleH int `xml:"scaleH,attr"`
+	Pages int `xml:"pages,attr"`
+	Packed int `xml:"packed,attr"`
+	AlphaChnl int `xml:"alphaChnl,attr"`
+	RedChnl int `xml:"redChnl,attr"`
+	GreenChnl int `xml:"greenChnl,attr"`
+	BlueChnl int `xml:"blueChnl,attr"`
+}
+
+type bmPage struct {
+	ID int `xml:"id,attr"`
+	File string `xml:"file,attr"`
+}
+
+type bmChars struct {
+	Count int `xml:"count,attr"`
+	Chars []bmChar `xml:"char"`
+}
+
+type bmChar struct {
+	ID int `xml:"id,attr"` // code point, or -1 for the invalid char glyph (notdef)
+	X int `xml:"x,attr"`
+	Y int `xml:"y,attr"`
+	Width int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+	XOffset int `xml:"xoffset,attr"` // from the pen position to the left of the glyph
+	YOffset int `xml:"yoffset,attr"` // from the top of the line to the top of the glyph
+	XAdvance int `xml:"xadvance,attr"`
+	Page int `xml:"page,attr"`
+	Chnl int `xml:"chnl,attr"`
+}
+
+type bmKernings struct {
+	Count int `xml:"count,attr"`
+	Kernings []bmKerning `xml:"kerning"`
+}
+
+type bmKerning struct {
+	First int `xml:"first,attr"`
+	Second int `xml:"second,attr"`
+	Amount int `xml:"amount,attr"`
+}
+
+// Writes the descriptor in the text format.
+func (self *bmFont) WriteText(w io.Writer) error {
+	writer := bufio.NewWriter(w)
+	info := self.Info
+	fmt.Fprintf(writer, "info face=%s size=%d bold=%d italic=%d charset=%s unicode=%d stretchH=%d smooth=%d aa=%d padding=%s spacing=%s outline=%d\n",
+		quote(info.Face), info.Size, info.Bold, info.Italic, quote(info.Charset), info.Unicode,
+		info.StretchH, info.Smooth, info.AA, info.Padding, info.Spacing, info.Outline)
+	common := self.Common
+	fmt.Fprintf(writer, "common lineHeight=%d base=%d scaleW=%d scaleH=%d pages=%d packed=%d alphaChnl=%d redChnl=%d greenChnl=%d blueChnl=%d\n",
+		common.LineHeight, common.Base, common.ScaleW, common.ScaleH, common.Pages, common.Packed,
+		common.AlphaChnl, common.RedChnl, common.GreenChnl, common.BlueChnl)
+	for _, page := range self.Pages {
+		fmt.Fprintf(writer, "page id=%d file=%s\n", page.ID, quote(page.File))
+	}
+	fmt.Fprintf(writer, "chars count=%d\n", self.Chars.Count)
+	for _, char := range self.Chars.Chars {
+		fmt.Fprintf(writer, "char id=%d x=%d y=%d width=%d height=%d xoffset=%d yoffset=%d xadvance=%d page=%d chnl=%d\n",
+			char.ID, char.X, char.Y, char.Width, char.Height, char.XOffset, char.YOffset, char.XAdvance, char.Page, char.Chnl)
+	}
+	if self.Kernings != nil {
+		fmt.Fprintf(writer, "kernings count=%d\n", self.Kernings.Count)
+		for _, kerning := range self.Kernings.Kernings {
+			fmt.Fprintf(writer, "kerning first=%d second=%d amount=%d\n", kerning.First, kerning.Second, kerning.Amount)
+		}
+	}
+	return writer.Flush()
+}
+
+// Writes the descriptor in the XML format.
+func (self *bmFont) WriteXML(w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil { return err }
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(self)
+	if err != nil { return err }
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// Parses a descriptor in the text format. Only the common, page and
+// char lines are read, which is enough to extract the glyphs.
+func parseText(r io.Reader) (*bmFont, error) {
+	var font bmFont
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		tag, attrs, err := parseTextLine(scanner.Text())
+		if err != nil { return nil, fmt.Errorf("line %d: %w", lineNum, err) }
+		var get = func(key string) int {
+			value, convErr := strconv.Atoi(attrs[key])
+			if convErr != nil && err == nil { err = fmt.Errorf("line %d: invalid %s value %q", lineNum, key, attrs[key]) }
+			return value
+		}
+		switch tag {
+		case "common":
+			font.Common = bmCommon{
+				LineHeight: get("lineHeight"), Base: get("base"),
+				ScaleW: get("scaleW"), ScaleH: get("scaleH"), Pages: get("pages"),
+			}
+		case "page":
+			font.Pages = append(font.Pages, bmPage{ ID: get("id"), File: attrs["file"] })
+		case "char":
+			font.Chars.Chars = append(font.Chars.Chars, bmChar{
+				ID: get("id"), X: get("x"), Y: get("y"), Width: get("width"), Height: get("height"),
+				XOffset: get("xoffset"), YOffset: get("yoffset"), XAdvance: get("xadvance"), Page: get("page"),
+			})
+		}
+		if err != nil { return nil, err }
+	}
+	font.Chars.Count = len(font.Chars.Chars)
+	return &font, scanner.Err()
+}
+
+// Splits a text format line into its tag and key=value attributes.
+// Values can be quoted, and quoted values can contain spaces.
+func parseTextLine(line string) (string, map[string]string, error) {
+	tag, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+	attrs := make(map[string]string)
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if rest == "" { return tag, attrs, nil }
+		key, value, found := strings.Cut(rest, "=")
+		if !found { return "", nil, fmt.Errorf("expected key=value, found %q", rest) }
+		if strings.HasPrefix(value, `"`) {
+			end := strings.IndexByte(value[1 : ], '"')
+			if end == -1 { return "", nil, fmt.Errorf("unterminated quote for %s", key) }
+			attrs[key], rest = value[1 : end + 1], value[end + 2 : ]
+		} else {
+			attrs[key], rest, _ = strings.Cut(value, " ")
+		}
+	}
+}
+
+// The text format has no escaping, so quotes are replaced.
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
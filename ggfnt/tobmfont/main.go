@@ -0,0 +1,285 @@
+package main
+
+import "os"
+import "fmt"
+import "log"
+import "flag"
+import "bytes"
+import "image"
+import "image/png"
+import "image/draw"
+import "image/color"
+import "unicode"
+import "strings"
+import "encoding/xml"
+import "path/filepath"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Exports a ggfnt font to the AngelCode BMFont format: a power of two
+// PNG atlas with all the mapped glyphs, and .fnt descriptors in the
+// text and XML variants. Code points are mapped with the default
+// settings, and notdef is exported as the invalid char glyph (id -1).
+//
+// After exporting, the descriptors and the atlas are read back and the
+// glyphs are compared against the masks from ptxt's LoadMask. The same
+// comparison runs for the bundled fonts with "go test -tags cputext .".
+//
+// Usage:
+// > go run -tags cputext . [--font jammy|font.ggfnt] [--padding 1] [--out jammy]
+//
+// Outputs jammy.fnt (text), jammy.xml.fnt (XML) and jammy_0.png.
+
+// Kerning pairs are checked for all glyph combinations, which
+// is too slow for big fonts.
+const MaxKerningGlyphs = 2048
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var paddingFlag = flag.Int("padding", 1, "transparent pixels between glyphs in the atlas")
+var maxSizeFlag = flag.Int("max-size", 4096, "max atlas width and height")
+var outFlag = flag.String("out", "", "output path without extension; defaults to the font name")
+
+func main() {
+	// usage check
+	flag.Parse()
+	if flag.NArg() != 0 || *paddingFlag < 0 {
+		fmt.Print("Usage: go run -tags cputext . [--font jammy|font.ggfnt] [--padding 1] [--out path/name]\n")
+		os.Exit(1)
+	}
+
+	// load font
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { log.Fatal(err) }
+	fmt.Printf("Font loaded: %s\n", font.Header().Name())
+	outPath := *outFlag
+	if outPath == "" { outPath = fileName(font.Header().Name()) }
+
+	// export and read back
+	err = export(font, outPath, *paddingFlag, *maxSizeFlag)
+	if err != nil { log.Fatal(err) }
+	err = roundTrip(font, outPath)
+	if err != nil { log.Fatalf("round-trip failed: %s", err) }
+	fmt.Print("Round-trip: text and XML descriptors match the LoadMask masks.\n")
+}
+
+// Exports the font to outPath + ".fnt" (text descriptor), outPath +
+// ".xml.fnt" (XML descriptor) and outPath + "_0.png" (atlas).
+func export(font *ggfnt.Font, outPath string, padding, maxSize int) error {
+	// collect mapped glyphs and pack their masks
+	chars, glyphOrder, multiGlyph := collectChars(font)
+	if multiGlyph > 0 {
+		fmt.Printf("Note: %d code points map to glyph groups, only their first glyph is exported\n", multiGlyph)
+	}
+	masks := make([]*image.Alpha, len(glyphOrder))
+	sizes := make([]image.Point, len(glyphOrder))
+	for i, glyphIndex := range glyphOrder {
+		masks[i] = font.Glyphs().RasterizeMask(glyphIndex)
+		if masks[i] == nil { masks[i] = &image.Alpha{} } // empty glyph
+		sizes[i] = masks[i].Rect.Size()
+	}
+	positions, atlasSize, err := packAtlas(sizes, padding, maxSize)
+	if err != nil { return err }
+
+	// draw atlas, white with the glyph data in the alpha channel
+	atlas := image.NewNRGBA(image.Rectangle{ Max: atlasSize })
+	for i, mask := range masks {
+		rect := image.Rectangle{ Min: positions[i], Max: positions[i].Add(sizes[i]) }
+		draw.DrawMask(atlas, rect, image.NewUniform(color.White), image.Point{}, mask, mask.Rect.Min, draw.Src)
+	}
+
+	// build descriptor
+	pageFile := filepath.Base(outPath) + "_0.png"
+	bmfont := newDescriptor(font, pageFile, atlasSize, padding)
+	slots := make(map[ggfnt.GlyphIndex]int, len(glyphOrder))
+	for i, glyphIndex := range glyphOrder {
+		slots[glyphIndex] = i
+	}
+	interspacing := int(font.Metrics().HorzInterspacing()) // bmfont has no interspacing, add it to the advances
+	for _, char := range chars {
+		slot := slots[char.glyph]
+		mask := masks[slot]
+		bmfont.Chars.Chars = append(bmfont.Chars.Chars, bmChar{
+			ID: char.id,
+			X: positions[slot].X, Y: positions[slot].Y,
+			Width: sizes[slot].X, Height: sizes[slot].Y,
+			XOffset: mask.Rect.Min.X,
+			YOffset: bmfont.Common.Base + mask.Rect.Min.Y,
+			XAdvance: int(font.Glyphs().Advance(char.glyph)) + interspacing,
+			Chnl: 15, // all channels
+		})
+	}
+	bmfont.Chars.Count = len(bmfont.Chars.Chars)
+	if font.Kerning().NumPairs() > 0 {
+		if len(glyphOrder) > MaxKerningGlyphs {
+			fmt.Printf("Note: too many glyphs, kerning pairs skipped (max %d)\n", MaxKerningGlyphs)
+		} else {
+			bmfont.Kernings = collectKernings(font, chars)
+		}
+	}
+
+	// export files
+	var textBuffer, xmlBuffer, pngBuffer bytes.Buffer
+	err = bmfont.WriteText(&textBuffer)
+	if err != nil { return err }
+	err = bmfont.WriteXML(&xmlBuffer)
+	if err != nil { return err }
+	err = png.Encode(&pngBuffer, atlas)
+	if err != nil { return err }
+	err = writeOutput(outPath + ".fnt", textBuffer.Bytes())
+	if err != nil { return err }
+	err = writeOutput(outPath + ".xml.fnt", xmlBuffer.Bytes())
+	if err != nil { return err }
+	err = writeOutput(filepath.Join(filepath.Dir(outPath), pageFile), pngBuffer.Bytes())
+	if err != nil { return err }
+	fmt.Printf("Atlas: %dx%d, %d glyphs, %d chars\n", atlasSize.X, atlasSize.Y, len(glyphOrder), bmfont.Chars.Count)
+	return nil
+}
+
+// A BMFont char and the glyph it uses.
+type charEntry struct {
+	id int
+	glyph ggfnt.GlyphIndex
+}
+
+// Returns the chars for all the mapped code points with the default
+// settings, in order, preceded by notdef if the font has it. Also
+// returns the unique glyphs, in order of first use, and the number of
+// code points mapped to glyph groups.
+func collectChars(font *ggfnt.Font) ([]charEntry, []ggfnt.GlyphIndex, int) {
+	var chars []charEntry
+	if notdef := font.Glyphs().FindIndexByName("notdef"); notdef != ggfnt.GlyphMissing {
+		chars = append(chars, charEntry{ -1, notdef })
+	}
+	var multiGlyph int
+	settings := make([]uint8, font.Settings().Count())
+	for codePoint := rune(0); codePoint <= unicode.MaxRune; codePoint++ {
+		if codePoint == 0xD800 { codePoint = 0xE000 } // skip surrogates
+		group, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { continue }
+		if group.Size() > 1 { multiGlyph += 1 }
+		chars = append(chars, charEntry{ int(codePoint), group.Select(0) })
+	}
+
+	var glyphOrder []ggfnt.GlyphIndex
+	seen := make(map[ggfnt.GlyphIndex]bool)
+	for _, char := range chars {
+		if seen[char.glyph] { continue }
+		seen[char.glyph] = true
+		glyphOrder = append(glyphOrder, char.glyph)
+	}
+	return chars, glyphOrder, multiGlyph
+}
+
+// Returns the kerning pairs between the chars, by code point.
+func collectKernings(font *ggfnt.Font, chars []charEntry) *bmKernings {
+	kernings := &bmKernings{}
+	for _, first := range chars {
+		if first.id < 0 { continue }
+		for _, second := range chars {
+			if second.id < 0 { continue }
+			amount := font.Kerning().Get(first.glyph, second.glyph)
+			if amount == 0 { continue }
+			kernings.Kernings = append(kernings.Kernings, bmKerning{ first.id, second.id, int(amount) })
+		}
+	}
+	kernings.Count = len(kernings.Kernings)
+	return kernings
+}
+
+func newDescriptor(font *ggfnt.Font, pageFile string, atlasSize image.Point, padding int) *bmFont {
+	metrics := font.Metrics()
+	return &bmFont{
+		Info: bmInfo{
+			Face: font.Header().Name(),
+			Size: int(metrics.Ascent()) + int(metrics.Descent()),
+			Unicode: 1,
+			StretchH: 100,
+			Padding: "0,0,0,0",
+			Spacing: fmt.Sprintf("%d,%d", padding, padding),
+		},
+		Common: bmCommon{
+			LineHeight: metrics.LineHeight(),
+			Base: int(metrics.Ascent()), // the line gap goes below the descent
+			ScaleW: atlasSize.X, ScaleH: atlasSize.Y,
+			Pages: 1,
+			AlphaChnl: 0, // glyph
+			RedChnl: 4, GreenChnl: 4, BlueChnl: 4, // one
+		},
+		Pages: []bmPage{ { ID: 0, File: pageFile } },
+	}
+}
+
+// Reads the exported descriptors and atlas, and checks that each char
+// matches the glyph mask given by ptxt's LoadMask and its advance.
+func roundTrip(font *ggfnt.Font, outPath string) error {
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { return err }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	chars, _, _ := collectChars(font)
+
+	// parse descriptors
+	textFile, err := os.Open(outPath + ".fnt")
+	if err != nil { return err }
+	textFont, err := parseText(textFile)
+	_ = textFile.Close()
+	if err != nil { return err }
+	xmlData, err := os.ReadFile(outPath + ".xml.fnt")
+	if err != nil { return err }
+	var xmlFont bmFont
+	err = xml.Unmarshal(xmlData, &xmlFont)
+	if err != nil { return err }
+
+	for _, bmfont := range []*bmFont{ textFont, &xmlFont } {
+		// load atlas
+		if len(bmfont.Pages) != 1 { return fmt.Errorf("expected 1 page, found %d", len(bmfont.Pages)) }
+		atlasFile, err := os.Open(filepath.Join(filepath.Dir(outPath), bmfont.Pages[0].File))
+		if err != nil { return err }
+		atlas, err := png.Decode(atlasFile)
+		_ = atlasFile.Close()
+		if err != nil { return err }
+
+		// compare chars
+		if len(bmfont.Chars.Chars) != len(chars) {
+			return fmt.Errorf("expected %d chars, found %d", len(chars), len(bmfont.Chars.Chars))
+		}
+		interspacing := int(font.Metrics().HorzInterspacing())
+		for i, char := range bmfont.Chars.Chars {
+			if char.ID != chars[i].id { return fmt.Errorf("char %d: expected id %d, found %d", i, chars[i].id, char.ID) }
+			mask := image.NewAlpha(image.Rect(0, 0, char.Width, char.Height))
+			draw.Draw(mask, mask.Rect, atlas, image.Pt(char.X, char.Y), draw.Src)
+			mask.Rect = mask.Rect.Add(image.Pt(char.XOffset, char.YOffset - bmfont.Common.Base))
+			if !fontconv.SameMask(mask, renderer.Advanced().LoadMask(chars[i].glyph)) {
+				return fmt.Errorf("char %d mask mismatch", char.ID)
+			}
+			if char.XAdvance != int(font.Glyphs().Advance(chars[i].glyph)) + interspacing {
+				return fmt.Errorf("char %d advance mismatch", char.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// ---- helpers ----
+
+func writeOutput(path string, data []byte) error {
+	err := os.WriteFile(path, data, 0644)
+	if err != nil { return err }
+	absPath, err := filepath.Abs(path)
+	if err != nil { return err }
+	fmt.Printf("Output: %s\n", absPath)
+	return nil
+}
+
+// Converts the font name to a lowercase file name.
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) { return unicode.ToLower(r) }
+		return '_'
+	}, name)
+	if name == "" { return "font" }
+	return name
+}
@@ -0,0 +1,54 @@
+package main
+
+import "fmt"
+import "sort"
+import "image"
+
+// Finds the smallest power of two atlas where all the rects fit with
+// the given padding around them, growing the width and height in turns.
+// Returns the top-left position of each rect.
+func packAtlas(sizes []image.Point, padding int, maxSize int) ([]image.Point, image.Point, error) {
+	var area int
+	for _, size := range sizes {
+		area += (size.X + padding)*(size.Y + padding)
+	}
+	width, height := 1, 1
+	for width*height < area {
+		if width <= height { width *= 2 } else { height *= 2 }
+	}
+	for width <= maxSize && height <= maxSize {
+		positions, fits := packShelves(sizes, width, height, padding)
+		if fits { return positions, image.Pt(width, height), nil }
+		if width <= height { width *= 2 } else { height *= 2 }
+	}
+	return nil, image.Point{}, fmt.Errorf("glyphs don't fit in a %dx%d atlas", maxSize, maxSize)
+}
+
+// Shelf packing: rects are sorted by decreasing height and placed left
+// to right, starting a new shelf below the current one when the row is
+// full. Simple, and tight enough for glyphs of similar heights.
+func packShelves(sizes []image.Point, width, height, padding int) ([]image.Point, bool) {
+	order := make([]int, len(sizes))
+	for i := range order { order[i] = i }
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := sizes[order[i]], sizes[order[j]]
+		if a.Y != b.Y { return a.Y > b.Y }
+		return a.X > b.X
+	})
+
+	positions := make([]image.Point, len(sizes))
+	x, y, shelfHeight := padding, padding, 0
+	for _, index := range order {
+		size := sizes[index]
+		if size.X == 0 || size.Y == 0 { continue } // empty glyphs take no space
+		if x + size.X + padding > width { // next shelf
+			x, y = padding, y + shelfHeight + padding
+			shelfHeight = 0
+		}
+		if x + size.X + padding > width || y + size.Y + padding > height { return nil, false }
+		positions[index] = image.Pt(x, y)
+		x += size.X + padding
+		shelfHeight = max(shelfHeight, size.Y)
+	}
+	return positions, true
+}
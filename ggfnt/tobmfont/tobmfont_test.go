@@ -0,0 +1,199 @@
+//go:build cputext
+
+package main
+
+import "os"
+import "fmt"
+import "testing"
+import "image"
+import "image/png"
+import "image/draw"
+import "encoding/xml"
+import "path/filepath"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/fontconv"
+
+// Run with:
+// > go test -tags cputext .
+
+// Exports the bundled fonts to a temporary directory, reads the atlas
+// back through both descriptors and compares each char against the
+// mask given by ptxt's LoadMask for the glyph its code point maps to.
+func TestExport(t *testing.T) {
+	for _, name := range []string{ "jammy", "jumpy" } {
+		for _, padding := range []int{ 0, 1, 3 } {
+			t.Run(fmt.Sprintf("%s/padding%d", name, padding), func(t *testing.T) {
+				font := loadFont(t, name)
+				outPath := filepath.Join(t.TempDir(), name)
+				err := export(font, outPath, padding, 4096)
+				if err != nil { t.Fatal(err) }
+
+				textFont, xmlFont := readDescriptors(t, outPath)
+				for _, bmfont := range []*bmFont{ textFont, xmlFont } {
+					checkChars(t, font, outPath, bmfont, padding)
+				}
+				err = roundTrip(font, outPath)
+				if err != nil { t.Errorf("roundTrip(): %s", err) }
+			})
+		}
+	}
+}
+
+// The in-exporter round-trip must reject an atlas with a single
+// changed pixel.
+func TestRoundTripDetectsCorruption(t *testing.T) {
+	font := loadFont(t, "jammy")
+	outPath := filepath.Join(t.TempDir(), "jammy")
+	err := export(font, outPath, 1, 4096)
+	if err != nil { t.Fatal(err) }
+
+	// clear the first set pixel of the char for 'A'
+	textFont, _ := readDescriptors(t, outPath)
+	atlasPath := filepath.Join(filepath.Dir(outPath), textFont.Pages[0].File)
+	decoded := readAtlas(t, atlasPath)
+	atlas := image.NewNRGBA(decoded.Bounds())
+	draw.Draw(atlas, atlas.Rect, decoded, image.Point{}, draw.Src)
+	char, found := findChar(textFont, 'A')
+	if !found { t.Fatal("char 'A' not exported") }
+	cleared := false
+	for y := char.Y; y < char.Y + char.Height && !cleared; y++ {
+		for x := char.X; x < char.X + char.Width && !cleared; x++ {
+			rgba := atlas.NRGBAAt(x, y)
+			if rgba.A == 0 { continue }
+			rgba.A = 0
+			atlas.SetNRGBA(x, y, rgba)
+			cleared = true
+		}
+	}
+	if !cleared { t.Fatal("char 'A' has no set pixels") }
+	file, err := os.Create(atlasPath)
+	if err != nil { t.Fatal(err) }
+	err = png.Encode(file, atlas)
+	_ = file.Close()
+	if err != nil { t.Fatal(err) }
+
+	err = roundTrip(font, outPath)
+	if err == nil { t.Error("roundTrip() accepted an atlas with a cleared pixel") }
+}
+
+// Checks that the descriptor has one char per mapped code point (plus
+// notdef), that each char matches its glyph and that the chars don't
+// overlap in the atlas.
+func checkChars(t *testing.T, font *ggfnt.Font, outPath string, bmfont *bmFont, padding int) {
+	t.Helper()
+	if len(bmfont.Pages) != 1 { t.Fatalf("expected 1 page, found %d", len(bmfont.Pages)) }
+	atlas := readAtlas(t, filepath.Join(filepath.Dir(outPath), bmfont.Pages[0].File))
+	if atlas.Bounds().Dx() != bmfont.Common.ScaleW || atlas.Bounds().Dy() != bmfont.Common.ScaleH {
+		t.Errorf("atlas is %v, descriptor says %dx%d", atlas.Bounds().Size(), bmfont.Common.ScaleW, bmfont.Common.ScaleH)
+	}
+	if bmfont.Chars.Count != len(bmfont.Chars.Chars) {
+		t.Errorf("chars count is %d, found %d chars", bmfont.Chars.Count, len(bmfont.Chars.Chars))
+	}
+
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { t.Fatal(err) }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	settings := make([]uint8, font.Settings().Count())
+	interspacing := int(font.Metrics().HorzInterspacing())
+
+	var mapped int
+	for _, codePoint := range []rune("AHZ") {
+		_, found := findChar(bmfont, int(codePoint))
+		if !found { t.Errorf("char %q not exported", codePoint) }
+	}
+	rects := make(map[image.Rectangle]bool)
+	for _, char := range bmfont.Chars.Chars {
+		// expected glyph
+		var glyph ggfnt.GlyphIndex
+		if char.ID == -1 {
+			glyph = font.Glyphs().FindIndexByName("notdef")
+			if glyph == ggfnt.GlyphMissing { t.Errorf("invalid char exported without notdef"); continue }
+		} else {
+			group, found := font.Mapping().Utf8(rune(char.ID), settings)
+			if !found { t.Errorf("char %d isn't mapped in the font", char.ID); continue }
+			glyph = group.Select(0)
+			mapped += 1
+		}
+
+		// mask and advance
+		mask := image.NewAlpha(image.Rect(0, 0, char.Width, char.Height))
+		draw.Draw(mask, mask.Rect, atlas, image.Pt(char.X, char.Y), draw.Src)
+		mask.Rect = mask.Rect.Add(image.Pt(char.XOffset, char.YOffset - bmfont.Common.Base))
+		if !fontconv.SameMask(mask, renderer.Advanced().LoadMask(glyph)) {
+			t.Errorf("char %d doesn't match the LoadMask mask of glyph %d", char.ID, glyph)
+		}
+		advance := int(font.Glyphs().Advance(glyph)) + interspacing
+		if char.XAdvance != advance {
+			t.Errorf("char %d advance is %d, expected %d", char.ID, char.XAdvance, advance)
+		}
+
+		// placement (chars sharing a glyph share the rect)
+		rect := image.Rect(char.X, char.Y, char.X + char.Width, char.Y + char.Height)
+		if rect.Empty() || rects[rect] { continue }
+		padded := rect.Inset(-padding)
+		if !padded.In(atlas.Bounds()) { t.Errorf("char %d padded rect %v outside the atlas", char.ID, padded) }
+		for other := range rects {
+			if padded.Overlaps(other) { t.Errorf("char %d padded rect %v overlaps %v", char.ID, padded, other) }
+		}
+		rects[rect] = true
+	}
+	if mapped != countMapped(font, settings) {
+		t.Errorf("expected %d mapped chars, found %d", countMapped(font, settings), mapped)
+	}
+}
+
+// ---- helpers ----
+
+func loadFont(t *testing.T, name string) *ggfnt.Font {
+	t.Helper()
+	font, err := fontreg.Resolve(name)
+	if err != nil { t.Fatal(err) }
+	return font
+}
+
+func readDescriptors(t *testing.T, outPath string) (*bmFont, *bmFont) {
+	t.Helper()
+	file, err := os.Open(outPath + ".fnt")
+	if err != nil { t.Fatal(err) }
+	textFont, err := parseText(file)
+	_ = file.Close()
+	if err != nil { t.Fatal(err) }
+	data, err := os.ReadFile(outPath + ".xml.fnt")
+	if err != nil { t.Fatal(err) }
+	var xmlFont bmFont
+	err = xml.Unmarshal(data, &xmlFont)
+	if err != nil { t.Fatal(err) }
+	return textFont, &xmlFont
+}
+
+func readAtlas(t *testing.T, path string) image.Image {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil { t.Fatal(err) }
+	defer file.Close()
+	atlas, err := png.Decode(file)
+	if err != nil { t.Fatal(err) }
+	return atlas
+}
+
+func findChar(bmfont *bmFont, id int) (bmChar, bool) {
+	for _, char := range bmfont.Chars.Chars {
+		if char.ID == id { return char, true }
+	}
+	return bmChar{}, false
+}
+
+// Counts the code points mapped by the font, skipping surrogates.
+func countMapped(font *ggfnt.Font, settings []uint8) int {
+	var count int
+	for codePoint := rune(0); codePoint <= 0x10FFFF; codePoint++ {
+		if codePoint == 0xD800 { codePoint = 0xE000 }
+		_, found := font.Mapping().Utf8(codePoint, settings)
+		if found { count += 1 }
+	}
+	return count
+}
@@ -2,6 +2,7 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "image"
 import "image/color"
 
@@ -9,6 +10,11 @@ import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/hajimehoshi/ebiten/v2/inpututil"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/hotfont"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+import "github.com/tinne26/ptxt-examples/internal/inputmap"
+import "github.com/tinne26/ptxt-examples/internal/labelcache"
 
 const CanvasWidth, CanvasHeight = 640, 360
 const UpperInstructions = "CLICK AROUND TO SET DRAW COORDINATES\nUSE ARROWS TO CHANGE ALIGNS\n[D] CHANGE TEXT DIRECTION\n[T] CHANGE TEXT"
@@ -25,16 +31,21 @@ var Directions []ptxt.Direction = []ptxt.Direction{
 	ptxt.Horizontal, ptxt.Sideways, ptxt.SidewaysRight,
 }
 
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+
 func main() {
 	// usage check
-	if len(os.Args) != 2 {
-		fmt.Print("Usage: go run main.go font.ggfnt\n")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run main.go [--capture-on-exit] [--font jammy|font.ggfnt]\n")
 		os.Exit(1)
 	}
 
-	// parse font and create strand
-	strand, err := ptxt.NewStrand(os.Args[1])
+	// parse font and create strand (font files are reloaded
+	// automatically when modified)
+	fontLoader, err := hotfont.Open(*fontName)
 	if err != nil { panic(err) }
+	strand := fontLoader.Strand()
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
 	// create strand and renderer
@@ -56,7 +67,10 @@ func main() {
 	err = ebiten.RunGame(&Game{
 		text: renderer,
 		info: infoRenderer,
+		labels: labelcache.New(256*1024),
+		font: fontLoader,
 		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("aligns"),
 		cx: CanvasWidth/2.0,
 		cy: CanvasHeight/2.0,
 		uppercaseOnly: !renderer.Advanced().AllGlyphsAvailable("abcdefghijklmnopqrstuvwzyx"),
@@ -67,7 +81,10 @@ func main() {
 type Game struct {
 	text *ptxt.Renderer
 	info *ptxt.Renderer
+	labels *labelcache.Cache // static info text
+	font *hotfont.Loader
 	canvas *ebiten.Image
+	capturer *capture.Capturer
 	cx, cy int
 	horzAlignIndex, vertAlignIndex int
 	dirIndex int
@@ -85,6 +102,16 @@ func (self *Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float
 }
 
 func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
+	// reload font if modified
+	if self.font.Update(self.text, self.info) {
+		self.uppercaseOnly = !self.text.Advanced().AllGlyphsAvailable("abcdefghijklmnopqrstuvwzyx")
+		self.labels.Clear() // old labels would be evicted anyway, but no need to wait
+	}
+
 	// detect horz align changes
 	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
 		self.horzAlignIndex -= 1
@@ -141,7 +168,8 @@ func (self *Game) Update() error {
 		x, y := ebiten.CursorPosition()
 		fromWidth, fromHeight := int(self.hiResWidth), int(self.hiResHeight)
 		toWidth, toHeight     := CanvasWidth, CanvasHeight
-		self.cx, self.cy = ptxt.Proportional.Remap(x, y, fromWidth, fromHeight, toWidth, toHeight)
+		cx, cy, inside := inputmap.Map(ptxt.Proportional, x, y, fromWidth, fromHeight, toWidth, toHeight)
+		if inside { self.cx, self.cy = cx, cy } // ignore clicks on letterboxing borders
 	}
 
 	return nil
@@ -157,13 +185,13 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 	vert := self.canvas.SubImage(image.Rect(self.cx, 0, self.cx + 1, CanvasHeight)).(*ebiten.Image)
 	vert.Fill(color.RGBA{161, 181, 166, 255})
 
-	// draw instructions
-	self.info.SetColor(color.RGBA{171, 191, 176, 255})
+	// draw instructions (cached, as they rarely change)
+	instructionsColor := color.RGBA{171, 191, 176, 255}
 	self.info.SetAlign(ptxt.Top | ptxt.Left)
 	if self.uppercaseOnly {
-		self.info.Draw(self.canvas, UpperInstructions, 6, 6)
+		self.labels.Draw(self.canvas, self.info, UpperInstructions, instructionsColor, 6, 6)
 	} else {
-		self.info.Draw(self.canvas, LowerInstructions, 6, 6)
+		self.labels.Draw(self.canvas, self.info, LowerInstructions, instructionsColor, 6, 6)
 	}
 
 	// draw text
@@ -177,22 +205,23 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 	self.text.Draw(self.canvas, mainText, self.cx, self.cy)
 
 	// aux info warnings
-	self.info.SetColor(color.RGBA{140, 70, 40, 255})
+	warningColor := color.RGBA{140, 70, 40, 255}
 	self.info.SetAlign(ptxt.Baseline | ptxt.Left)
 	vertAlign := self.text.GetAlign().Vert()
 	switch vertAlign {
 	case ptxt.Midline:
 		if self.text.Strand().Font().Metrics().MidlineAscent() == 0 {
-			self.info.Draw(self.canvas, "WARNING: FONT MIDLINE ASCENT IS ZERO", 6, CanvasHeight - 6)
+			self.labels.Draw(self.canvas, self.info, "WARNING: FONT MIDLINE ASCENT IS ZERO", warningColor, 6, CanvasHeight - 6)
 		}
 	case ptxt.CapLine:
 		if self.text.Strand().Font().Metrics().UppercaseAscent() == 0 {
-			self.info.Draw(self.canvas, "WARNING: FONT UPPERCASE ASCENT IS ZERO", 6, CanvasHeight - 6)
+			self.labels.Draw(self.canvas, self.info, "WARNING: FONT UPPERCASE ASCENT IS ZERO", warningColor, 6, CanvasHeight - 6)
 		}
 	}
 
 	// project logical canvas to main (optional ptxt utility)
 	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+	self.font.DrawErrorBanner(hiResCanvas)
 }
 
 // Helper method to make the align strings more compatible with
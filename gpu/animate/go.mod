@@ -5,7 +5,8 @@ go 1.22.2
 require (
 	github.com/hajimehoshi/ebiten/v2 v2.7.2
 	github.com/tinne26/ggfnt-fonts/jumpy v0.0.0-20240702174359-a662e6ba4b03
-	github.com/tinne26/ptxt v0.0.0-20240701101317-3f500077e3cd
+	github.com/tinne26/ptxt v0.0.0-20240705121025-08cffae613f3
+	github.com/tinne26/ptxt-examples/internal v0.0.0-00010101000000-000000000000
 )
 
 require (
@@ -13,7 +14,9 @@ require (
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
 	github.com/ebitengine/purego v0.7.0 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
-	github.com/tinne26/ggfnt v0.0.0-20240701093853-0332791c25f2 // indirect
+	github.com/tinne26/ggfnt v0.0.0-20240705120847-d849d4c6e12d // indirect
 	golang.org/x/sync v0.6.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 )
+
+replace github.com/tinne26/ptxt-examples/internal => ../../internal
@@ -1,14 +1,17 @@
 package main
 
+import "flag"
 import "image/color"
 import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/capture"
 import "github.com/tinne26/ggfnt-fonts/jumpy"
 
 const CanvasWidth, CanvasHeight = 160, 90
 
 type Game struct {
 	canvas *ebiten.Image // logical canvas
+	capturer *capture.Capturer
 	text *ptxt.Renderer
 }
 
@@ -18,7 +21,10 @@ func (*Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
 	return logicWinWidth*scale, logicWinHeight*scale
 }
 
-func (self *Game) Update() error { return nil }
+func (self *Game) Update() error {
+	return self.capturer.Update(self.canvas) // F12 captures and --capture-on-exit
+}
+
 func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 	// fill background
 	self.canvas.Fill(color.RGBA{246, 242, 240, 255})
@@ -33,6 +39,8 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 // ---- main function ----
 
 func main() {
+	flag.Parse() // see internal/capture flags
+
 	// initialize font strand
 	strand, err := ptxt.NewStrand(jumpy.Font())
 	if err != nil { panic(err) }
@@ -48,6 +56,6 @@ func main() {
 	// set up Ebitengine and start the game
 	ebiten.SetWindowTitle("ptxt-examples/gpu/animate")
 	canvas := ebiten.NewImage(CanvasWidth, CanvasHeight)
-	err = ebiten.RunGame(&Game{ text: renderer, canvas: canvas })
+	err = ebiten.RunGame(&Game{ text: renderer, canvas: canvas, capturer: capture.New("animate") })
 	if err != nil { panic(err) }
 }
@@ -2,12 +2,16 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "image/color"
 
 import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/hajimehoshi/ebiten/v2/inpututil"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/hotfont"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/capture"
 
 const CanvasWidth, CanvasHeight = 160, 90
 var BackColor = color.RGBA{25, 100, 126, 255}
@@ -17,16 +21,21 @@ var ShadowColor = color.RGBA{55, 57, 46, 255}
 // TODO: yeah, this example doesn't seem to set or use the ascent
 //       offset appropriately. Check ptxt and addd more tests...
 
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+
 func main() {
 	// usage check
-	if len(os.Args) != 2 {
-		fmt.Print("Usage: go run main.go font.ggfnt\n")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run main.go [--capture-on-exit] [--font jammy|font.ggfnt]\n")
 		os.Exit(1)
 	}
 
-	// parse font and create strand
-	strand, err := ptxt.NewStrand(os.Args[1])
+	// parse font and create strand (font files are reloaded
+	// automatically when modified)
+	fontLoader, err := hotfont.Open(*fontName)
 	if err != nil { panic(err) }
+	strand := fontLoader.Strand()
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
 	// create strand and renderer
@@ -46,14 +55,18 @@ func main() {
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	err = ebiten.RunGame(&Game{
 		text: renderer,
+		font: fontLoader,
 		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("bounding"),
 	})
 	if err != nil { panic(err) }
 }
 
 type Game struct {
 	text *ptxt.Renderer
+	font *hotfont.Loader
 	canvas *ebiten.Image
+	capturer *capture.Capturer
 }
 
 func (*Game) Layout(_, _ int) (int, int) { panic("F") }
@@ -63,6 +76,13 @@ func (self *Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float
 }
 
 func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
+	// reload font if modified
+	self.font.Update(self.text)
+
 	// detect bounding mode changes
 	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
 		switch self.text.Advanced().GetBoundingMode() {
@@ -91,6 +111,7 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 
 	// project logical canvas to main (optional ptxt utility)
 	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+	self.font.DrawErrorBanner(hiResCanvas)
 }
 
 // format camel case ascii into hyphen separated uppercase
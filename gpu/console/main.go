@@ -0,0 +1,212 @@
+package main
+
+import "fmt"
+import "flag"
+import "image"
+import "unicode"
+import "math/rand"
+import "image/color"
+
+import "github.com/hajimehoshi/ebiten/v2"
+import "github.com/hajimehoshi/ebiten/v2/inpututil"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+const CanvasWidth, CanvasHeight = 240, 135 // (1/8th of 1920x1080)
+const LogCapacity = 64
+
+var BackgroundColor color.RGBA = color.RGBA{ 17,  16,  23, 255}
+var InputBackColor  color.RGBA = color.RGBA{ 36,  33,  46, 255}
+var PlayerColor     color.RGBA = color.RGBA{238, 232, 213, 255}
+var SystemColor     color.RGBA = color.RGBA{122, 196, 222, 255}
+var WarningColor    color.RGBA = color.RGBA{240, 160,  80, 255}
+var LootColor       color.RGBA = color.RGBA{160, 222, 100, 255}
+
+var SystemMessages = []string{
+	"A cold wind blows from the north.",
+	"You hear footsteps somewhere behind the old mill.",
+	"The merchant has restocked his wares. Potions, scrolls and a very suspicious looking hat are now available.",
+	"Your torch flickers.",
+	"Somebody left the gate open again. The goats are escaping and nobody seems to care, which is honestly kind of impressive.",
+}
+var WarningMessages = []string{
+	"Your backpack is almost full!",
+	"Low health. Find a safe place to rest before continuing.",
+}
+var LootMessages = []string{
+	"You found 12 gold coins.",
+	"You found a rusty key. It doesn't look like it opens anything nearby.",
+	"You found a map fragment!",
+}
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+
+func main() {
+	flag.Parse() // see internal/capture and internal/fontreg flags
+
+	// initialize font strand
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { panic(err) }
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { panic(err) }
+	err = strand.Mapping().AutoInitRewriteRules()
+	if err != nil { panic(err) }
+	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
+
+	// create text renderer, set the main properties
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	renderer.SetScale(1)
+
+	// heuristic for lowercase support
+	allowLowercase := renderer.Advanced().AllGlyphsAvailable("abcdefghijklmnopqrstuvwxyz")
+
+	// create log and add a few initial messages
+	textLog := NewTextLog(LogCapacity)
+	textLog.Push(renderer, fmtCase("Welcome to the console example!", allowLowercase), SystemColor)
+	textLog.Push(renderer, fmtCase("Type and press ENTER to send messages. Use the mouse wheel or PAGE UP / PAGE DOWN to scroll, END to go back to the bottom.", allowLowercase), SystemColor)
+
+	// run game
+	ebiten.SetWindowTitle("ptxt-examples/gpu/console")
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	err = ebiten.RunGame(&Game{
+		text: renderer,
+		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("console"),
+		log: textLog,
+		allowLowercase: allowLowercase,
+		nextMessageTicks: 90,
+	})
+	if err != nil { panic(err) }
+}
+
+type Game struct {
+	text *ptxt.Renderer
+	canvas *ebiten.Image
+	capturer *capture.Capturer
+	log *TextLog
+	input []rune // AppendInputChars uses runes
+	underscoreTicker uint8
+	allowLowercase bool
+	nextMessageTicks int
+}
+
+func (*Game) Layout(_, _ int) (int, int) { panic("F") }
+func (*Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
+	scale := ebiten.DeviceScaleFactor()
+	return logicWinWidth*scale, logicWinHeight*scale
+}
+
+func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
+	// helper function
+	var keyRepeat = func(key ebiten.Key) bool {
+		ticks := inpututil.KeyPressDuration(key)
+		return ticks == 1 || (ticks > 14 && (ticks - 14) % 5 == 0)
+	}
+
+	// text input: enter to send, backspace to delete
+	if keyRepeat(ebiten.KeyBackspace) && len(self.input) >= 1 {
+		self.input = self.input[0 : len(self.input) - 1]
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		if len(self.input) > 0 {
+			self.log.Push(self.text, "> " + string(self.input), PlayerColor)
+			self.log.ScrollToBottom()
+			self.input = self.input[ : 0]
+		}
+	} else {
+		preLen := len(self.input)
+		self.input = ebiten.AppendInputChars(self.input)
+		if !self.allowLowercase {
+			for i := preLen; i < len(self.input); i++ {
+				self.input[i] = unicode.ToUpper(self.input[i])
+			}
+		}
+	}
+
+	// scrolling
+	lineHeight := self.text.Strand().Font().Metrics().LineHeight()
+	_, wheel := ebiten.Wheel()
+	if wheel != 0 {
+		self.log.Scroll(int(wheel*float64(lineHeight)))
+	}
+	if keyRepeat(ebiten.KeyPageUp) {
+		self.log.Scroll(lineHeight*4)
+	} else if keyRepeat(ebiten.KeyPageDown) {
+		self.log.Scroll(-lineHeight*4)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEnd) {
+		self.log.ScrollToBottom()
+	}
+
+	// incoming messages
+	self.nextMessageTicks -= 1
+	if self.nextMessageTicks <= 0 {
+		self.nextMessageTicks = 60 + rand.Intn(180)
+		var msg string
+		var clr color.RGBA
+		switch roll := rand.Intn(10); {
+		case roll < 6:
+			msg, clr = SystemMessages[rand.Intn(len(SystemMessages))], SystemColor
+		case roll < 8:
+			msg, clr = LootMessages[rand.Intn(len(LootMessages))], LootColor
+		default:
+			msg, clr = WarningMessages[rand.Intn(len(WarningMessages))], WarningColor
+		}
+		self.log.Push(self.text, fmtCase(msg, self.allowLowercase), clr)
+	}
+
+	// update underscore ticker
+	self.underscoreTicker += 1
+	if self.underscoreTicker > 64 {
+		self.underscoreTicker = 0
+	}
+
+	return nil
+}
+
+func (self *Game) Draw(hiResCanvas *ebiten.Image) {
+	// background color
+	self.canvas.Fill(BackgroundColor)
+
+	// draw log
+	const Pad = 4
+	lineHeight := self.text.Strand().Font().Metrics().LineHeight()
+	inputHeight := lineHeight + Pad
+	logRect := image.Rect(Pad, Pad, CanvasWidth - Pad, CanvasHeight - Pad*2 - inputHeight)
+	self.log.Draw(self.canvas, self.text, logRect)
+
+	// draw input line
+	inputRect := image.Rect(Pad, CanvasHeight - Pad - inputHeight, CanvasWidth - Pad, CanvasHeight - Pad)
+	self.canvas.SubImage(inputRect).(*ebiten.Image).Fill(InputBackColor)
+	input := "> " + string(self.input)
+	if self.underscoreTicker < 24 { input += "_" }
+	self.text.SetColor(PlayerColor)
+	inputArea := self.canvas.SubImage(inputRect.Inset(Pad/2)).(*ebiten.Image)
+	inputWidth, _ := self.text.Measure(input)
+	if inputWidth <= inputRect.Dx() - Pad { // left aligned until it overflows
+		self.text.SetAlign(ptxt.VertCenter | ptxt.Left)
+		self.text.Draw(inputArea, input, inputRect.Min.X + Pad/2, (inputRect.Min.Y + inputRect.Max.Y)/2)
+	} else {
+		self.text.SetAlign(ptxt.VertCenter | ptxt.Right)
+		self.text.Draw(inputArea, input, inputRect.Max.X - Pad/2, (inputRect.Min.Y + inputRect.Max.Y)/2)
+	}
+
+	// project logical canvas to main (optional ptxt utility)
+	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+}
+
+// Helper function to make messages compatible with pixel art
+// fonts that often only include uppercase letters.
+func fmtCase(msg string, allowLowercase bool) string {
+	if allowLowercase { return msg }
+	runes := []rune(msg)
+	for i, codePoint := range runes {
+		runes[i] = unicode.ToUpper(codePoint)
+	}
+	return string(runes)
+}
@@ -0,0 +1,163 @@
+package main
+
+import "image"
+import "image/color"
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+import "github.com/tinne26/ptxt"
+
+// A scrollable message log for text consoles and game chats. New
+// entries are added at the bottom and older ones scroll up, being
+// dropped once the log reaches its capacity.
+//
+// Entries are only measured once when added (or when the wrap width
+// changes), so drawing doesn't require re-measuring the whole history
+// on each frame.
+type TextLog struct {
+	entries []logEntry // ring buffer
+	head int // index of the oldest entry
+	count int
+
+	wrapWidth int // width used for the cached entry heights
+	totalHeight int // sum of entry heights, including separations
+	entrySpacing int
+	scroll int // distance scrolled up from the bottom, in logical pixels
+
+	BackColor color.RGBA
+	TrackColor color.RGBA
+	ThumbColor color.RGBA
+}
+
+type logEntry struct {
+	text string
+	color color.RGBA
+	height int // -1 if the entry still needs to be measured
+}
+
+const scrollbarWidth = 3
+const logPadding = 3
+
+// Creates a new text log with the given entry capacity.
+func NewTextLog(capacity int) *TextLog {
+	if capacity <= 0 { panic("text log capacity must be strictly positive") }
+	return &TextLog{
+		entries: make([]logEntry, capacity),
+		entrySpacing: 2,
+		BackColor:  color.RGBA{ 27,  25,  35, 255},
+		TrackColor: color.RGBA{ 43,  40,  55, 255},
+		ThumbColor: color.RGBA{ 98,  93, 120, 255},
+	}
+}
+
+// Returns the number of entries currently stored in the log.
+func (self *TextLog) Len() int { return self.count }
+
+// Adds a new entry at the bottom of the log. If the log is full,
+// the oldest entry is dropped. If the log is scrolled up, the view
+// is preserved instead of jumping to the new entry.
+func (self *TextLog) Push(renderer *ptxt.Renderer, text string, rgba color.RGBA) {
+	// drop oldest entry if we are at full capacity
+	if self.count == len(self.entries) {
+		oldest := &self.entries[self.head]
+		if oldest.height >= 0 {
+			self.totalHeight -= oldest.height + self.entrySpacing
+		}
+		self.head = (self.head + 1) % len(self.entries)
+		self.count -= 1
+		self.clampScroll(0)
+	}
+
+	// store new entry and measure it if we already know the layout width
+	index := (self.head + self.count) % len(self.entries)
+	self.entries[index] = logEntry{ text: text, color: rgba, height: -1 }
+	self.count += 1
+	if self.wrapWidth > 0 {
+		height := self.measureEntry(renderer, index)
+		if self.scroll > 0 { self.scroll += height + self.entrySpacing }
+	}
+}
+
+// Scrolls the log by the given amount of logical pixels. Positive
+// values scroll up (towards older entries), negative values scroll
+// down (towards newer entries).
+func (self *TextLog) Scroll(pixels int) {
+	self.scroll += pixels
+	self.clampScroll(0)
+}
+
+// Scrolls back to the newest entries.
+func (self *TextLog) ScrollToBottom() {
+	self.scroll = 0
+}
+
+// Draws the log within the given viewport of the target. Text outside
+// the viewport is clipped.
+func (self *TextLog) Draw(target *ebiten.Image, renderer *ptxt.Renderer, viewport image.Rectangle) {
+	// background
+	area := target.SubImage(viewport).(*ebiten.Image)
+	area.Fill(self.BackColor)
+
+	// update layout if the viewport width changed
+	wrapWidth := viewport.Dx() - logPadding*2 - scrollbarWidth
+	if wrapWidth != self.wrapWidth {
+		self.relayout(renderer, wrapWidth)
+	}
+	self.clampScroll(viewport.Dy())
+
+	// draw entries from newest to oldest, stopping as soon
+	// as we go past the top of the viewport
+	renderer.SetAlign(ptxt.Top | ptxt.Left)
+	x := viewport.Min.X + logPadding
+	y := viewport.Max.Y - logPadding + self.scroll
+	for i := self.count - 1; i >= 0; i-- {
+		entry := &self.entries[(self.head + i) % len(self.entries)]
+		y -= entry.height
+		if y < viewport.Max.Y {
+			renderer.SetColor(entry.color)
+			renderer.DrawWithWrap(area, entry.text, x, y, self.wrapWidth)
+		}
+		y -= self.entrySpacing
+		if y < viewport.Min.Y { break }
+	}
+
+	// draw scrollbar if the content doesn't fit
+	contentHeight := self.contentHeight()
+	if contentHeight <= viewport.Dy() { return }
+	track := image.Rect(viewport.Max.X - scrollbarWidth, viewport.Min.Y, viewport.Max.X, viewport.Max.Y)
+	target.SubImage(track).(*ebiten.Image).Fill(self.TrackColor)
+	thumbHeight := max((viewport.Dy()*viewport.Dy())/contentHeight, scrollbarWidth)
+	maxThumbOffset := viewport.Dy() - thumbHeight
+	maxScroll := contentHeight - viewport.Dy()
+	thumbBottom := track.Max.Y - (self.scroll*maxThumbOffset)/maxScroll
+	thumb := image.Rect(track.Min.X, thumbBottom - thumbHeight, track.Max.X, thumbBottom)
+	target.SubImage(thumb).(*ebiten.Image).Fill(self.ThumbColor)
+}
+
+func (self *TextLog) measureEntry(renderer *ptxt.Renderer, index int) int {
+	entry := &self.entries[index]
+	_, entry.height = renderer.MeasureWithWrap(entry.text, self.wrapWidth)
+	self.totalHeight += entry.height + self.entrySpacing
+	return entry.height
+}
+
+func (self *TextLog) relayout(renderer *ptxt.Renderer, wrapWidth int) {
+	self.wrapWidth = wrapWidth
+	self.totalHeight = 0
+	for i := 0; i < self.count; i++ {
+		self.measureEntry(renderer, (self.head + i) % len(self.entries))
+	}
+}
+
+// Returns the height of all the entries, including the padding.
+func (self *TextLog) contentHeight() int {
+	if self.count == 0 { return 0 }
+	return self.totalHeight - self.entrySpacing + logPadding*2
+}
+
+// Clamps the scroll to the valid range. If viewportHeight is zero,
+// only the lower bound and the total content height are considered.
+func (self *TextLog) clampScroll(viewportHeight int) {
+	maxScroll := self.contentHeight() - viewportHeight
+	self.scroll = max(min(self.scroll, maxScroll), 0)
+}
@@ -0,0 +1,165 @@
+package main
+
+import "math"
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/core"
+import "github.com/tinne26/ggfnt"
+
+// A glyph effect modifies the draw parameters of individual glyphs.
+// The glyph argument is the position of the glyph within the text
+// being drawn (starting at 0), and tick is the number of updates
+// since the effect layer was (re)started.
+//
+// Offsets are given in logical pixels and should be multiplied by
+// params.Scale in order to stay consistent with the text scale.
+type GlyphEffect interface {
+	Apply(glyph int, tick int, params *ptxt.MaskDrawParameters)
+}
+
+// The effect layer draws text glyph by glyph through a custom draw
+// function, applying all the configured effects in order. This works
+// with any font, unlike glyph pickers, which require fonts to include
+// multiple glyphs for the animated code points.
+type EffectLayer struct {
+	renderer *ptxt.Renderer
+	effects []GlyphEffect
+	tick int
+	glyph int // current glyph position within the text being drawn
+}
+
+// Creates a new effect layer for the given renderer.
+func NewEffectLayer(renderer *ptxt.Renderer, effects ...GlyphEffect) *EffectLayer {
+	return &EffectLayer{ renderer: renderer, effects: effects }
+}
+
+// Replaces the effects of the layer. Effects are applied in order.
+func (self *EffectLayer) SetEffects(effects ...GlyphEffect) {
+	self.effects = effects
+}
+
+// Must be called once per tick from [ebiten.Game].Update().
+func (self *EffectLayer) Update() { self.tick += 1 }
+
+// Resets the layer tick count, restarting time-based effects like [FadeIn].
+func (self *EffectLayer) Restart() { self.tick = 0 }
+
+// Draws the given text with the layer effects applied. The renderer
+// draw function is only overridden for the duration of this call.
+func (self *EffectLayer) Draw(target *ebiten.Image, text string, x, y int) {
+	self.renderer.Advanced().SetDrawPassListener(self.onDrawPass)
+	self.renderer.Advanced().SetDrawFunc(self.drawGlyph)
+	self.renderer.Draw(target, text, x, y)
+	self.renderer.Advanced().SetDrawFunc(nil)
+	self.renderer.Advanced().SetDrawPassListener(nil)
+}
+
+func (self *EffectLayer) onDrawPass(_ *ptxt.Renderer, _ ptxt.DrawPass) {
+	self.glyph = 0 // shadow and main passes must get the same effects
+}
+
+func (self *EffectLayer) drawGlyph(target core.Target, glyphIndex ggfnt.GlyphIndex, params ptxt.MaskDrawParameters) {
+	mask := self.renderer.Advanced().LoadMask(glyphIndex)
+	if mask != nil {
+		for _, effect := range self.effects {
+			effect.Apply(self.glyph, self.tick, &params)
+		}
+		self.renderer.Advanced().DrawMask(target, mask, self.renderer.Strand(), params)
+	}
+	self.glyph += 1
+}
+
+// ---- effects ----
+
+// Moves glyphs vertically following a sine wave.
+type Wave struct {
+	Amplitude float64 // in logical pixels
+	Wavelength float64 // in glyphs
+	Speed float64 // in cycles per second
+}
+
+func (self Wave) Apply(glyph int, tick int, params *ptxt.MaskDrawParameters) {
+	phase := float64(glyph)/self.Wavelength + float64(tick)*self.Speed/float64(ebiten.TPS())
+	offset := math.Round(math.Sin(phase*2*math.Pi)*self.Amplitude)
+	params.Y += int(offset)*params.Scale
+}
+
+// Displaces glyphs randomly. The results are deterministic for
+// any given seed, glyph and tick.
+type Shake struct {
+	Seed uint64
+	Intensity int // max offset, in logical pixels
+	Interval int // number of ticks between changes
+}
+
+func (self Shake) Apply(glyph int, tick int, params *ptxt.MaskDrawParameters) {
+	if self.Intensity <= 0 { return }
+	step := tick/max(self.Interval, 1)
+	hash := splitMix64(self.Seed ^ (uint64(glyph) << 32) ^ uint64(step))
+	span := uint64(self.Intensity*2 + 1)
+	params.X += (int(hash % span) - self.Intensity)*params.Scale
+	params.Y += (int((hash >> 32) % span) - self.Intensity)*params.Scale
+}
+
+// Cycles glyph colors through the hue wheel. The original alpha
+// of the text color is preserved.
+type Rainbow struct {
+	Spread float64 // hue shift between consecutive glyphs, in [0, 1)
+	Speed float64 // in full hue cycles per second
+	Saturation float64
+	Value float64
+}
+
+func (self Rainbow) Apply(glyph int, tick int, params *ptxt.MaskDrawParameters) {
+	hue := float64(glyph)*self.Spread + float64(tick)*self.Speed/float64(ebiten.TPS())
+	hue -= math.Floor(hue)
+	r, g, b := hsvToRGB(hue, self.Saturation, self.Value)
+	alpha := params.RGBA[3]
+	params.RGBA = [4]float32{ r*alpha, g*alpha, b*alpha, alpha }
+}
+
+// Makes glyphs appear one after another, each glyph fading in
+// progressively.
+type FadeIn struct {
+	Delay int // ticks before the first glyph starts fading in
+	Stagger int // ticks between consecutive glyphs start fading in
+	Duration int // ticks it takes a glyph to become fully opaque
+}
+
+func (self FadeIn) Apply(glyph int, tick int, params *ptxt.MaskDrawParameters) {
+	elapsed := tick - self.Delay - glyph*self.Stagger
+	if elapsed >= self.Duration { return }
+	var factor float32
+	if elapsed > 0 { factor = float32(elapsed)/float32(self.Duration) }
+	for i := 0; i < 4; i++ { // colors are premultiplied
+		params.RGBA[i] *= factor
+	}
+}
+
+// ---- helpers ----
+
+func splitMix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30))*0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27))*0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+func hsvToRGB(h, s, v float64) (float32, float32, float32) {
+	h6 := h*6.0
+	sector := int(h6) % 6
+	f := h6 - math.Floor(h6)
+	p, q, t := v*(1 - s), v*(1 - s*f), v*(1 - s*(1 - f))
+	var r, g, b float64
+	switch sector {
+	case 0: r, g, b = v, t, p
+	case 1: r, g, b = q, v, p
+	case 2: r, g, b = p, v, t
+	case 3: r, g, b = p, q, v
+	case 4: r, g, b = t, p, v
+	default: r, g, b = v, p, q
+	}
+	return float32(r), float32(g), float32(b)
+}
@@ -0,0 +1,155 @@
+package main
+
+import "os"
+import "fmt"
+import "flag"
+import "strings"
+import "image/color"
+
+import "github.com/hajimehoshi/ebiten/v2"
+import "github.com/hajimehoshi/ebiten/v2/inpututil"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/hotfont"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+
+// Usage:
+// > go run .                      (uses the jammy font)
+// > go run . --font font.ggfnt    (effects work with any font)
+// Press F12 to save captures of the canvas.
+
+const CanvasWidth, CanvasHeight = 160, 90
+const SampleText = "PIXELS IN MOTION"
+
+var BackColor = color.RGBA{ 34,  32,  52, 255}
+var TextColor = color.RGBA{255, 214, 120, 255}
+var InfoColor = color.RGBA{102,  98, 140, 255}
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+
+func main() {
+	// parse flags and font, create strand (font files are
+	// reloaded automatically when modified)
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run main.go [--capture-on-exit] [--font jammy|font.ggfnt]\n")
+		os.Exit(1)
+	}
+	fontLoader, err := hotfont.Open(*fontName)
+	if err != nil { panic(err) }
+	fontStrand := fontLoader.Strand()
+	fmt.Printf("Font loaded: %s\n", fontStrand.Font().Header().Name())
+
+	// create text renderer, set the main properties
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(fontStrand)
+	renderer.SetAlign(ptxt.Center)
+	renderer.SetScale(1)
+
+	// run game
+	ebiten.SetWindowTitle("ptxt-examples/gpu/effects")
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	game := Game{
+		text: renderer,
+		font: fontLoader,
+		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("effects"),
+		effects: NewEffectLayer(renderer),
+		waveOn: true,
+		fadeOn: true,
+	}
+	game.refreshEffects()
+	err = ebiten.RunGame(&game)
+	if err != nil { panic(err) }
+}
+
+type Game struct {
+	text *ptxt.Renderer
+	font *hotfont.Loader
+	canvas *ebiten.Image
+	capturer *capture.Capturer
+	effects *EffectLayer
+	waveOn, shakeOn, rainbowOn, fadeOn bool
+}
+
+func (*Game) Layout(_, _ int) (int, int) { panic("F") }
+func (*Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
+	scale := ebiten.DeviceScaleFactor()
+	return logicWinWidth*scale, logicWinHeight*scale
+}
+
+func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
+	// reload font if modified
+	self.font.Update(self.text)
+
+	// detect effect toggles
+	changed := true
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyW): self.waveOn = !self.waveOn
+	case inpututil.IsKeyJustPressed(ebiten.KeyS): self.shakeOn = !self.shakeOn
+	case inpututil.IsKeyJustPressed(ebiten.KeyR): self.rainbowOn = !self.rainbowOn
+	case inpututil.IsKeyJustPressed(ebiten.KeyF): self.fadeOn = !self.fadeOn
+	default:
+		changed = false
+	}
+	if changed { self.refreshEffects() }
+
+	// restart time based effects
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		self.effects.Restart()
+	}
+
+	self.effects.Update()
+	return nil
+}
+
+func (self *Game) refreshEffects() {
+	var effects []GlyphEffect
+	if self.waveOn {
+		effects = append(effects, Wave{ Amplitude: 2, Wavelength: 8, Speed: 0.8 })
+	}
+	if self.shakeOn {
+		effects = append(effects, Shake{ Seed: 0xC0FFEE, Intensity: 1, Interval: 4 })
+	}
+	if self.rainbowOn {
+		effects = append(effects, Rainbow{ Spread: 0.06, Speed: 0.4, Saturation: 0.55, Value: 1.0 })
+	}
+	if self.fadeOn {
+		effects = append(effects, FadeIn{ Delay: 10, Stagger: 4, Duration: 20 })
+	}
+	self.effects.SetEffects(effects...)
+	self.effects.Restart()
+}
+
+func (self *Game) Draw(hiResCanvas *ebiten.Image) {
+	// background color
+	self.canvas.Fill(BackColor)
+
+	// draw text with effects
+	self.text.SetColor(TextColor)
+	self.text.SetAlign(ptxt.Center)
+	self.effects.Draw(self.canvas, SampleText, CanvasWidth/2, CanvasHeight/2 - 8)
+
+	// draw effect toggles info
+	var info strings.Builder
+	info.WriteString(fmtToggle("[W] WAVE", self.waveOn) + "  " + fmtToggle("[S] SHAKE", self.shakeOn) + "\n")
+	info.WriteString(fmtToggle("[R] RAINBOW", self.rainbowOn) + "  " + fmtToggle("[F] FADE", self.fadeOn) + "\n")
+	info.WriteString("[SPACE] RESTART")
+	self.text.SetColor(InfoColor)
+	self.text.SetAlign(ptxt.LastBaseline | ptxt.HorzCenter)
+	self.text.Draw(self.canvas, info.String(), CanvasWidth/2, CanvasHeight - 4)
+
+	// project logical canvas to main (optional ptxt utility)
+	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+	self.font.DrawErrorBanner(hiResCanvas)
+}
+
+func fmtToggle(name string, on bool) string {
+	if on { return name + ": ON" }
+	return name + ": OFF"
+}
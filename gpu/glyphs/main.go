@@ -2,12 +2,16 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "image/color"
 
 import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/hajimehoshi/ebiten/v2/inpututil"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/hotfont"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/capture"
 import "github.com/tinne26/ggfnt"
 
 const CanvasWidth, CanvasHeight = 160, 90
@@ -15,21 +19,21 @@ const CanvasWidth, CanvasHeight = 160, 90
 var BackColor = color.RGBA{24, 24, 24, 255}
 var TextColor = color.RGBA{250, 250, 250, 255}
 
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+
 func main() {
 	// usage check
-	if len(os.Args) != 2 {
-		fmt.Print("Usage: go run main.go font.ggfnt\n")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run main.go [--capture-on-exit] [--font jammy|font.ggfnt]\n")
 		os.Exit(1)
 	}
 
-	// open font file
-	fontName := os.Args[1]
-	fontFile, err := os.Open(fontName)
-	if err != nil { panic(err) }
-
-	// parse font and create strand
-	strand, err := ptxt.NewStrand(fontFile)
+	// parse font and create strand (font files are reloaded
+	// automatically when modified)
+	fontLoader, err := hotfont.Open(*fontName)
 	if err != nil { panic(err) }
+	strand := fontLoader.Strand()
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
 	// create strand and renderer
@@ -42,7 +46,9 @@ func main() {
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	game := Game{
 		text: renderer,
+		font: fontLoader,
 		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("glyphs"),
 	}
 	game.Init()
 	err = ebiten.RunGame(&game)
@@ -51,7 +57,9 @@ func main() {
 
 type Game struct {
 	text *ptxt.Renderer
+	font *hotfont.Loader
 	canvas *ebiten.Image
+	capturer *capture.Capturer
 	startIndex int
 	glyphCount int
 	boxSize int
@@ -77,6 +85,16 @@ func (self *Game) Init() {
 }
 
 func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
+	// reload font if modified
+	if self.font.Update(self.text) {
+		self.Init()
+		if self.startIndex >= self.glyphCount { self.startIndex = 0 }
+	}
+
 	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
 		if self.startIndex >= self.maxGlyphsPerRow {
 			self.startIndex -= self.maxGlyphsPerRow
@@ -127,4 +145,5 @@ loop:
 
 	// project logical canvas to main (optional ptxt utility)
 	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+	self.font.DrawErrorBanner(hiResCanvas)
 }
@@ -2,6 +2,7 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "unicode"
 import "image"
 import "image/color"
@@ -10,6 +11,10 @@ import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/hajimehoshi/ebiten/v2/inpututil"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/hotfont"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+import "github.com/tinne26/ptxt-examples/internal/pseudoloc"
 
 const CanvasWidth, CanvasHeight = 160, 90
 
@@ -17,21 +22,22 @@ var BackgroundColor color.RGBA = color.RGBA{142, 166,   4, 255}
 var HighlightColor  color.RGBA = color.RGBA{ 38, 131,  17, 255}
 var TextColor       color.RGBA = color.RGBA{222, 235,  76, 255}
 
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var expansionFlag = flag.Float64("expansion", 100*pseudoloc.DefaultExpansion, "pseudo-localization length expansion, in percentage")
+
 func main() {
 	// usage check
-	if len(os.Args) != 2 {
-		fmt.Print("Usage: go run main.go font.ggfnt\n")
+	flag.Parse()
+	if flag.NArg() != 0 || *expansionFlag < 0 {
+		fmt.Print("Usage: go run main.go [--capture-on-exit] [--font jammy|font.ggfnt] [--expansion 30]\n")
 		os.Exit(1)
 	}
 
-	// open font file
-	fontName := os.Args[1]
-	fontFile, err := os.Open(fontName)
-	if err != nil { panic(err) }
-
-	// parse font and create strand
-	strand, err := ptxt.NewStrand(fontFile)
+	// parse font and create strand (font files are reloaded
+	// automatically when modified)
+	fontLoader, err := hotfont.Open(*fontName)
 	if err != nil { panic(err) }
+	strand := fontLoader.Strand()
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
 	// create strand and renderer
@@ -44,37 +50,49 @@ func main() {
 	// renderer.Advanced().SetBoundingMode(ptxt.MaskBounding) // uncomment if you want to test this
 
 	// apply rewrite rules (optional)
-	err = strand.Mapping().AutoInitRewriteRules()
+	err = fontLoader.AutoInitRewriteRules()
 	if err != nil { panic(err) }
 
 	// heuristic for lowercase support
 	var initContent []rune
-	var allowLowercase bool
-	if renderer.Advanced().AllGlyphsAvailable("abcdefghijklmnopqrstuvwxyz") {
-		allowLowercase = true
+	allowLowercase := hasLowercase(renderer)
+	if allowLowercase {
 		initContent = []rune("Type something!")
 	} else {
 		initContent = []rune("TYPE SOMETHING!")
 	}
 
+	// pseudo-localization, to see how the measured
+	// area changes with longer text (toggled with Tab)
+	pseudo := pseudoloc.New(strand.Font())
+	pseudo.Expansion = *expansionFlag/100
+	fmt.Printf("Press Tab to toggle pseudo-localization (+%g%% length)\n", *expansionFlag)
+
 	// run game
 	ebiten.SetWindowTitle("ptxt-examples/gpu/measure")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	err = ebiten.RunGame(&Game{
 		text: renderer,
+		font: fontLoader,
 		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("measure"),
 		content: initContent,
 		allowLowercase: allowLowercase,
+		pseudo: pseudo,
 	})
 	if err != nil { panic(err) }
 }
 
 type Game struct {
 	text *ptxt.Renderer
+	font *hotfont.Loader
 	canvas *ebiten.Image
+	capturer *capture.Capturer
 	content []rune // not very efficient, but AppendInputChars uses runes
 	underscoreTicker uint8
 	allowLowercase bool
+	pseudo *pseudoloc.Transformer
+	pseudoOn bool
 }
 
 func (*Game) Layout(_, _ int) (int, int) { panic("F") }
@@ -84,12 +102,32 @@ func (*Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
 }
 
 func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
 	// helper function
 	var keyRepeat = func(key ebiten.Key) bool {
 		ticks := inpututil.KeyPressDuration(key)
 		return ticks == 1 || (ticks > 14 && (ticks - 14) % 5 == 0)
 	}
 
+	// reload font if modified
+	if self.font.Update(self.text) {
+		self.pseudo.SetFont(self.font.Strand().Font())
+		self.allowLowercase = hasLowercase(self.text)
+		if !self.allowLowercase { // the new font could panic on current content
+			for i := range self.content {
+				self.content[i] = unicode.ToUpper(self.content[i])
+			}
+		}
+	}
+
+	// toggle pseudo-localization (not P, as we are typing)
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		self.pseudoOn = !self.pseudoOn
+	}
+
 	// detect enter for newline, backspace for removing text,
 	// and otherwise append any new text input we get
 	if keyRepeat(ebiten.KeyBackspace) && len(self.content) >= 1 {
@@ -121,6 +159,7 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 
 	// draw highlight rect
 	content := string(self.content)
+	if self.pseudoOn { content = self.pseudo.Transform(content) }
 	w, h := self.text.Measure(content)
 	ox, _ := self.text.Advanced().LastBoundsOffset() // (only relevant for MaskBounding mode)
 	rect := image.Rect(4 + ox, 4, 4 + ox + w, 4 + h)
@@ -133,4 +172,10 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 
 	// project logical canvas to main (optional ptxt utility)
 	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+	self.font.DrawErrorBanner(hiResCanvas)
+}
+
+// Heuristic for lowercase support.
+func hasLowercase(renderer *ptxt.Renderer) bool {
+	return renderer.Advanced().AllGlyphsAvailable("abcdefghijklmnopqrstuvwxyz")
 }
@@ -0,0 +1,295 @@
+package main
+
+import "os"
+import "fmt"
+import "flag"
+import "image"
+import "image/color"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/core"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/inputmap"
+
+// Usage:
+// > go run .                                    (interactive explorer)
+// > go run -tags cputext . --sheet sheet.png    (headless, writes the comparison sheet)
+//
+// ptxt projectors draw with Ebitengine, which can only render while
+// the game loop is running. The --sheet mode doesn't use Ebitengine at
+// all: it draws the text with the cputext renderer and emulates the
+// projections with the internal/inputmap math instead, so it also works
+// without a display. Nearest filtering is exact, and bilinear filtering
+// is approximated (see sheet_cpu.go).
+//
+// Most gpu examples draw on a small logical canvas and then project
+// it to the high resolution screen with a ptxt.Projector. This example
+// projects the same logical canvas with each projector into simulated
+// windows of different sizes and device scale factors, so you can see
+// the trade-offs:
+//  - Proportional fills as much space as possible while keeping the
+//    aspect ratio, but non-integer factors require bilinear filtering,
+//    which results in blurry pixels.
+//  - PixelPerfect only uses integer factors, so pixels stay sharp, at
+//    the cost of bigger letterboxing borders.
+//  - Stretched fills the whole window, deforming the content if the
+//    aspect ratios don't match.
+
+const CanvasWidth, CanvasHeight = 80, 45 // (1/24th of 1920x1080)
+const LabelScale = 2
+const Pad = 12
+
+var SheetColor     = color.RGBA{ 30,  28,  36, 255}
+var LetterboxColor = color.RGBA{ 92,  40,  72, 255}
+var CanvasColor    = color.RGBA{246, 242, 240, 255}
+var TextColor      = color.RGBA{242, 143,  59, 255}
+var DetailColor    = color.RGBA{ 68,  72,  90, 255}
+var GridColor      = color.RGBA{  0,  64,  80,  96} // (premultiplied alpha)
+var LabelColor     = color.RGBA{220, 216, 230, 255}
+var InfoColor      = color.RGBA{140, 136, 156, 255}
+
+// The projection modes of ptxt.Projector, as defined by inputmap so
+// they are also available without Ebitengine (see Projection.Projector()).
+var Projections = []inputmap.Projection{ inputmap.Proportional, inputmap.PixelPerfect, inputmap.Stretched }
+
+// A window size as reported by Ebitengine's Layout() and the
+// device scale factor applied to it to get the high resolution
+// canvas size (like in LayoutF() on all gpu examples).
+type SimulatedWindow struct {
+	Width, Height int
+	ScaleFactor float64
+}
+
+var Windows = []SimulatedWindow{
+	{ Width: 160, Height:  90, ScaleFactor: 1.00 }, // exact 2x
+	{ Width: 200, Height: 120, ScaleFactor: 1.00 }, // 2.5x horz, 2.67x vert
+	{ Width: 256, Height: 144, ScaleFactor: 1.25 }, // 320x180, exact 4x after scaling
+	{ Width: 213, Height: 120, ScaleFactor: 1.50 }, // 319x180, off by a single pixel
+	{ Width: 150, Height: 100, ScaleFactor: 1.75 }, // 262x175, 3.28x with tall window
+	{ Width: 100, Height:  40, ScaleFactor: 0.75 }, // 75x30, smaller than the canvas
+}
+
+// Returns the size of the high resolution canvas for the window.
+func (self SimulatedWindow) CanvasSize() (int, int) {
+	return int(float64(self.Width)*self.ScaleFactor), int(float64(self.Height)*self.ScaleFactor)
+}
+
+func (self SimulatedWindow) String() string {
+	width, height := self.CanvasSize()
+	return fmt.Sprintf("WINDOW %dx%d @ %.2f\n= CANVAS %dx%d", self.Width, self.Height, self.ScaleFactor, width, height)
+}
+
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var sheetPath = flag.String("sheet", "", "write the comparison sheet to the given PNG file and exit (requires -tags cputext)")
+
+func main() {
+	// parse flags
+	flag.Parse()
+	if flag.NArg() != 0 || (*sheetPath != "") != cpuBuild {
+		fmt.Print("Usage: go run . [--capture-on-exit] [--font jammy|font.ggfnt]\n")
+		fmt.Print("       go run -tags cputext . --sheet sheet.png [--font jammy|font.ggfnt]\n")
+		os.Exit(1)
+	}
+
+	// initialize font strand
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { panic(err) }
+	strand, err := ptxt.NewStrand(font)
+	if err != nil { panic(err) }
+	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
+
+	// create text renderer and compute the sheet layout
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand)
+	sheet := newSheet(renderer)
+
+	// run the explorer (gpu) or write the sheet (cputext)
+	err = run(sheet)
+	if err != nil { panic(err) }
+}
+
+// The comparison sheet, a table with the simulated windows as rows
+// and the projections as columns. The drawing is shared by the gpu
+// and cputext versions, which only differ in the helpers at the end
+// of sheet_gpu.go and sheet_cpu.go.
+type Sheet struct {
+	text *ptxt.Renderer
+	showGrid bool
+
+	// layout, see newSheet()
+	width, height int
+	rowHeaderWidth, columnWidth int
+	headerHeight, lineHeight int
+}
+
+// Creates the sheet and computes its dimensions.
+func newSheet(renderer *ptxt.Renderer) *Sheet {
+	self := &Sheet{ text: renderer, showGrid: true }
+	self.text.SetScale(LabelScale)
+	self.lineHeight = self.text.Strand().Font().Metrics().LineHeight()*LabelScale
+
+	for _, window := range Windows {
+		width, _ := self.text.Measure(window.String())
+		self.rowHeaderWidth = max(self.rowHeaderWidth, width)
+		canvasWidth, _ := window.CanvasSize()
+		self.columnWidth = max(self.columnWidth, canvasWidth)
+	}
+	for _, projection := range Projections {
+		width, _ := self.text.Measure(fmtUpper(projection.String()))
+		self.columnWidth = max(self.columnWidth, width)
+	}
+	self.rowHeaderWidth += Pad*2
+	self.columnWidth += Pad*2
+	self.headerHeight = self.lineHeight + Pad*2
+	self.width = self.rowHeaderWidth + len(Projections)*self.columnWidth
+	self.height = self.headerHeight + self.lineHeight*2 + Pad
+	for _, window := range Windows {
+		self.height += self.rowHeight(window)
+	}
+	return self
+}
+
+func (self *Sheet) rowHeight(window SimulatedWindow) int {
+	_, canvasHeight := window.CanvasSize()
+	return max(canvasHeight, self.lineHeight*2) + self.lineHeight*2 + Pad*2
+}
+
+// Draws the logical canvas shared by all projections. The content
+// includes 1px details, which make scaling artifacts easy to see.
+func (self *Sheet) DrawLogicalCanvas(canvas core.Target) {
+	fillRect(canvas, canvas.Bounds(), CanvasColor)
+
+	// border and checkerboard corner
+	fillRect(canvas, image.Rect(0, 0, CanvasWidth, 1), DetailColor)
+	fillRect(canvas, image.Rect(0, CanvasHeight - 1, CanvasWidth, CanvasHeight), DetailColor)
+	fillRect(canvas, image.Rect(0, 0, 1, CanvasHeight), DetailColor)
+	fillRect(canvas, image.Rect(CanvasWidth - 1, 0, CanvasWidth, CanvasHeight), DetailColor)
+	for y := 3; y < 11; y++ {
+		for x := 3 + (y % 2); x < 11; x += 2 {
+			fillRect(canvas, image.Rect(x, y, x + 1, y + 1), DetailColor)
+		}
+	}
+
+	// 1px lines with growing gaps
+	x := 14
+	for gap := 1; gap <= 4; gap++ {
+		fillRect(canvas, image.Rect(x, 3, x + 1, 11), DetailColor)
+		x += gap + 1
+	}
+
+	// text
+	self.text.SetScale(1)
+	self.text.SetColor(TextColor)
+	self.text.SetAlign(ptxt.Center)
+	self.text.Draw(canvas, "PIXELS!\nptxt 1:1", CanvasWidth/2, CanvasHeight/2 + 6)
+}
+
+// Draws the comparison sheet on the target, which must be of the size
+// computed by newSheet(), with the given footer line.
+func (self *Sheet) Compose(target core.Target, canvas core.Target, footer string) {
+	rowHeaderWidth, columnWidth := self.rowHeaderWidth, self.columnWidth
+	fillRect(target, target.Bounds(), SheetColor)
+	self.text.SetScale(LabelScale)
+
+	// column headers
+	self.text.SetColor(LabelColor)
+	self.text.SetAlign(ptxt.Top | ptxt.HorzCenter)
+	for i, projection := range Projections {
+		x := rowHeaderWidth + i*columnWidth + columnWidth/2
+		self.text.Draw(target, fmtUpper(projection.String()), x, Pad)
+	}
+
+	// rows
+	y := self.headerHeight
+	for _, window := range Windows {
+		canvasWidth, canvasHeight := window.CanvasSize()
+
+		// row header
+		self.text.SetColor(LabelColor)
+		self.text.SetAlign(ptxt.Top | ptxt.Left)
+		self.text.Draw(target, window.String(), Pad, y)
+
+		// project into each simulated high resolution canvas
+		for i, projection := range Projections {
+			x := rowHeaderWidth + i*columnWidth + (columnWidth - canvasWidth)/2
+			hiResCanvas := subImage(target, image.Rect(x, y, x + canvasWidth, y + canvasHeight))
+			fillRect(hiResCanvas, hiResCanvas.Bounds(), LetterboxColor)
+			projected := project(projection, canvas, hiResCanvas)
+			if self.showGrid {
+				drawPixelGrid(target, projected, CanvasWidth, CanvasHeight)
+			}
+
+			// projection info
+			self.text.SetColor(InfoColor)
+			self.text.SetAlign(ptxt.Top | ptxt.HorzCenter)
+			info := fmtProjection(projection, projected)
+			self.text.Draw(target, info, rowHeaderWidth + i*columnWidth + columnWidth/2, y + canvasHeight + Pad/2)
+		}
+
+		y += self.rowHeight(window)
+	}
+
+	// footer
+	self.text.SetColor(InfoColor)
+	self.text.SetAlign(ptxt.LastBaseline | ptxt.Left)
+	self.text.Draw(target, footer, Pad, self.height - Pad)
+}
+
+// Returns the effective scaling factor and the type of filtering
+// used by the projection, as text.
+func fmtProjection(projection inputmap.Projection, rect image.Rectangle) string {
+	xScale := float64(rect.Dx())/CanvasWidth
+	yScale := float64(rect.Dy())/CanvasHeight
+	var scale string
+	if fmt.Sprintf("%.2f", xScale) == fmt.Sprintf("%.2f", yScale) {
+		scale = fmt.Sprintf("%.2fX", xScale)
+	} else {
+		scale = fmt.Sprintf("%.2fX BY %.2fX", xScale, yScale)
+	}
+	filter := "BILINEAR"
+	if usesNearest(projection, rect) { filter = "NEAREST" }
+	return fmt.Sprintf("%dx%d, %s\n%s", rect.Dx(), rect.Dy(), scale, filter)
+}
+
+// Reports whether the projector uses nearest filtering for the given
+// projected rect (this replicates the logic in ptxt's projector
+// implementation).
+func usesNearest(projection inputmap.Projection, rect image.Rectangle) bool {
+	xScale := float64(rect.Dx())/CanvasWidth
+	yScale := float64(rect.Dy())/CanvasHeight
+	return projection != inputmap.Stretched && xScale == yScale && xScale == float64(int(xScale))
+}
+
+// Draws a line over each logical pixel boundary within the projected
+// area, which shows how big each pixel ends up being. With non-integer
+// scaling factors, the pixel sizes are uneven.
+func drawPixelGrid(target core.Target, area image.Rectangle, logicalWidth, logicalHeight int) {
+	for i := 0; i <= logicalWidth; i++ {
+		x := area.Min.X + (i*area.Dx() + logicalWidth/2)/logicalWidth
+		x  = min(x, area.Max.X - 1)
+		drawRect(target, image.Rect(x, area.Min.Y, x + 1, area.Max.Y), GridColor)
+	}
+	for i := 0; i <= logicalHeight; i++ {
+		y := area.Min.Y + (i*area.Dy() + logicalHeight/2)/logicalHeight
+		y  = min(y, area.Max.Y - 1)
+		drawRect(target, image.Rect(area.Min.X, y, area.Max.X, y + 1), GridColor)
+	}
+}
+
+// ---- helpers ----
+
+// Converts "PixelPerfect" style names to "PIXEL PERFECT".
+func fmtUpper(name string) string {
+	var bytes []byte = make([]byte, 0, len(name) + 2)
+	for i := 0; i < len(name); i++ {
+		char := name[i]
+		if char >= 'A' && char <= 'Z' && i > 0 {
+			bytes = append(bytes, ' ')
+		}
+		if char >= 'a' && char <= 'z' {
+			char = char - 'a' + 'A'
+		}
+		bytes = append(bytes, char)
+	}
+	return string(bytes)
+}
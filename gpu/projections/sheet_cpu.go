@@ -0,0 +1,105 @@
+//go:build cputext
+
+package main
+
+import "os"
+import "fmt"
+import "image"
+import "image/png"
+import "image/draw"
+import "image/color"
+import "math"
+
+import "github.com/tinne26/ptxt/core"
+import "github.com/tinne26/ptxt-examples/internal/inputmap"
+
+// The interactive explorer requires Ebitengine, so only --sheet
+// is available with -tags cputext.
+const cpuBuild = true
+
+// Writes the comparison sheet to --sheet without Ebitengine.
+func run(sheet *Sheet) error {
+	canvas := image.NewRGBA(image.Rect(0, 0, CanvasWidth, CanvasHeight))
+	sheet.DrawLogicalCanvas(canvas)
+	img := image.NewRGBA(image.Rect(0, 0, sheet.width, sheet.height))
+	sheet.Compose(img, canvas, "CPU RENDER: PROJECTIONS EMULATED, BILINEAR FILTERING APPROXIMATED")
+
+	file, err := os.Create(*sheetPath)
+	if err != nil { return err }
+	err = png.Encode(file, img)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil { return err }
+	fmt.Printf("Output image: %s\n", *sheetPath)
+	return nil
+}
+
+// ---- helpers ----
+
+// Emulates ptxt's projector and returns the projected area. The area
+// and the nearest filtering come straight from the inputmap math, so
+// they match the gpu version exactly. Bilinear filtering samples the
+// four closest logical pixels like Ebitengine's FilterLinear, clamping
+// at the canvas edges, but GPU rounding can still differ slightly.
+func project(projection inputmap.Projection, canvas, hiResCanvas core.Target) image.Rectangle {
+	src := canvas.(*image.RGBA)
+	dst := hiResCanvas.(*image.RGBA)
+	logicalWidth, logicalHeight := src.Rect.Dx(), src.Rect.Dy()
+	hiResWidth, hiResHeight := dst.Rect.Dx(), dst.Rect.Dy()
+	area := projection.ProjectedRect(logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+	nearest := usesNearest(projection, area)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if nearest {
+				lx, ly, inside := projection.Map(x, y, hiResWidth, hiResHeight, logicalWidth, logicalHeight)
+				if !inside { continue }
+				dst.SetRGBA(dst.Rect.Min.X + x, dst.Rect.Min.Y + y, src.RGBAAt(lx, ly))
+			} else {
+				fx, fy, inside := projection.MapF(float64(x) + 0.5, float64(y) + 0.5, hiResWidth, hiResHeight, logicalWidth, logicalHeight)
+				if !inside { continue }
+				dst.SetRGBA(dst.Rect.Min.X + x, dst.Rect.Min.Y + y, sampleBilinear(src, fx, fy))
+			}
+		}
+	}
+	return area.Add(dst.Rect.Min)
+}
+
+// Samples the image at the given position, in pixels, interpolating
+// between the centers of the closest pixels.
+func sampleBilinear(img *image.RGBA, x, y float64) color.RGBA {
+	x, y = x - 0.5, y - 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	tx, ty := x - x0, y - y0
+	ix, iy := int(x0), int(y0)
+	bounds := img.Rect
+	at := func(x, y int) color.RGBA {
+		x = max(min(x, bounds.Max.X - 1), bounds.Min.X)
+		y = max(min(y, bounds.Max.Y - 1), bounds.Min.Y)
+		return img.RGBAAt(x, y)
+	}
+	a, b := at(ix, iy), at(ix + 1, iy)
+	c, d := at(ix, iy + 1), at(ix + 1, iy + 1)
+	lerp := func(a, b, c, d uint8) uint8 {
+		top := float64(a)*(1 - tx) + float64(b)*tx
+		bottom := float64(c)*(1 - tx) + float64(d)*tx
+		return uint8(math.Round(top*(1 - ty) + bottom*ty))
+	}
+	return color.RGBA{ lerp(a.R, b.R, c.R, d.R), lerp(a.G, b.G, c.G, d.G), lerp(a.B, b.B, c.B, d.B), lerp(a.A, b.A, c.A, d.A) }
+}
+
+func subImage(target core.Target, rect image.Rectangle) core.Target {
+	return target.(*image.RGBA).SubImage(rect).(*image.RGBA)
+}
+
+// Fills the rect, replacing any previous content.
+func fillRect(target core.Target, rect image.Rectangle, clr color.RGBA) {
+	draw.Draw(target, rect, image.NewUniform(clr), image.Point{}, draw.Src)
+}
+
+// Draws the rect with alpha blending (unlike fillRect).
+func drawRect(target core.Target, rect image.Rectangle, clr color.RGBA) {
+	draw.Draw(target, rect, image.NewUniform(clr), image.Point{}, draw.Over)
+}
@@ -0,0 +1,126 @@
+//go:build !cputext
+
+package main
+
+import "image"
+import "image/color"
+
+import "github.com/hajimehoshi/ebiten/v2"
+import "github.com/hajimehoshi/ebiten/v2/inpututil"
+
+import "github.com/tinne26/ptxt/core"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+import "github.com/tinne26/ptxt-examples/internal/inputmap"
+
+// The sheet can only be written headless with -tags cputext.
+const cpuBuild = false
+
+// Runs the interactive explorer.
+func run(sheet *Sheet) error {
+	game := Game{
+		sheet: sheet,
+		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		image: ebiten.NewImage(sheet.width, sheet.height),
+		capturer: capture.New("projections"),
+		dirty: true,
+	}
+	ebiten.SetWindowTitle("ptxt-examples/gpu/projections")
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowSize(min(sheet.width, 1280), min(sheet.height, 720))
+	return ebiten.RunGame(&game)
+}
+
+type Game struct {
+	sheet *Sheet
+	canvas *ebiten.Image // logical canvas, the same for all projections
+	image *ebiten.Image // composed sheet
+	dirty bool
+	capturer *capture.Capturer
+	scrollX, scrollY int
+	hiResWidth, hiResHeight int
+}
+
+func (*Game) Layout(_, _ int) (int, int) { panic("F") }
+func (self *Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
+	scale := ebiten.DeviceScaleFactor()
+	self.hiResWidth  = int(logicWinWidth*scale)
+	self.hiResHeight = int(logicWinHeight*scale)
+	return logicWinWidth*scale, logicWinHeight*scale
+}
+
+func (self *Game) Update() error {
+	// (re)draw the sheet if necessary
+	if self.dirty {
+		grid := "OFF"
+		if self.sheet.showGrid { grid = "ON" }
+		self.sheet.DrawLogicalCanvas(self.canvas)
+		self.sheet.Compose(self.image, self.canvas, "[G] PIXEL GRID: " + grid + "   [F12] CAPTURE   [ARROWS] SCROLL")
+		self.dirty = false
+	}
+
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.image)
+	if err != nil { return err }
+
+	// toggle pixel grid
+	if inpututil.IsKeyJustPressed(ebiten.KeyG) {
+		self.sheet.showGrid = !self.sheet.showGrid
+		self.dirty = true
+	}
+
+	// scroll when the sheet doesn't fit the screen
+	const ScrollSpeed = 6
+	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft)  { self.scrollX -= ScrollSpeed }
+	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) { self.scrollX += ScrollSpeed }
+	if ebiten.IsKeyPressed(ebiten.KeyArrowUp)    { self.scrollY -= ScrollSpeed }
+	if ebiten.IsKeyPressed(ebiten.KeyArrowDown)  { self.scrollY += ScrollSpeed }
+	self.scrollX = max(min(self.scrollX, self.sheet.width  - self.hiResWidth ), 0)
+	self.scrollY = max(min(self.scrollY, self.sheet.height - self.hiResHeight), 0)
+
+	return nil
+}
+
+func (self *Game) Draw(hiResCanvas *ebiten.Image) {
+	// the sheet is already at high resolution, so we draw
+	// it directly, without any projection or scaling
+	hiResCanvas.Fill(SheetColor)
+	if self.dirty { return }
+	var opts ebiten.DrawImageOptions
+	x, y := -self.scrollX, -self.scrollY
+	if self.sheet.width  < self.hiResWidth  { x = (self.hiResWidth  - self.sheet.width )/2 }
+	if self.sheet.height < self.hiResHeight { y = (self.hiResHeight - self.sheet.height)/2 }
+	opts.GeoM.Translate(float64(x), float64(y))
+	hiResCanvas.DrawImage(self.image, &opts)
+}
+
+// ---- helpers ----
+
+// Projects the canvas with the actual ptxt projector and returns
+// the projected area.
+func project(projection inputmap.Projection, canvas, hiResCanvas core.Target) image.Rectangle {
+	return projection.Projector().Project(canvas, hiResCanvas).Bounds()
+}
+
+func subImage(target core.Target, rect image.Rectangle) core.Target {
+	return target.SubImage(rect).(*ebiten.Image)
+}
+
+// Fills the rect, replacing any previous content.
+func fillRect(target core.Target, rect image.Rectangle, clr color.RGBA) {
+	target.SubImage(rect).(*ebiten.Image).Fill(clr)
+}
+
+var pixel *ebiten.Image
+
+// Draws the rect with alpha blending (unlike fillRect).
+func drawRect(target core.Target, rect image.Rectangle, clr color.RGBA) {
+	if pixel == nil {
+		pixel = ebiten.NewImage(1, 1)
+		pixel.Fill(color.White)
+	}
+	var opts ebiten.DrawImageOptions
+	opts.GeoM.Scale(float64(rect.Dx()), float64(rect.Dy()))
+	opts.GeoM.Translate(float64(rect.Min.X), float64(rect.Min.Y))
+	opts.ColorScale.ScaleWithColor(clr)
+	target.DrawImage(pixel, &opts)
+}
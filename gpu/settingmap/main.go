@@ -1,16 +1,23 @@
 package main
 
+import "flag"
 import "image/color"
 import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/hajimehoshi/ebiten/v2/inpututil"
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+import "github.com/tinne26/ptxt-examples/internal/labelcache"
 import "github.com/tinne26/ggfnt-fonts/jammy"
 
 const CanvasWidth, CanvasHeight = 160, 90
 
+var TextColor = color.RGBA{242, 143, 59, 255}
+
 type Game struct {
 	canvas *ebiten.Image // logical canvas
+	capturer *capture.Capturer
 	text *ptxt.Renderer
+	labels *labelcache.Cache // setting changes render new labels automatically
 }
 
 func (*Game) Layout(int, int) (int, int) { panic("F") }
@@ -20,6 +27,10 @@ func (*Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
 }
 
 func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
 	shift := ebiten.IsKeyPressed(ebiten.KeyShiftLeft) || ebiten.IsKeyPressed(ebiten.KeyShiftLeft)
 	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
 		strand := self.text.Strand()
@@ -56,9 +67,9 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 
 	// draw text
 	self.text.SetAlign(ptxt.LastBaseline | ptxt.Left)
-	self.text.Draw(self.canvas, "[Z] Switch zero mark\n[N] Switch numeric style", 3, CanvasHeight - 3)
+	self.labels.Draw(self.canvas, self.text, "[Z] Switch zero mark\n[N] Switch numeric style", TextColor, 3, CanvasHeight - 3)
 	self.text.SetAlign(ptxt.Center)
-	self.text.Draw(self.canvas, info, CanvasWidth/2, CanvasHeight/2)
+	self.labels.Draw(self.canvas, self.text, info, TextColor, CanvasWidth/2, CanvasHeight/2)
 
 	// project logical canvas to main (optional ptxt utility)
 	ptxt.PixelPerfect.Project(self.canvas, hiResCanvas)
@@ -67,6 +78,8 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 // ---- main function ----
 
 func main() {
+	flag.Parse() // see internal/capture flags
+
 	// initialize font strand
 	strand, err := ptxt.NewStrand(jammy.Font())
 	if err != nil { panic(err) }
@@ -83,11 +96,15 @@ func main() {
 	renderer := ptxt.NewRenderer()
 	renderer.SetStrand(strand)
 	renderer.SetAlign(ptxt.Center)
-	renderer.SetColor(color.RGBA{242, 143, 59, 255})
+	renderer.SetColor(TextColor)
 
 	// set up Ebitengine and start the game
 	ebiten.SetWindowTitle("ptxt-examples/gpu/settingmap")
 	canvas := ebiten.NewImage(CanvasWidth, CanvasHeight)
-	err = ebiten.RunGame(&Game{ text: renderer, canvas: canvas })
+	labels := labelcache.New(CanvasWidth*CanvasHeight*4)
+	err = ebiten.RunGame(&Game{
+		text: renderer, labels: labels, canvas: canvas,
+		capturer: capture.New("settingmap"),
+	})
 	if err != nil { panic(err) }
 }
@@ -2,29 +2,33 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "image/color"
 
 import "github.com/hajimehoshi/ebiten/v2"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/hotfont"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/capture"
 
 const CanvasWidth, CanvasHeight = 160, 90
 
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+
 func main() {
 	// usage check
-	if len(os.Args) != 2 {
-		fmt.Print("Usage: go run main.go font.ggfnt\n")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Print("Usage: go run main.go [--capture-on-exit] [--font jammy|font.ggfnt]\n")
 		os.Exit(1)
 	}
 
-	// open font file
-	fontName := os.Args[1]
-	fontFile, err := os.Open(fontName)
-	if err != nil { panic(err) }
-
-	// parse font and create strand
-	strand, err := ptxt.NewStrand(fontFile)
+	// parse font and create strand (font files are reloaded
+	// automatically when modified)
+	fontLoader, err := hotfont.Open(*fontName)
 	if err != nil { panic(err) }
+	strand := fontLoader.Strand()
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
 	// create strand and renderer
@@ -39,23 +43,35 @@ func main() {
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	err = ebiten.RunGame(&Game{
 		text: renderer,
+		font: fontLoader,
 		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("sideways"),
 	})
 	if err != nil { panic(err) }
 }
 
 type Game struct {
 	text *ptxt.Renderer
+	font *hotfont.Loader
 	canvas *ebiten.Image
+	capturer *capture.Capturer
 }
 
-func (*Game) Update() error { return nil }
 func (*Game) Layout(_, _ int) (int, int) { panic("F") }
 func (*Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
 	scale := ebiten.DeviceScaleFactor()
 	return logicWinWidth*scale, logicWinHeight*scale
 }
 
+func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
+	self.font.Update(self.text) // reload font if modified
+	return nil
+}
+
 func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 	// background color
 	self.canvas.Fill(color.RGBA{229, 255, 222, 255})
@@ -82,4 +98,5 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 
 	// project logical canvas to main (optional ptxt utility)
 	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+	self.font.DrawErrorBanner(hiResCanvas)
 }
@@ -1,9 +1,10 @@
 package main
 
-import ( "math" ; "image/color" )
+import ( "math" ; "flag" ; "image/color" )
 import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/tinne26/ptxt"
-import "github.com/tinne26/ggfnt-fonts/jammy"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
 
 const CanvasWidth, CanvasHeight = 80, 45 // (1/24th of 1920x1080)
 const WordsPerSec = 2.71828
@@ -16,6 +17,7 @@ var Words = []string {
 
 type Game struct {
 	canvas *ebiten.Image // logical canvas
+	capturer *capture.Capturer
 	text *ptxt.Renderer
 	wordIndex float64
 }
@@ -27,6 +29,10 @@ func (*Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float64) {
 }
 
 func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
 	newIndex := (self.wordIndex + WordsPerSec/60.0)
 	self.wordIndex = math.Mod(newIndex, float64(len(Words)))
 	return nil
@@ -46,9 +52,15 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 
 // ---- main function ----
 
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+
 func main() {
+	flag.Parse() // see internal/capture and internal/fontreg flags
+
 	// initialize font strand
-	strand, err := ptxt.NewStrand(jammy.Font())
+	font, err := fontreg.Resolve(*fontName)
+	if err != nil { panic(err) }
+	strand, err := ptxt.NewStrand(font)
 	if err != nil { panic(err) }
 	
 	// create text renderer, set the main properties
@@ -60,6 +72,6 @@ func main() {
 	// set up Ebitengine and start the game
 	ebiten.SetWindowTitle("ptxt-examples/gpu/words")
 	canvas := ebiten.NewImage(CanvasWidth, CanvasHeight)
-	err = ebiten.RunGame(&Game{ text: renderer, canvas: canvas })
+	err = ebiten.RunGame(&Game{ text: renderer, canvas: canvas, capturer: capture.New("words") })
 	if err != nil { panic(err) }
 }
@@ -2,6 +2,7 @@ package main
 
 import "os"
 import "fmt"
+import "flag"
 import "image"
 import "image/color"
 
@@ -9,6 +10,11 @@ import "github.com/hajimehoshi/ebiten/v2"
 import "github.com/hajimehoshi/ebiten/v2/inpututil"
 
 import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/hotfont"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+import "github.com/tinne26/ptxt-examples/internal/capture"
+import "github.com/tinne26/ptxt-examples/internal/inputmap"
+import "github.com/tinne26/ptxt-examples/internal/pseudoloc"
 
 const CanvasWidth, CanvasHeight = 160, 90
 
@@ -16,21 +22,22 @@ var BackgroundColor color.RGBA = color.RGBA{ 59,  82,  73, 255}
 var WrapLineColor   color.RGBA = color.RGBA{ 79, 102,  93, 255}
 var TextColor       color.RGBA = color.RGBA{  6, 167, 125, 255}
 
+var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+var expansionFlag = flag.Float64("expansion", 100*pseudoloc.DefaultExpansion, "pseudo-localization length expansion, in percentage")
+
 func main() {
 	// usage check
-	if len(os.Args) != 2 {
-		fmt.Print("Usage: go run main.go font.ggfnt\n")
+	flag.Parse()
+	if flag.NArg() != 0 || *expansionFlag < 0 {
+		fmt.Print("Usage: go run main.go [--capture-on-exit] [--font jammy|font.ggfnt] [--expansion 30]\n")
 		os.Exit(1)
 	}
 
-	// open font file
-	fontName := os.Args[1]
-	fontFile, err := os.Open(fontName)
-	if err != nil { panic(err) }
-
-	// parse font and create strand
-	strand, err := ptxt.NewStrand(fontFile)
+	// parse font and create strand (font files are reloaded
+	// automatically when modified)
+	fontLoader, err := hotfont.Open(*fontName)
 	if err != nil { panic(err) }
+	strand := fontLoader.Strand()
 	fmt.Printf("Font loaded: %s\n", strand.Font().Header().Name())
 
 	// create strand and renderer
@@ -46,22 +53,35 @@ func main() {
 	// strand.Shadow().SetOffsets(1, 1)
 	// strand.Shadow().SetColor(color.RGBA{52, 21, 21, 52})
 
+	// pseudo-localization, to see how the paragraph reflows
+	// with longer text (toggled with P)
+	pseudo := pseudoloc.New(strand.Font())
+	pseudo.Expansion = *expansionFlag/100
+	fmt.Printf("Press P to toggle pseudo-localization (+%g%% length)\n", *expansionFlag)
+
 	// run game
 	ebiten.SetWindowTitle("ptxt-examples/gpu/wrap")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	err = ebiten.RunGame(&Game{
 		text: renderer,
+		font: fontLoader,
 		canvas: ebiten.NewImage(CanvasWidth, CanvasHeight),
+		capturer: capture.New("wrap"),
 		wrapX: CanvasWidth - (3*CanvasHeight/20),
+		pseudo: pseudo,
 	})
 	if err != nil { panic(err) }
 }
 
 type Game struct {
 	text *ptxt.Renderer
+	font *hotfont.Loader
 	canvas *ebiten.Image
+	capturer *capture.Capturer
 	lastWidth, lastHeight float64
 	wrapX int
+	pseudo *pseudoloc.Transformer
+	pseudoOn bool
 }
 
 func (*Game) Layout(_, _ int) (int, int) { panic("F") }
@@ -73,11 +93,25 @@ func (self *Game) LayoutF(logicWinWidth, logicWinHeight float64) (float64, float
 }
 
 func (self *Game) Update() error {
+	// F12 captures and --capture-on-exit
+	err := self.capturer.Update(self.canvas)
+	if err != nil { return err }
+
+	// reload font if modified
+	if self.font.Update(self.text) {
+		self.pseudo.SetFont(self.font.Strand().Font())
+	}
+
+	// toggle pseudo-localization
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		self.pseudoOn = !self.pseudoOn
+	}
+
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 		hiWidth, hiHeight := int(self.lastWidth), int(self.lastHeight)
 		x, y := ebiten.CursorPosition()
-		x,  _ = ptxt.Proportional.Remap(x, y, hiWidth, hiHeight, CanvasWidth, CanvasHeight)
-		self.wrapX = x
+		x, _, inside := inputmap.Map(ptxt.Proportional, x, y, hiWidth, hiHeight, CanvasWidth, CanvasHeight)
+		if inside { self.wrapX = x } // ignore clicks on letterboxing borders
 	}
 	return nil
 }
@@ -100,8 +134,10 @@ func (self *Game) Draw(hiResCanvas *ebiten.Image) {
 
 	// draw text
 	text := "You may click at any point within this rectangle in order to adjust the wrapping point, which is displayed as a lighter box on the right."
+	if self.pseudoOn { text = self.pseudo.Transform(text) }
 	self.text.DrawWithWrap(self.canvas, text, pad1p5, pad1p5, wrapXStart - pad1p5)
 
 	// project logical canvas to main (optional ptxt utility)
 	ptxt.Proportional.Project(self.canvas, hiResCanvas)
+	self.font.DrawErrorBanner(hiResCanvas)
 }
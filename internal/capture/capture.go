@@ -0,0 +1,155 @@
+// Package capture provides screenshots for the gpu examples. Pressing
+// F12 saves the logical canvas as a PNG, together with an upscaled
+// version that uses an integer scaling factor, so pixels stay crisp.
+//
+// The package also registers a --capture-on-exit flag, which makes
+// the program save a capture automatically when the window is closed.
+//
+// Usage:
+//	flag.Parse()
+//	capturer := capture.New("example_name")
+//	...
+//	// on Update()
+//	err := capturer.Update(logicalCanvas)
+//	if err != nil { return err }
+package capture
+
+import "os"
+import "fmt"
+import "flag"
+import "time"
+import "image"
+import "image/png"
+import "path/filepath"
+
+import "github.com/hajimehoshi/ebiten/v2"
+import "github.com/hajimehoshi/ebiten/v2/inpututil"
+
+var captureOnExit = flag.Bool("capture-on-exit", false, "save a capture of the canvas when the window is closed")
+
+// Key used to take captures.
+var Key = ebiten.KeyF12
+
+// Captures the logical canvas of an example on demand.
+type Capturer struct {
+	name string
+	Scale int // upscaling factor. if zero, it's based on the window size
+}
+
+// Creates a new capturer. The name is used to generate the output
+// filenames, which look like "ptxt_examples_gpu_{name}_{timestamp}.png".
+//
+// Must be called after flag.Parse() and before ebiten.RunGame().
+func New(name string) *Capturer {
+	if !flag.Parsed() { panic("capture.New() called before flag.Parse()") }
+	if *captureOnExit {
+		ebiten.SetWindowClosingHandled(true)
+	}
+	return &Capturer{ name: name }
+}
+
+// Must be called once per tick from [ebiten.Game].Update(). When the
+// capture key is pressed, the given canvas is saved. If the window is
+// being closed and --capture-on-exit is set, the canvas is saved and
+// [ebiten.Termination] is returned.
+//
+// Since this is called on Update(), captures show the most recently
+// drawn frame.
+func (self *Capturer) Update(canvas *ebiten.Image) error {
+	if *captureOnExit && ebiten.IsWindowBeingClosed() {
+		self.saveAndReport(canvas)
+		return ebiten.Termination
+	}
+	if inpututil.IsKeyJustPressed(Key) {
+		self.saveAndReport(canvas)
+	}
+	return nil
+}
+
+func (self *Capturer) saveAndReport(canvas *ebiten.Image) {
+	logicalFilename, scaledFilename, err := self.Save(canvas)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Capture failed: %s\n", err)
+	} else {
+		fmt.Printf("Capture saved: %s\n", logicalFilename)
+		fmt.Printf("Capture saved: %s\n", scaledFilename)
+	}
+}
+
+// Saves the canvas as a PNG on the working directory, together with
+// an upscaled version, and returns the absolute paths of both files.
+func (self *Capturer) Save(canvas *ebiten.Image) (string, string, error) {
+	// read canvas pixels
+	bounds := canvas.Bounds()
+	logical := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	canvas.ReadPixels(logical.Pix) // both use premultiplied alpha
+
+	// get filenames
+	scale := self.Scale
+	if scale <= 0 { scale = autoScale(bounds.Dx(), bounds.Dy()) }
+	stem := "ptxt_examples_gpu_" + self.name + "_" + time.Now().Format("20060102_150405")
+	basename := stem
+	for i := 2; fileExists(basename + ".png"); i++ { // multiple captures within a second
+		basename = fmt.Sprintf("%s_%d", stem, i)
+	}
+	logicalFilename, err := filepath.Abs(basename + ".png")
+	if err != nil { return "", "", err }
+	scaledFilename, err := filepath.Abs(fmt.Sprintf("%s_x%d.png", basename, scale))
+	if err != nil { return "", "", err }
+
+	// export both images
+	err = exportPNG(logicalFilename, logical)
+	if err != nil { return "", "", err }
+	err = exportPNG(scaledFilename, upscale(logical, scale))
+	if err != nil { return "", "", err }
+	return logicalFilename, scaledFilename, nil
+}
+
+// Saves the given image as a PNG file, without any scaling.
+// Like [Capturer.Save](), it must be called while the game is
+// running.
+func WritePNG(filename string, img *ebiten.Image) error {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	img.ReadPixels(rgba.Pix)
+	return exportPNG(filename, rgba)
+}
+
+// Returns the biggest integer scaling factor that fits within the
+// window, and at least 2.
+func autoScale(width, height int) int {
+	winWidth, winHeight := ebiten.WindowSize()
+	factor := ebiten.DeviceScaleFactor()
+	xScale := int(float64(winWidth)*factor)/max(width, 1)
+	yScale := int(float64(winHeight)*factor)/max(height, 1)
+	return max(min(xScale, yScale), 2)
+}
+
+func upscale(img *image.RGBA, scale int) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	scaled := image.NewRGBA(image.Rect(0, 0, width*scale, height*scale))
+	for y := 0; y < height*scale; y++ {
+		srcRow := img.Pix[(y/scale)*img.Stride : ]
+		dstRow := scaled.Pix[y*scaled.Stride : ]
+		for x := 0; x < width*scale; x++ {
+			copy(dstRow[x*4 : x*4 + 4], srcRow[(x/scale)*4 : (x/scale)*4 + 4])
+		}
+	}
+	return scaled
+}
+
+func exportPNG(filename string, img image.Image) error {
+	file, err := os.Create(filename)
+	if err != nil { return err }
+	err = png.Encode(file, img)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
@@ -0,0 +1,258 @@
+// Package fallback provides a renderer that draws text with an ordered
+// list of font strands: each code point is drawn with the first strand
+// that can map it, so glyphs missing in the primary font can be taken
+// from other fonts instead of showing up as notdef (or making ptxt
+// panic).
+//
+// Text is split into segments of consecutive code points covered by
+// the same strand. All segments of a line share the same baseline,
+// and line heights use the largest ascent, descent and line height of
+// the chain, so lines are evenly spaced even when the fonts' metrics
+// differ. Only horizontal text is supported.
+//
+// Usage:
+//	chain := fallback.New(primaryStrand, fallbackStrand)
+//	chain.Renderer().SetScale(2)
+//	chain.SetAlign(ptxt.Center)
+//	chain.Draw(canvas, "Hello 世界", x, y)
+package fallback
+
+import "image"
+import "unicode"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/core"
+import "github.com/tinne26/ptxt/strand"
+
+// Strand index of segments with code points no strand can map.
+// These segments are neither drawn nor measured.
+const Missing = -1
+
+// A piece of text drawn with a single strand of the chain.
+type Segment struct {
+	Text string
+	StrandIndex int // index of the strand in the chain, or Missing
+	Bounds image.Rectangle // logical bounds, only set by Renderer.Layout()
+}
+
+// A renderer that draws text with a chain of strands. Scale, color,
+// blend mode and other renderer properties are configured through
+// [Renderer.Renderer](), but the align must be set with
+// [Renderer.SetAlign]() instead.
+type Renderer struct {
+	renderer *ptxt.Renderer
+	strands []*strand.Strand
+	align ptxt.Align
+}
+
+// Creates a renderer for the given strands, in order of preference.
+func New(strands ...*strand.Strand) *Renderer {
+	if len(strands) == 0 { panic("fallback.New() requires at least one strand") }
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strands[0])
+	renderer.SetAlign(ptxt.Left | ptxt.Baseline)
+	return &Renderer{
+		renderer: renderer,
+		strands: strands,
+		align: ptxt.Left | ptxt.Baseline,
+	}
+}
+
+// Returns the underlying renderer, for configuration purposes. Its
+// strand and align are modified while drawing and measuring.
+func (self *Renderer) Renderer() *ptxt.Renderer { return self.renderer }
+
+// Returns the strands of the chain, in order of preference.
+func (self *Renderer) Strands() []*strand.Strand { return self.strands }
+
+// Sets the align, with the same semantics as [ptxt.Renderer.SetAlign]().
+func (self *Renderer) SetAlign(align ptxt.Align) {
+	self.align = self.align.Adjusted(align)
+}
+
+// Returns the current align.
+func (self *Renderer) GetAlign() ptxt.Align { return self.align }
+
+// Splits the text into segments by strand coverage. Line breaks are
+// kept as separate segments with the strand index of the previous
+// segment. Spaces stay in the current segment if its strand can map
+// them, so fallback words aren't split unnecessarily.
+func (self *Renderer) Segment(text string) []Segment {
+	var segments []Segment
+	var start, lastStrand int
+	current := Missing
+	for i, codePoint := range text {
+		if codePoint == '\n' {
+			if i > start { segments = append(segments, Segment{ Text: text[start : i], StrandIndex: current }) }
+			segments = append(segments, Segment{ Text: "\n", StrandIndex: lastStrand })
+			start, current = i + 1, Missing
+			continue
+		}
+
+		index := current
+		if current == Missing || !unicode.IsSpace(codePoint) || !self.canMap(current, codePoint) {
+			index = self.strandFor(codePoint)
+		}
+		if i > start && index != current {
+			segments = append(segments, Segment{ Text: text[start : i], StrandIndex: current })
+			start = i
+		}
+		current = index
+		if current != Missing { lastStrand = current }
+	}
+	if start < len(text) {
+		segments = append(segments, Segment{ Text: text[start : ], StrandIndex: current })
+	}
+	return segments
+}
+
+// Returns the segments of the text with their logical bounds when drawn
+// at the given coordinates. Line break segments are not included.
+func (self *Renderer) Layout(text string, x, y int) []Segment {
+	segments, _, _ := self.layout(text, x, y)
+	return segments
+}
+
+// Returns the logical width and height of the text, including
+// segments drawn with fallback strands.
+func (self *Renderer) Measure(text string) (width, height int) {
+	_, width, height = self.layout(text, 0, 0)
+	return width, height
+}
+
+// Draws the text at the given coordinates, interpreted according to
+// the current align. Code points that no strand can map are skipped.
+func (self *Renderer) Draw(target core.Target, text string, x, y int) {
+	ascent, _, _ := self.lineMetrics()
+	for _, segment := range self.Layout(text, x, y) {
+		if segment.StrandIndex == Missing { continue }
+		self.renderer.SetStrand(self.strands[segment.StrandIndex])
+		self.renderer.Draw(target, segment.Text, segment.Bounds.Min.X, segment.Bounds.Min.Y + ascent)
+	}
+	self.renderer.SetStrand(self.strands[0])
+}
+
+func (self *Renderer) layout(text string, x, y int) ([]Segment, int, int) {
+	lines := splitLines(self.Segment(text))
+	ascent, descent, lineHeight := self.lineMetrics()
+
+	// measure segments and lines
+	lineWidths := make([]int, len(lines))
+	var width int
+	for i, line := range lines {
+		var lineWidth int
+		for j := range line {
+			segment := &line[j]
+			if segment.StrandIndex != Missing {
+				if lineWidth > 0 { lineWidth += self.interspacing(segment.StrandIndex) }
+				segment.Bounds.Min.X = lineWidth
+				lineWidth += self.segmentWidth(segment)
+			} else {
+				segment.Bounds.Min.X = lineWidth
+			}
+			segment.Bounds.Max.X = lineWidth
+		}
+		lineWidths[i] = lineWidth
+		width = max(width, lineWidth)
+	}
+	height := ascent + descent + (len(lines) - 1)*lineHeight
+
+	// apply align
+	var baseline int
+	switch self.align.Vert() {
+	case ptxt.Top          : baseline = y + ascent
+	case ptxt.CapLine      : baseline = y + self.primaryShift(true, ascent)
+	case ptxt.Midline      : baseline = y + self.primaryShift(false, ascent)
+	case ptxt.VertCenter   : baseline = y - height/2 + ascent
+	case ptxt.Baseline     : baseline = y
+	case ptxt.Bottom       : baseline = y - height + ascent
+	case ptxt.LastBaseline : baseline = y - (len(lines) - 1)*lineHeight
+	default:
+		panic("unexpected align")
+	}
+
+	var placed []Segment
+	for i, line := range lines {
+		lineX := x
+		switch self.align.Horz() {
+		case ptxt.HorzCenter : lineX = x - lineWidths[i]/2
+		case ptxt.Right      : lineX = x - lineWidths[i]
+		}
+		for _, segment := range line {
+			segment.Bounds = image.Rect(lineX + segment.Bounds.Min.X, baseline - ascent, lineX + segment.Bounds.Max.X, baseline + descent)
+			placed = append(placed, segment)
+		}
+		baseline += lineHeight
+	}
+	return placed, width, height
+}
+
+// ---- helpers ----
+
+func (self *Renderer) strandFor(codePoint rune) int {
+	for i := range self.strands {
+		if self.canMap(i, codePoint) { return i }
+	}
+	return Missing
+}
+
+func (self *Renderer) canMap(strandIndex int, codePoint rune) bool {
+	fontStrand := self.strands[strandIndex]
+	settings := fontStrand.UnderlyingSettingsCache().UnsafeSlice()
+	_, found := fontStrand.Font().Mapping().Utf8(codePoint, settings)
+	return found
+}
+
+// Returns the largest ascent, descent and line height of the chain,
+// already scaled.
+func (self *Renderer) lineMetrics() (ascent, descent, lineHeight int) {
+	scale := int(self.renderer.GetScale())
+	for _, fontStrand := range self.strands {
+		metrics := fontStrand.Font().Metrics()
+		ascent = max(ascent, int(metrics.Ascent())*scale)
+		descent = max(descent, int(metrics.Descent())*scale)
+		lineHeight = max(lineHeight, (metrics.LineHeight() + int(fontStrand.VertInterspacingShift()))*scale)
+	}
+	return ascent, descent, lineHeight
+}
+
+func (self *Renderer) segmentWidth(segment *Segment) int {
+	self.renderer.SetStrand(self.strands[segment.StrandIndex])
+	width, _ := self.renderer.Measure(segment.Text)
+	self.renderer.SetStrand(self.strands[0])
+	return width
+}
+
+// Interspacing between the previous segment and a segment of the given
+// strand, like the interspacing between glyphs of the same strand.
+func (self *Renderer) interspacing(strandIndex int) int {
+	fontStrand := self.strands[strandIndex]
+	interspacing := int(fontStrand.Font().Metrics().HorzInterspacing()) + int(fontStrand.HorzInterspacingShift())
+	return interspacing*int(self.renderer.GetScale())
+}
+
+// Distance from the top of the primary strand's cap line or midline to
+// the baseline, with the same fallbacks as ptxt when they are undefined.
+func (self *Renderer) primaryShift(capLine bool, ascent int) int {
+	metrics := self.strands[0].Font().Metrics()
+	scale := int(self.renderer.GetScale())
+	if capLine {
+		if metrics.UppercaseAscent() == 0 { return ascent }
+		return int(metrics.UppercaseAscent())*scale
+	}
+	if metrics.MidlineAscent() == 0 { return ascent >> 1 }
+	return int(metrics.MidlineAscent())*scale
+}
+
+// Groups segments by line, dropping the line break segments.
+func splitLines(segments []Segment) [][]Segment {
+	lines := [][]Segment{ nil }
+	for _, segment := range segments {
+		if segment.Text == "\n" {
+			lines = append(lines, nil)
+			continue
+		}
+		lines[len(lines) - 1] = append(lines[len(lines) - 1], segment)
+	}
+	return lines
+}
@@ -0,0 +1,121 @@
+// Package fontconv contains the glyph mask, metrics and verification
+// helpers shared by the ggfnt converters (ggfnt/fromttf, ggfnt/frombdf
+// and ggfnt/fromsheet). The ggfnt/tobmfont exporter also uses the mask
+// comparison.
+//
+// Masks follow the ggfnt conventions: the origin is at the baseline,
+// on the left side of the glyph, so pixels above the baseline have
+// negative y coordinates.
+package fontconv
+
+import "os"
+import "fmt"
+import "image"
+import "image/draw"
+import "unicode/utf8"
+
+import "github.com/tinne26/ggfnt"
+
+// Crops the mask to the bounds of its non-empty pixels. The result is
+// a new image, as the ggfnt mask encoder doesn't accept subimages.
+func Trim(mask *image.Alpha) *image.Alpha {
+	bounds := image.Rectangle{}
+	for y := mask.Rect.Min.Y; y < mask.Rect.Max.Y; y++ {
+		for x := mask.Rect.Min.X; x < mask.Rect.Max.X; x++ {
+			if mask.AlphaAt(x, y).A == 0 { continue }
+			bounds = bounds.Union(image.Rect(x, y, x + 1, y + 1))
+		}
+	}
+	trimmed := image.NewAlpha(bounds)
+	draw.Draw(trimmed, bounds, mask, bounds.Min, draw.Src)
+	return trimmed
+}
+
+// Compares the masks by their set pixels. Nil masks are empty.
+func SameMask(a, b *image.Alpha) bool {
+	if a == nil { a = &image.Alpha{} }
+	if b == nil { b = &image.Alpha{} }
+	rect := a.Bounds().Union(b.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if (a.AlphaAt(x, y).A == 0) != (b.AlphaAt(x, y).A == 0) { return false }
+		}
+	}
+	return true
+}
+
+// Reports whether the mask is non-empty and fits within the given
+// ascent and descent (which should include the extra ascent and
+// descent).
+func FitsMetrics(mask *image.Alpha, ascent, descent int) bool {
+	if mask.Rect.Empty() { return false }
+	return -mask.Rect.Min.Y <= ascent && mask.Rect.Max.Y <= descent
+}
+
+// Placeholder for missing or unusable notdef glyphs: a hollow
+// rectangle as tall as the uppercase letters.
+func NotdefMask(uppercaseAscent int) *image.Alpha {
+	height := max(uppercaseAscent, 3)
+	width := max(height*2/3, 3)
+	mask := image.NewAlpha(image.Rect(0, -height, width, 0))
+	for y := -height; y < 0; y++ {
+		for x := 0; x < width; x++ {
+			if x == 0 || x == width - 1 || y == -height || y == -1 {
+				mask.Pix[mask.PixOffset(x, y)] = 255
+			}
+		}
+	}
+	return mask
+}
+
+// Truncates the string to at most n bytes, without splitting runes.
+// Useful for ggfnt header fields, which have length limits.
+func Truncate(str string, n int) string {
+	if len(str) <= n { return str }
+	for n > 0 && !utf8.RuneStart(str[n]) { n -= 1 }
+	return str[ : n]
+}
+
+// ---- verification ----
+
+// Parses and validates the exported font, so converters can check
+// the result against their source data.
+func ParseExported(path string) (*ggfnt.Font, error) {
+	file, err := os.Open(path)
+	if err != nil { return nil, err }
+	defer file.Close()
+	font, err := ggfnt.Parse(file)
+	if err != nil { return nil, err }
+	err = font.Validate(ggfnt.FmtDefault)
+	if err != nil { return nil, err }
+	return font, nil
+}
+
+// Checks that the font has the expected number of glyphs.
+func VerifyGlyphCount(font *ggfnt.Font, count int) error {
+	if int(font.Glyphs().Count()) != count {
+		return fmt.Errorf("expected %d glyphs, found %d", count, font.Glyphs().Count())
+	}
+	return nil
+}
+
+// Checks that the glyph's mask and advance match the given ones.
+// Errors don't identify the glyph, so callers should wrap them.
+func VerifyGlyph(font *ggfnt.Font, index ggfnt.GlyphIndex, mask *image.Alpha, advance int) error {
+	if !SameMask(font.Glyphs().RasterizeMask(index), mask) {
+		return fmt.Errorf("mask mismatch")
+	}
+	if int(font.Glyphs().Advance(index)) != advance {
+		return fmt.Errorf("advance mismatch")
+	}
+	return nil
+}
+
+// Checks that the code point is mapped to the given glyph only.
+func VerifyMapping(font *ggfnt.Font, codePoint rune, index ggfnt.GlyphIndex) error {
+	group, found := font.Mapping().Utf8(codePoint, nil)
+	if !found || group.Size() != 1 || group.Select(0) != index {
+		return fmt.Errorf("U+%04X mapping mismatch", codePoint)
+	}
+	return nil
+}
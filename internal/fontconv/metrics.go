@@ -0,0 +1,129 @@
+package fontconv
+
+import "image"
+
+// Sample glyphs used to derive the font metrics, by priority.
+const CapSamples = "HIEFTLKNMZ"
+const MidlineSamples = "xzvwuonmsr"
+const AscenderSamples = "bdhklf"
+const DescenderSamples = "gjpqy"
+
+// Vertical metrics for the ggfnt font, in pixels.
+type Metrics struct {
+	Ascent, ExtraAscent int
+	Descent, ExtraDescent int
+	UppercaseAscent int
+	MidlineAscent int
+	LineGap int
+}
+
+// Reference lines used by [DeriveMetrics]() when the sample glyphs
+// are missing. Source describes where they come from in the notes
+// (e.g. "the ttf metrics").
+type Fallback struct {
+	CapHeight int
+	Midline int // if <= 0, the cap height is used
+	Descent int
+	Source string
+}
+
+// Derives the vertical metrics from the sample glyph masks, indexed
+// by code point, and calls [FitExtras]() for all the masks. The line
+// gap is left to the caller. Returns the metrics and notes about the
+// fallbacks and adjustments used.
+func DeriveMetrics(masks map[rune]*image.Alpha, all []*image.Alpha, fallback Fallback) (Metrics, []string) {
+	var notes []string
+	var metrics Metrics
+
+	// main reference lines
+	capHeight, found := FirstTop(masks, CapSamples)
+	if !found {
+		capHeight = fallback.CapHeight
+		notes = append(notes, "no uppercase samples, using the cap height from " + fallback.Source)
+	}
+	midline, found := FirstTop(masks, MidlineSamples)
+	if !found {
+		midline = fallback.Midline
+		if midline <= 0 {
+			midline = capHeight
+			notes = append(notes, "no lowercase samples, using the cap height as the midline")
+		} else {
+			notes = append(notes, "no lowercase samples, using the midline from " + fallback.Source)
+		}
+	}
+	ascenders, _ := MaxTop(masks, AscenderSamples)
+	descent, found := MaxBottom(masks, DescenderSamples)
+	if !found {
+		descent = fallback.Descent
+		notes = append(notes, "no descender samples, using the descent from " + fallback.Source)
+	}
+
+	metrics.Ascent = max(capHeight, ascenders, 1)
+	metrics.UppercaseAscent = min(capHeight, metrics.Ascent)
+	metrics.MidlineAscent = min(max(midline, 0), metrics.UppercaseAscent)
+	metrics.Descent = max(descent, 1)
+	notes = append(notes, FitExtras(&metrics, all)...)
+	return metrics, notes
+}
+
+// Sets the extra ascent and descent so that all the masks fit. As
+// ggfnt requires the ascent and descent to be bigger than their
+// extras, extras that would be too big are merged into the main
+// values instead. Returns notes about the merges.
+func FitExtras(metrics *Metrics, masks []*image.Alpha) []string {
+	var notes []string
+	var top, bottom int
+	for _, mask := range masks {
+		if mask == nil || mask.Rect.Empty() { continue }
+		top = max(top, -mask.Rect.Min.Y)
+		bottom = max(bottom, mask.Rect.Max.Y)
+	}
+	metrics.ExtraAscent = max(top - metrics.Ascent, 0)
+	metrics.ExtraDescent = max(bottom - metrics.Descent, 0)
+	if metrics.ExtraAscent >= metrics.Ascent {
+		metrics.Ascent, metrics.ExtraAscent = top, 0
+		notes = append(notes, "extra ascent would exceed ascent, merged into ascent")
+	}
+	if metrics.ExtraDescent >= metrics.Descent {
+		metrics.Descent, metrics.ExtraDescent = bottom, 0
+		notes = append(notes, "extra descent would exceed descent, merged into descent")
+	}
+	return notes
+}
+
+// Returns the top of the first sample glyph found. Reference lines
+// are taken from a single glyph, as round letters often overshoot.
+func FirstTop(masks map[rune]*image.Alpha, samples string) (int, bool) {
+	for _, codePoint := range samples {
+		mask := masks[codePoint]
+		if mask == nil || mask.Rect.Empty() { continue }
+		return -mask.Rect.Min.Y, true
+	}
+	return 0, false
+}
+
+// Returns the highest top among all the sample glyphs found.
+func MaxTop(masks map[rune]*image.Alpha, samples string) (int, bool) {
+	var top int
+	var anyFound bool
+	for _, codePoint := range samples {
+		mask := masks[codePoint]
+		if mask == nil || mask.Rect.Empty() { continue }
+		top = max(top, -mask.Rect.Min.Y)
+		anyFound = true
+	}
+	return top, anyFound
+}
+
+// Returns the lowest bottom among all the sample glyphs found.
+func MaxBottom(masks map[rune]*image.Alpha, samples string) (int, bool) {
+	var bottom int
+	var anyFound bool
+	for _, codePoint := range samples {
+		mask := masks[codePoint]
+		if mask == nil || mask.Rect.Empty() { continue }
+		bottom = max(bottom, mask.Rect.Max.Y)
+		anyFound = true
+	}
+	return bottom, anyFound
+}
@@ -0,0 +1,143 @@
+// Package fontreg provides a registry of fonts for the examples, so
+// they can be run without arguments and fonts can be selected by name.
+//
+// The fonts from ggfnt-fonts are bundled by default. Extra .ggfnt
+// files can be registered from an [embed.FS] with [RegisterFS](), or
+// from a directory with [RegisterDir]() or the --font-dir flag.
+//
+// Usage:
+//	var fontName = fontreg.Flag(fontreg.Default) // --font and --font-dir
+//	...
+//	flag.Parse()
+//	font, err := fontreg.Resolve(*fontName) // "jammy" or "path/to.ggfnt"
+//	if err != nil { panic(err) }
+package fontreg
+
+import "os"
+import "fmt"
+import "flag"
+import "sort"
+import "bytes"
+import "errors"
+import "io/fs"
+import "strings"
+import "path"
+
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ggfnt-fonts/jammy"
+import "github.com/tinne26/ggfnt-fonts/jumpy"
+
+// Name of the font used by the examples when none is specified.
+const Default = "jammy"
+
+// Extension of the files registered by [RegisterFS]().
+const Ext = ".ggfnt"
+
+var registry = make(map[string]func() (*ggfnt.Font, error))
+
+var fontDir *string
+
+func init() {
+	Register("jammy", func() (*ggfnt.Font, error) { return jammy.Font(), nil })
+	Register("jumpy", func() (*ggfnt.Font, error) { return jumpy.Font(), nil })
+}
+
+// Registers a font under the given name. Names are case insensitive,
+// and registering the same name twice replaces the previous font.
+func Register(name string, load func() (*ggfnt.Font, error)) {
+	if name == "" { panic("fontreg.Register(): empty font name") }
+	registry[strings.ToLower(name)] = load
+}
+
+// Registers all the .ggfnt files in the given directory of the file
+// system, which can be an [embed.FS]. Fonts are named after their
+// filenames without the extension, and they aren't parsed until
+// they are resolved. Subdirectories are ignored.
+//
+// Returns the names of the registered fonts.
+func RegisterFS(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil { return nil, err }
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(path.Ext(entry.Name()), Ext) { continue }
+		filename := path.Join(dir, entry.Name())
+		name := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		Register(name, func() (*ggfnt.Font, error) {
+			data, err := fs.ReadFile(fsys, filename)
+			if err != nil { return nil, err }
+			return ggfnt.Parse(bytes.NewReader(data))
+		})
+		names = append(names, strings.ToLower(name))
+	}
+	return names, nil
+}
+
+// Same as [RegisterFS]() for a directory of the operating system.
+func RegisterDir(dir string) ([]string, error) {
+	return RegisterFS(os.DirFS(dir), ".")
+}
+
+// Returns the sorted names of all the registered fonts.
+func Names() []string {
+	registerFlagDir()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reports whether the given name refers to a registered font. When
+// false, [Resolve]() will treat the name as a file path.
+func IsRegistered(name string) bool {
+	registerFlagDir()
+	_, found := registry[strings.ToLower(name)]
+	return found
+}
+
+// Returns the registered font with the given name or, if there's
+// none, parses the font at the given path. Registered names take
+// precedence, so use "./jammy" to load a file literally named "jammy".
+func Resolve(nameOrPath string) (*ggfnt.Font, error) {
+	registerFlagDir()
+	load, found := registry[strings.ToLower(nameOrPath)]
+	if found { return load() }
+
+	file, err := os.Open(nameOrPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !strings.ContainsAny(nameOrPath, `./\`) {
+			return nil, fmt.Errorf("unknown font %q (available: %s)", nameOrPath, strings.Join(Names(), ", "))
+		}
+		return nil, err
+	}
+	font, err := ggfnt.Parse(file)
+	closeErr := file.Close()
+	if err != nil { return nil, err }
+	return font, closeErr
+}
+
+// Defines the --font and --font-dir flags on [flag.CommandLine] and
+// returns the --font value, to be passed to [Resolve]() after calling
+// [flag.Parse](). The font directory is registered automatically.
+func Flag(defaultFont string) *string {
+	usage := "font name (" + strings.Join(Names(), ", ") + ") or path to a " + Ext + " file"
+	fontName := flag.String("font", defaultFont, usage)
+	fontDir = flag.String("font-dir", "", "directory with extra " + Ext + " files to register by name")
+	return fontName
+}
+
+// Registers the --font-dir directory, if any. Errors are reported
+// and make the program exit, like invalid flag values do.
+func registerFlagDir() {
+	if fontDir == nil || *fontDir == "" || !flag.Parsed() { return }
+	dir := *fontDir
+	*fontDir = "" // only once
+	_, err := RegisterDir(dir)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid value %q for flag -font-dir: %s\n", dir, err)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,111 @@
+// Package ggfntraw reads ggfnt tables directly from the font data,
+// for the ggfnt tools that need more than the ggfnt API exposes
+// (ggfnt/mapping, ggfnt/subset and ggfnt/gengo). The ggfnt API only
+// resolves mappings for the active settings and doesn't give access
+// to the glyph names by index.
+//
+// The font is assumed to be valid, as ggfnt.Parse() already checks
+// the table offsets and sizes.
+package ggfntraw
+
+import "encoding/binary"
+
+import "github.com/tinne26/ggfnt"
+
+// Switch types with special meaning in a mapping entry. Anything
+// else is a switch with one glyph group per case.
+const (
+	SwitchSingleGroup uint8 = 254 // a glyph group without settings
+	SwitchUnconditional uint8 = 255 // a single glyph
+)
+
+// A mapping entry. Data is the raw entry, starting with the switch
+// type, as stored in the font.
+type MappingEntry struct {
+	CodePoint rune
+	Data []byte
+}
+
+// One or more glyphs (animations, random variations, etc.).
+type GlyphGroup struct {
+	Glyphs []ggfnt.GlyphIndex
+	Flags uint8 // animation flags, only for groups with multiple glyphs
+}
+
+// Calls the given function for each mapping entry, in code point order.
+func EachMappingEntry(font *ggfnt.Font, each func(MappingEntry)) {
+	data := font.Data[font.OffsetToMapping : ]
+	numEntries := int(binary.LittleEndian.Uint16(data))
+	codePoints := data[2 : ]
+	endOffsets := codePoints[numEntries*4 : ]
+	mappings := endOffsets[numEntries*3 : ]
+	var start int
+	for i := 0; i < numEntries; i++ {
+		codePoint := rune(int32(binary.LittleEndian.Uint32(codePoints[i*4 : ])))
+		end := ReadUint24(endOffsets[i*3 : ])
+		each(MappingEntry{ CodePoint: codePoint, Data: mappings[start : end] })
+		start = end
+	}
+}
+
+// Returns the entry's switch type.
+func (self *MappingEntry) SwitchType() uint8 {
+	return self.Data[0]
+}
+
+// Returns the entry's glyph groups, one per switch case. Unconditional
+// mappings and single glyph groups return a single group.
+func (self *MappingEntry) GlyphGroups() []GlyphGroup {
+	data := self.Data[1 : ]
+	if self.SwitchType() == SwitchUnconditional {
+		glyph := ggfnt.GlyphIndex(binary.LittleEndian.Uint16(data))
+		return []GlyphGroup{ { Glyphs: []ggfnt.GlyphIndex{ glyph } } }
+	}
+
+	var groups []GlyphGroup
+	for index := 0; index < len(data); {
+		var group GlyphGroup
+		info := data[index]
+		size := int(info & 0b0111_1111) + 1
+		index += 1
+		if size > 1 {
+			group.Flags = data[index]
+			index += 1
+		}
+		if info & 0b1000_0000 != 0 { // range
+			first := int(binary.LittleEndian.Uint16(data[index : ]))
+			for glyphIndex := first; glyphIndex < first + size; glyphIndex++ {
+				group.Glyphs = append(group.Glyphs, ggfnt.GlyphIndex(glyphIndex))
+			}
+			index += 2
+		} else { // list
+			for i := 0; i < size; i++ {
+				group.Glyphs = append(group.Glyphs, ggfnt.GlyphIndex(binary.LittleEndian.Uint16(data[index : ])))
+				index += 2
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// Calls the given function for each named glyph, in the font's
+// order (sorted by name).
+func EachNamedGlyph(font *ggfnt.Font, each func(ggfnt.GlyphIndex, string)) {
+	data := font.Data[font.OffsetToGlyphNames : ]
+	numNamed := int(binary.LittleEndian.Uint16(data))
+	ids := data[2 : ]
+	endOffsets := ids[numNamed*2 : ]
+	names := endOffsets[numNamed*3 : ]
+	var start int
+	for i := 0; i < numNamed; i++ {
+		end := ReadUint24(endOffsets[i*3 : ])
+		each(ggfnt.GlyphIndex(binary.LittleEndian.Uint16(ids[i*2 : ])), string(names[start : end]))
+		start = end
+	}
+}
+
+// Reads a little endian uint24, as used for ggfnt offsets and counts.
+func ReadUint24(data []byte) int {
+	return int(data[0]) | int(data[1]) << 8 | int(data[2]) << 16
+}
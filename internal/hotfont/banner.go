@@ -0,0 +1,58 @@
+package hotfont
+
+import "image"
+import "strings"
+import "image/color"
+
+import "github.com/hajimehoshi/ebiten/v2"
+import "github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+var BannerBackColor = color.RGBA{128, 24, 32, 230}
+
+// Size of ebitenutil's debug font characters, in pixels.
+const debugCharWidth, debugLineHeight = 6, 16
+
+// If the last reload failed, draws a banner with the error message
+// at the top of the given target. Otherwise, it does nothing.
+//
+// The banner uses Ebitengine's debug font instead of ptxt, as the
+// problem might be the font itself. Draw it on the high resolution
+// canvas, after projecting the logical canvas, so it's readable.
+func (self *Loader) DrawErrorBanner(target *ebiten.Image) {
+	if self.err == nil { return }
+
+	const Pad = 4
+	bounds := target.Bounds()
+	maxChars := max((bounds.Dx() - Pad*2)/debugCharWidth, 1)
+	lines := wrapLines("FONT RELOAD FAILED: " + self.err.Error(), maxChars)
+	bannerHeight := len(lines)*debugLineHeight + Pad*2
+	bannerRect := image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y + bannerHeight)
+	target.SubImage(bannerRect).(*ebiten.Image).Fill(BannerBackColor)
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(target, line, bounds.Min.X + Pad, bounds.Min.Y + Pad + i*debugLineHeight)
+	}
+}
+
+// Splits the text in lines of at most maxChars characters, breaking
+// at spaces when possible.
+func wrapLines(text string, maxChars int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line []rune
+		for _, word := range strings.Fields(paragraph) {
+			wordRunes := []rune(word)
+			if len(line) > 0 && len(line) + 1 + len(wordRunes) > maxChars {
+				lines = append(lines, string(line))
+				line = line[ : 0]
+			}
+			if len(line) > 0 { line = append(line, ' ') }
+			line = append(line, wordRunes...)
+			for len(line) > maxChars { // split words that don't fit
+				lines = append(lines, string(line[ : maxChars]))
+				line = append(line[ : 0], line[maxChars : ]...)
+			}
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -0,0 +1,202 @@
+// Package hotfont provides a font loader that watches a .ggfnt file
+// and reloads it automatically when it changes, which is very handy
+// while designing fonts: re-export the font and see the changes
+// directly on the running program.
+//
+// Usage:
+//	loader, err := hotfont.Open("font.ggfnt") // or a fontreg name, like "jammy"
+//	if err != nil { panic(err) }
+//	renderer.SetStrand(loader.Strand())
+//	...
+//	// on Update()
+//	if loader.Update(renderer) { /* recompute font dependent state */ }
+//	...
+//	// on Draw(), after projecting the logical canvas
+//	loader.DrawErrorBanner(hiResCanvas)
+package hotfont
+
+import "os"
+import "fmt"
+import "time"
+import "bytes"
+import "hash/fnv"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// Default interval between font file checks.
+const DefaultPollInterval = 500*time.Millisecond
+
+// A font loader that polls a .ggfnt file for changes and swaps
+// the reloaded strand into the given renderers.
+//
+// Strand settings modified through [strand.Strand.SetSetting]() are
+// preserved across reloads, matching the settings by name. Rewrite
+// rules are also re-initialized if [Loader.AutoInitRewriteRules]()
+// has been used. Any other strand configuration (shadows, glyph
+// pickers, etc.) has to be reapplied manually when [Loader.Update]()
+// returns true.
+type Loader struct {
+	path string
+	strand *strand.Strand
+	PollInterval time.Duration
+
+	lastPoll time.Time
+	modTime time.Time
+	size int64
+	hash uint64
+	autoInitRewriteRules bool
+	err error
+}
+
+// Loads the font at the given path and returns a loader for it.
+// Errors on the initial load are returned directly, as there's no
+// previous font to fall back to.
+func New(path string) (*Loader, error) {
+	info, err := os.Stat(path)
+	if err != nil { return nil, err }
+	data, err := os.ReadFile(path)
+	if err != nil { return nil, err }
+	fontStrand, err := ptxt.NewStrand(data)
+	if err != nil { return nil, err }
+	return &Loader{
+		path: path,
+		strand: fontStrand,
+		PollInterval: DefaultPollInterval,
+		lastPoll: time.Now(),
+		modTime: info.ModTime(),
+		size: info.Size(),
+		hash: hashBytes(data),
+	}, nil
+}
+
+// Like [New](), but also accepts the names of fonts registered on
+// [fontreg]. Registered fonts are not files, so they are never
+// reloaded and [Loader.Update]() simply returns false.
+func Open(nameOrPath string) (*Loader, error) {
+	if !fontreg.IsRegistered(nameOrPath) {
+		_, err := os.Stat(nameOrPath)
+		if err != nil { // let fontreg explain unknown names
+			_, err = fontreg.Resolve(nameOrPath)
+			return nil, err
+		}
+		return New(nameOrPath)
+	}
+	font, err := fontreg.Resolve(nameOrPath)
+	if err != nil { return nil, err }
+	return &Loader{ strand: strand.New(font), PollInterval: DefaultPollInterval }, nil
+}
+
+// Returns the strand for the most recently loaded font.
+func (self *Loader) Strand() *strand.Strand { return self.strand }
+
+// Returns the error for the last failed reload attempt, or nil if
+// the font file and the loaded font are in sync.
+func (self *Loader) Err() error { return self.err }
+
+// Same as [strand.StrandMapping.AutoInitRewriteRules](), but the
+// loader will also apply it again to any reloaded strands.
+func (self *Loader) AutoInitRewriteRules() error {
+	self.autoInitRewriteRules = true
+	return self.strand.Mapping().AutoInitRewriteRules()
+}
+
+// Must be called once per tick from [ebiten.Game].Update(). The file
+// is only checked once per poll interval, and re-parsed only if its
+// modification time or size change. If the font has been reloaded
+// successfully, the new strand is set on all the given renderers that
+// were using the previous one, and the method returns true.
+//
+// If the new font can't be read or parsed, the previous strand will
+// remain active, the error will be available through [Loader.Err]()
+// and the file will be read again on the next poll.
+func (self *Loader) Update(renderers ...*ptxt.Renderer) bool {
+	if self.path == "" { return false } // registered font
+	now := time.Now()
+	if now.Sub(self.lastPoll) < self.PollInterval { return false }
+	self.lastPoll = now
+
+	// check modification time and size
+	info, err := os.Stat(self.path)
+	if err != nil {
+		self.err = err
+		self.modTime = time.Time{} // force reading the file once it's back
+		return false
+	}
+	if info.ModTime().Equal(self.modTime) && info.Size() == self.size {
+		return false
+	}
+	// modification time and size are only stored once the file has
+	// been read and parsed, so failures (e.g. the file is locked or
+	// mid-write by an editor) are retried on the next poll
+
+	// compare hashes to skip files that have only been touched
+	data, err := os.ReadFile(self.path)
+	if err != nil {
+		self.err = err
+		return false
+	}
+	hash := hashBytes(data)
+	if hash == self.hash {
+		self.modTime, self.size = info.ModTime(), info.Size()
+		self.err = nil
+		return false
+	}
+
+	// parse and configure the new strand
+	font, err := ggfnt.Parse(bytes.NewReader(data))
+	if err != nil {
+		self.err = err
+		return false
+	}
+	newStrand := strand.New(font)
+	transferSettings(self.strand, newStrand)
+	if self.autoInitRewriteRules {
+		err = newStrand.Mapping().AutoInitRewriteRules()
+		if err != nil {
+			self.err = err
+			return false
+		}
+	}
+	newStrand.Mapping().SetRewriteRulesEnabled(self.strand.Mapping().GetRewriteRulesEnabled())
+
+	// swap strands
+	for _, renderer := range renderers {
+		if renderer.Strand() == self.strand {
+			renderer.SetStrand(newStrand)
+		}
+	}
+	self.strand = newStrand
+	self.hash = hash
+	self.modTime, self.size = info.ModTime(), info.Size()
+	self.err = nil
+	fmt.Printf("Font reloaded: %s\n", font.Header().Name())
+	return true
+}
+
+// Copies setting values between strands of different fonts, matching
+// settings by name. Settings that don't exist on the target strand or
+// options that have become invalid are ignored.
+func transferSettings(from, to *strand.Strand) {
+	targetKeys := make(map[string]ggfnt.SettingKey, to.Font().Settings().Count())
+	to.Font().Settings().Each(func(key ggfnt.SettingKey, name string) {
+		targetKeys[name] = key
+	})
+	from.Font().Settings().Each(func(key ggfnt.SettingKey, name string) {
+		targetKey, found := targetKeys[name]
+		if !found { return }
+		option := from.GetSetting(key)
+		if option >= to.Font().Settings().GetNumOptions(targetKey) { return }
+		if option != to.GetSetting(targetKey) {
+			to.SetSetting(targetKey, option)
+		}
+	})
+}
+
+func hashBytes(data []byte) uint64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write(data)
+	return hasher.Sum64()
+}
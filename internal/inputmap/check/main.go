@@ -0,0 +1,131 @@
+//go:build !cputext
+
+// Cross-checks the inputmap package against actual ptxt projections:
+// it projects canvases with ptxt and verifies that each high resolution
+// pixel maps back to the logical pixel that was actually drawn there.
+// This requires a GPU, so it's a program instead of a test. The rest
+// of the checks can be run with go test -tags cputext ./inputmap.
+//
+// Usage:
+// > go run ./inputmap/check
+package main
+
+import "os"
+import "fmt"
+import "image"
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt-examples/internal/inputmap"
+
+var Projectors = []ptxt.Projector{ ptxt.Proportional, ptxt.PixelPerfect, ptxt.Stretched }
+
+func main() {
+	failures := checkGPU()
+	if failures > 0 {
+		fmt.Printf("FAILED (%d failures)\n", failures)
+		os.Exit(1)
+	}
+	fmt.Print("OK\n")
+}
+
+// ---- gpu cross-check ----
+
+var GPUCheckLogicalSizes = []image.Point{ {16, 9}, {7, 5} }
+var GPUCheckHiResSizes = []image.Point{ {16, 9}, {32, 18}, {33, 19}, {40, 25}, {24, 14}, {50, 17}, {57, 61} }
+
+type gpuChecker struct {
+	failures int
+}
+
+func checkGPU() int {
+	ebiten.SetWindowTitle("inputmap gpu check")
+	ebiten.SetWindowSize(160, 90)
+	var checker gpuChecker
+	err := ebiten.RunGame(&checker)
+	if err != nil { panic(err) }
+	fmt.Printf("GPU checks: %d failed\n", checker.failures)
+	return checker.failures
+}
+
+func (*gpuChecker) Layout(int, int) (int, int) { return 160, 90 }
+func (*gpuChecker) Draw(*ebiten.Image) {}
+
+// Projects logical canvases where each pixel color encodes its own
+// coordinates, and checks the result against inputmap.Map(). With
+// bilinear filtering, only the center of each logical pixel rect is
+// checked, as other pixels are blended with their neighbors.
+func (self *gpuChecker) Update() error {
+	for _, logical := range GPUCheckLogicalSizes {
+		canvas := ebiten.NewImage(logical.X, logical.Y)
+		pixels := make([]byte, logical.X*logical.Y*4)
+		for y := 0; y < logical.Y; y++ {
+			for x := 0; x < logical.X; x++ {
+				offset := (y*logical.X + x)*4
+				pixels[offset + 0] = encodeCoord(x)
+				pixels[offset + 1] = encodeCoord(y)
+				pixels[offset + 2] = 0
+				pixels[offset + 3] = 255
+			}
+		}
+		canvas.WritePixels(pixels)
+
+		for _, hiRes := range GPUCheckHiResSizes {
+			for _, projector := range Projectors {
+				self.checkProjection(projector, canvas, logical, hiRes)
+			}
+		}
+	}
+	return ebiten.Termination
+}
+
+func (self *gpuChecker) checkProjection(projector ptxt.Projector, canvas *ebiten.Image, logical, hiRes image.Point) {
+	target := ebiten.NewImage(hiRes.X, hiRes.Y)
+	projector.Project(canvas, target)
+	pixels := make([]byte, hiRes.X*hiRes.Y*4)
+	target.ReadPixels(pixels)
+
+	nearest := isNearest(projector, logical, hiRes)
+	for y := 0; y < hiRes.Y; y++ {
+		for x := 0; x < hiRes.X; x++ {
+			offset := (y*hiRes.X + x)*4
+			lx, ly, inside := inputmap.Map(projector, x, y, hiRes.X, hiRes.Y, logical.X, logical.Y)
+			drawn := (pixels[offset + 3] != 0)
+			if inside != drawn {
+				self.failures += 1
+				fmt.Printf("%s %v -> %v: pixel (%d, %d) drawn = %t, but inside = %t\n", projector, hiRes, logical, x, y, drawn, inside)
+				continue
+			}
+			if !inside { continue }
+			if !nearest {
+				rect := inputmap.PixelRect(projector, lx, ly, logical.X, logical.Y, hiRes.X, hiRes.Y)
+				center := rect.Min.Add(rect.Max).Div(2)
+				if x != center.X || y != center.Y { continue }
+			}
+			gotX, gotY := decodeCoord(pixels[offset + 0]), decodeCoord(pixels[offset + 1])
+			if gotX != lx || gotY != ly {
+				self.failures += 1
+				fmt.Printf("%s %v -> %v: pixel (%d, %d) shows (%d, %d), but maps to (%d, %d)\n", projector, hiRes, logical, x, y, gotX, gotY, lx, ly)
+			}
+		}
+	}
+}
+
+// Replicates the cases where ptxt projects with nearest filtering.
+func isNearest(projector ptxt.Projector, logical, hiRes image.Point) bool {
+	if logical == hiRes { return true }
+	switch projector {
+	case ptxt.Proportional:
+		return hiRes.X*logical.Y == hiRes.Y*logical.X && hiRes.X % logical.X == 0
+	case ptxt.PixelPerfect:
+		return hiRes.X >= logical.X && hiRes.Y >= logical.Y
+	default:
+		return false
+	}
+}
+
+// Coordinates are spread over the color range so that small blending
+// errors still decode to the right coordinate.
+func encodeCoord(coord int) byte { return byte(8 + coord*15) }
+func decodeCoord(value byte) int { return (int(value) - 1)/15 }
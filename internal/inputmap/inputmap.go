@@ -0,0 +1,182 @@
+// Package inputmap maps coordinates between high resolution canvases
+// and logical canvases projected with ptxt.Projector.Project().
+//
+// ptxt already provides Projector.Remap(), but it clamps coordinates
+// without reporting whether they fell within the letterboxing borders,
+// its margins don't exactly match the ones used by Project() in some
+// cases (e.g., PixelPerfect with odd sizes), and Stretched computes y
+// from the x coordinate (see remap_test.go). This package computes the
+// projected area exactly as Project() does, using integer math, so
+// mapping a high resolution pixel back to the logical canvas always
+// returns the logical pixel that was drawn there.
+//
+// The package can be tested with:
+// > go test -tags cputext ./inputmap
+// And cross-checked against actual ptxt projections with:
+// > go run ./inputmap/check
+package inputmap
+
+import "image"
+
+// The projection modes of ptxt.Projector. ptxt only defines projectors
+// for Ebitengine builds, so the math in this file uses its own values,
+// which can also be used with -tags cputext (e.g., to emulate the
+// projections without a GPU). See projector.go for the ptxt.Projector
+// API.
+type Projection uint8
+const (
+	Proportional Projection = iota
+	PixelPerfect
+	Stretched
+)
+
+func (self Projection) String() string {
+	switch self {
+	case Proportional: return "Proportional"
+	case PixelPerfect: return "PixelPerfect"
+	case Stretched: return "Stretched"
+	default:
+		panic("invalid projection")
+	}
+}
+
+// Like [Map](), but for a [Projection].
+func (self Projection) Map(x, y int, hiResWidth, hiResHeight, logicalWidth, logicalHeight int) (int, int, bool) {
+	if logicalWidth <= 0 || logicalHeight <= 0 { panic("inputmap.Map(): empty logical canvas") }
+	if hiResWidth <= 0 || hiResHeight <= 0 { return 0, 0, false }
+	area := projectedArea(self, logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+	if area.empty() { return 0, 0, false }
+	lx := area.mapX(x, logicalWidth)
+	ly := area.mapY(y, logicalHeight)
+	inside := (lx >= 0 && lx < logicalWidth && ly >= 0 && ly < logicalHeight)
+	lx = max(min(lx, logicalWidth  - 1), 0)
+	ly = max(min(ly, logicalHeight - 1), 0)
+	return lx, ly, inside
+}
+
+// Like [Projection.Map](), but without rounding nor clamping: returns
+// the position of the given high resolution point on the logical
+// canvas, in fractional logical pixels. Map() uses the pixel centers,
+// so Map(x, y) is the floor of MapF(x + 0.5, y + 0.5). Useful to
+// emulate filtering when projecting without a GPU.
+func (self Projection) MapF(x, y float64, hiResWidth, hiResHeight, logicalWidth, logicalHeight int) (float64, float64, bool) {
+	if logicalWidth <= 0 || logicalHeight <= 0 { panic("inputmap.MapF(): empty logical canvas") }
+	if hiResWidth <= 0 || hiResHeight <= 0 { return 0, 0, false }
+	area := projectedArea(self, logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+	if area.empty() { return 0, 0, false }
+	denom := float64(area.denom)
+	lx := (x*denom - float64(area.x0))*float64(logicalWidth)/float64(area.x1 - area.x0)
+	ly := (y*denom - float64(area.y0))*float64(logicalHeight)/float64(area.y1 - area.y0)
+	inside := (lx >= 0 && lx < float64(logicalWidth) && ly >= 0 && ly < float64(logicalHeight))
+	return lx, ly, inside
+}
+
+// Like [PixelRect](), but for a [Projection].
+func (self Projection) PixelRect(logicalX, logicalY int, logicalWidth, logicalHeight, hiResWidth, hiResHeight int) image.Rectangle {
+	if logicalWidth <= 0 || logicalHeight <= 0 { panic("inputmap.PixelRect(): empty logical canvas") }
+	if hiResWidth <= 0 || hiResHeight <= 0 { return image.Rectangle{} }
+	area := projectedArea(self, logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+	if area.empty() { return image.Rectangle{} }
+	minX, maxX := area.unmapX(logicalX, logicalWidth), area.unmapX(logicalX + 1, logicalWidth)
+	minY, maxY := area.unmapY(logicalY, logicalHeight), area.unmapY(logicalY + 1, logicalHeight)
+	if minX >= maxX || minY >= maxY { return image.Rectangle{} }
+	return image.Rect(minX, minY, maxX, maxY)
+}
+
+// Like [ProjectedRect](), but for a [Projection].
+func (self Projection) ProjectedRect(logicalWidth, logicalHeight, hiResWidth, hiResHeight int) image.Rectangle {
+	if logicalWidth <= 0 || logicalHeight <= 0 { panic("inputmap.ProjectedRect(): empty logical canvas") }
+	if hiResWidth <= 0 || hiResHeight <= 0 { return image.Rectangle{} }
+	area := projectedArea(self, logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+	if area.empty() { return image.Rectangle{} }
+	minX, minY := int(area.x0/area.denom), int(area.y0/area.denom)
+	maxX, maxY := int(ceilDiv(area.x1, area.denom)), int(ceilDiv(area.y1, area.denom))
+	return image.Rect(minX, minY, maxX, maxY)
+}
+
+// ---- internal ----
+
+// The exact area where the logical canvas is projected. Coordinates
+// are given in 1/denom units of high resolution pixels, which allows
+// us to represent the fractional margins of proportional projections
+// without any rounding errors.
+type area struct {
+	x0, y0, x1, y1 int64
+	denom int64
+}
+
+// Mirrors ptxt's Projector.Project() implementation.
+func projectedArea(mode Projection, logicalWidth, logicalHeight, hiResWidth, hiResHeight int) area {
+	lw, lh := int64(logicalWidth), int64(logicalHeight)
+	hw, hh := int64(hiResWidth), int64(hiResHeight)
+	denom := 2*lw*lh
+	full := area{ 0, 0, hw*denom, hh*denom, denom }
+	if lw == hw && lh == hh { return full }
+
+	switch mode {
+	case Proportional:
+		// margins: (hw - hh*lw/lh)/2 or (hh - hw*lh/lw)/2
+		switch {
+		case hw*lh == hh*lw: // same aspect ratio
+			return full
+		case hw*lh < hh*lw: // excess height
+			margin := (hh*lw - hw*lh)*lh // = (hh - hw*lh/lw)/2*denom
+			return area{ 0, margin, hw*denom, hh*denom - margin, denom }
+		default: // excess width
+			margin := (hw*lh - hh*lw)*lw // = (hw - hh*lw/lh)/2*denom
+			return area{ margin, 0, hw*denom - margin, hh*denom, denom }
+		}
+	case PixelPerfect:
+		var outWidth, outHeight int64
+		if hw < lw || hh < lh { // minification, same as ptxt's float math
+			zoom := min(float64(hw)/float64(lw), float64(hh)/float64(lh))
+			outWidth  = int64(float64(lw)*zoom)
+			outHeight = int64(float64(lh)*zoom)
+		} else {
+			zoom := min(hw/lw, hh/lh)
+			outWidth, outHeight = lw*zoom, lh*zoom
+		}
+		tx, ty := (hw - outWidth) >> 1, (hh - outHeight) >> 1
+		return area{ tx*denom, ty*denom, (tx + outWidth)*denom, (ty + outHeight)*denom, denom }
+	case Stretched:
+		return full
+	default:
+		panic("invalid " + mode.String())
+	}
+}
+
+// Extreme minification can result in empty projections.
+func (self *area) empty() bool {
+	return self.x1 <= self.x0 || self.y1 <= self.y0
+}
+
+// Pixels are mapped through their centers, like when rendering.
+func (self *area) mapX(x int, logicalWidth int) int {
+	return int(floorDiv((int64(x)*self.denom + self.denom/2 - self.x0)*int64(logicalWidth), self.x1 - self.x0))
+}
+
+func (self *area) mapY(y int, logicalHeight int) int {
+	return int(floorDiv((int64(y)*self.denom + self.denom/2 - self.y0)*int64(logicalHeight), self.y1 - self.y0))
+}
+
+// Returns the first high resolution x that maps to the given logical x
+// or any bigger value. This is the inverse of mapX().
+func (self *area) unmapX(logicalX int, logicalWidth int) int {
+	numerator := int64(logicalX)*(self.x1 - self.x0) - (self.denom/2 - self.x0)*int64(logicalWidth)
+	return int(ceilDiv(numerator, self.denom*int64(logicalWidth)))
+}
+
+func (self *area) unmapY(logicalY int, logicalHeight int) int {
+	numerator := int64(logicalY)*(self.y1 - self.y0) - (self.denom/2 - self.y0)*int64(logicalHeight)
+	return int(ceilDiv(numerator, self.denom*int64(logicalHeight)))
+}
+
+func floorDiv(a, b int64) int64 {
+	quotient := a/b
+	if (a % b != 0) && ((a < 0) != (b < 0)) { quotient -= 1 }
+	return quotient
+}
+
+func ceilDiv(a, b int64) int64 {
+	return -floorDiv(-a, b)
+}
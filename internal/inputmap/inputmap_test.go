@@ -0,0 +1,218 @@
+//go:build cputext
+
+package inputmap
+
+import "math"
+import "image"
+import "testing"
+import "math/rand"
+
+var projections = []Projection{ Proportional, PixelPerfect, Stretched }
+
+var logicalSizes = []image.Point{
+	{160, 90}, {80, 45}, {7, 5}, {3, 7}, {1, 1},
+}
+var hiResSizes = []image.Point{
+	{160, 90}, {320, 180}, {321, 181}, {333, 187}, {240, 135}, {500, 200},
+	{200, 500}, {79, 44}, {50, 90}, {1, 1}, {1000, 563}, {17, 3},
+}
+
+type mapCase struct {
+	mode Projection
+	hiResWidth, hiResHeight int
+	logicalWidth, logicalHeight int
+	x, y int // high resolution coordinates
+	wantX, wantY int
+	wantInside bool
+}
+
+var mapCases = []mapCase{
+	// proportional, exact 2x
+	{ Proportional, 320, 180, 160, 90,    0,   0,   0,  0, true  },
+	{ Proportional, 320, 180, 160, 90,    2,   3,   1,  1, true  },
+	{ Proportional, 320, 180, 160, 90,  319, 179, 159, 89, true  },
+	{ Proportional, 320, 180, 160, 90,   -1,   5,   0,  2, false },
+	{ Proportional, 320, 180, 160, 90,  320,   0, 159,  0, false },
+	{ Proportional, 320, 180, 160, 90,   40, 999,  20, 89, false },
+
+	// proportional, 1.5x (all logical pixels are either 1 or 2 pixels wide)
+	{ Proportional, 240, 135, 160, 90,    0,   0,   0,  0, true  },
+	{ Proportional, 240, 135, 160, 90,    1,   1,   1,  1, true  },
+	{ Proportional, 240, 135, 160, 90,    2,   2,   1,  1, true  },
+	{ Proportional, 240, 135, 160, 90,  239, 134, 159, 89, true  },
+
+	// proportional with 10px vertical letterboxing
+	{ Proportional, 320, 200, 160, 90,    5,   5,   2,  0, false },
+	{ Proportional, 320, 200, 160, 90,    5,   9,   2,  0, false },
+	{ Proportional, 320, 200, 160, 90,    5,  10,   2,  0, true  },
+	{ Proportional, 320, 200, 160, 90,    5, 189,   2, 89, true  },
+	{ Proportional, 320, 200, 160, 90,    5, 190,   2, 89, false },
+
+	// proportional with fractional horizontal letterboxing (2.5x, 12.5px margins)
+	{ Proportional, 425, 225, 160, 90,   11, 100,   0, 40, false },
+	{ Proportional, 425, 225, 160, 90,   12, 100,   0, 40, true  }, // (center on the edge)
+	{ Proportional, 425, 225, 160, 90,  411, 100, 159, 40, true  },
+	{ Proportional, 425, 225, 160, 90,  412, 100, 159, 40, false },
+
+	// pixel perfect, 2x with 15px and 10px margins
+	{ PixelPerfect, 350, 200, 160, 90,   14,  50,   0, 20, false },
+	{ PixelPerfect, 350, 200, 160, 90,   15,  10,   0,  0, true  },
+	{ PixelPerfect, 350, 200, 160, 90,  334, 189, 159, 89, true  },
+	{ PixelPerfect, 350, 200, 160, 90,  335, 100, 159, 45, false },
+
+	// pixel perfect, odd margins (Project() uses (331 - 320) >> 1 = 5)
+	{ PixelPerfect, 331, 181, 160, 90,    4,   0,   0,  0, false },
+	{ PixelPerfect, 331, 181, 160, 90,    5,   0,   0,  0, true  },
+	{ PixelPerfect, 331, 181, 160, 90,  324, 179, 159, 89, true  },
+	{ PixelPerfect, 331, 181, 160, 90,  325, 179, 159, 89, false },
+	{ PixelPerfect, 331, 181, 160, 90,  100, 180,  47, 89, false },
+
+	// stretched (ptxt's Remap() uses x instead of y here, see remap_test.go)
+	{ Stretched,    200, 100, 100, 50,  199,  99,  99, 49, true  },
+	{ Stretched,    200, 100, 100, 50,    0,  99,   0, 49, true  },
+	{ Stretched,    300,  50, 100, 50,  150,  25,  50, 25, true  },
+	{ Stretched,    300,  50, 100, 50,  300,  25,  99, 25, false },
+}
+
+func TestMapCases(t *testing.T) {
+	for _, c := range mapCases {
+		x, y, inside := c.mode.Map(c.x, c.y, c.hiResWidth, c.hiResHeight, c.logicalWidth, c.logicalHeight)
+		if x != c.wantX || y != c.wantY || inside != c.wantInside {
+			t.Errorf(
+				"%s %dx%d -> %dx%d: Map(%d, %d) = (%d, %d, %t), want (%d, %d, %t)",
+				c.mode, c.hiResWidth, c.hiResHeight, c.logicalWidth, c.logicalHeight,
+				c.x, c.y, x, y, inside, c.wantX, c.wantY, c.wantInside,
+			)
+		}
+	}
+}
+
+// Checks on many canvas sizes that:
+//  - Every high resolution pixel within the rect of a logical pixel maps
+//    back to that same logical pixel.
+//  - Logical pixel rects don't overlap, and together they cover exactly
+//    the pixels reported as inside.
+//  - Pixels outside the projected rect (letterboxing) and coordinates
+//    outside the high resolution canvas are reported as outside.
+//  - With magnification, every logical pixel has a non-empty rect.
+//  - Mapped coordinates never decrease when moving right or down.
+//  - MapF() on pixel centers agrees with Map().
+func TestProperties(t *testing.T) {
+	for _, mode := range projections {
+		for _, logical := range logicalSizes {
+			for _, hiRes := range hiResSizes {
+				checkProperties(t, mode, logical, hiRes)
+			}
+		}
+	}
+}
+
+func checkProperties(t *testing.T, mode Projection, logical, hiRes image.Point) {
+	var failures int
+	fail := func(format string, args ...any) {
+		failures += 1
+		if failures > 4 { return } // don't flood the output
+		args = append([]any{ mode, hiRes.X, hiRes.Y, logical.X, logical.Y }, args...)
+		t.Errorf("%s %dx%d -> %dx%d: " + format, args...)
+	}
+
+	// logical pixel rects map back to the logical pixel
+	magnified := (hiRes.X >= logical.X && hiRes.Y >= logical.Y)
+	var coveredArea int
+	for ly := 0; ly < logical.Y; ly++ {
+		for lx := 0; lx < logical.X; lx++ {
+			rect := mode.PixelRect(lx, ly, logical.X, logical.Y, hiRes.X, hiRes.Y)
+			if rect.Empty() {
+				if magnified { fail("PixelRect(%d, %d) is empty", lx, ly) }
+				continue
+			}
+			coveredArea += rect.Dx()*rect.Dy()
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					mx, my, inside := mode.Map(x, y, hiRes.X, hiRes.Y, logical.X, logical.Y)
+					if mx != lx || my != ly || !inside {
+						fail("pixel (%d, %d) in PixelRect(%d, %d) maps to (%d, %d, %t)", x, y, lx, ly, mx, my, inside)
+					}
+				}
+			}
+		}
+	}
+
+	// inside pixels, letterboxing and monotonicity
+	var insideArea int
+	projected := mode.ProjectedRect(logical.X, logical.Y, hiRes.X, hiRes.Y)
+	for y := 0; y < hiRes.Y; y++ {
+		prevX := -1
+		for x := 0; x < hiRes.X; x++ {
+			mx, my, inside := mode.Map(x, y, hiRes.X, hiRes.Y, logical.X, logical.Y)
+			fx, fy, fInside := mode.MapF(float64(x) + 0.5, float64(y) + 0.5, hiRes.X, hiRes.Y, logical.X, logical.Y)
+			if fInside != inside || (inside && (int(math.Floor(fx)) != mx || int(math.Floor(fy)) != my)) {
+				fail("MapF(%d.5, %d.5) = (%.3f, %.3f, %t), but Map() = (%d, %d, %t)", x, y, fx, fy, fInside, mx, my, inside)
+			}
+			if mx < prevX { fail("Map(%d, %d) x decreased from %d to %d", x, y, prevX, mx) }
+			prevX = mx
+			if y > 0 {
+				_, upY, _ := mode.Map(x, y - 1, hiRes.X, hiRes.Y, logical.X, logical.Y)
+				if my < upY { fail("Map(%d, %d) y decreased from %d to %d", x, y, upY, my) }
+			}
+			if !inside { continue }
+			insideArea += 1
+			if !(image.Point{x, y}).In(projected) {
+				fail("pixel (%d, %d) outside ProjectedRect %v reported as inside", x, y, projected)
+			}
+		}
+	}
+	if insideArea != coveredArea {
+		fail("%d inside pixels, but logical pixel rects cover %d", insideArea, coveredArea)
+	}
+
+	// out of range coordinates
+	for _, point := range []image.Point{
+		{-1, 0}, {0, -1}, {-100, -100}, {hiRes.X, 0}, {0, hiRes.Y},
+		{hiRes.X + 50, hiRes.Y/2}, {hiRes.X/2, -7},
+	} {
+		mx, my, inside := mode.Map(point.X, point.Y, hiRes.X, hiRes.Y, logical.X, logical.Y)
+		if inside { fail("out of range (%d, %d) reported as inside", point.X, point.Y) }
+		if mx < 0 || mx >= logical.X || my < 0 || my >= logical.Y {
+			fail("out of range (%d, %d) mapped to (%d, %d), not clamped", point.X, point.Y, mx, my)
+		}
+	}
+}
+
+// Randomized round trip checks on arbitrary sizes: any point reported
+// as inside must be contained in the rect of the logical pixel it maps
+// to, and the rect of a random logical pixel must map back to it.
+func TestRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(0x1DEA))
+	for i := 0; i < 2000; i++ {
+		mode := projections[rng.Intn(len(projections))]
+		logicalWidth, logicalHeight := 1 + rng.Intn(400), 1 + rng.Intn(300)
+		hiResWidth, hiResHeight := 1 + rng.Intn(4000), 1 + rng.Intn(3000)
+		x, y := rng.Intn(hiResWidth + 20) - 10, rng.Intn(hiResHeight + 20) - 10
+		lx, ly, inside := mode.Map(x, y, hiResWidth, hiResHeight, logicalWidth, logicalHeight)
+		if inside {
+			rect := mode.PixelRect(lx, ly, logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+			if !(image.Point{x, y}).In(rect) {
+				t.Errorf(
+					"%s %dx%d -> %dx%d: (%d, %d) maps to (%d, %d), but PixelRect is %v",
+					mode, hiResWidth, hiResHeight, logicalWidth, logicalHeight, x, y, lx, ly, rect,
+				)
+			}
+		}
+
+		lx, ly = rng.Intn(logicalWidth), rng.Intn(logicalHeight)
+		rect := mode.PixelRect(lx, ly, logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+		if rect.Empty() { continue }
+		corners := []image.Point{ rect.Min, rect.Max.Sub(image.Pt(1, 1)) }
+		for _, corner := range corners {
+			mx, my, inside := mode.Map(corner.X, corner.Y, hiResWidth, hiResHeight, logicalWidth, logicalHeight)
+			if mx != lx || my != ly || !inside {
+				t.Errorf(
+					"%s %dx%d -> %dx%d: PixelRect(%d, %d) = %v, but (%d, %d) maps to (%d, %d, %t)",
+					mode, hiResWidth, hiResHeight, logicalWidth, logicalHeight,
+					lx, ly, rect, corner.X, corner.Y, mx, my, inside,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !cputext
+
+package inputmap
+
+import "image"
+
+import "github.com/tinne26/ptxt"
+
+// Maps the given high resolution coordinates (e.g., the cursor
+// position) to the logical canvas. The returned coordinates are
+// always clamped to the logical canvas, but the returned bool will
+// be false if the high resolution point was outside the projected
+// area (e.g., clicking on letterboxing borders).
+//
+// The arguments follow the same order as ptxt.Projector.Remap().
+func Map(projector ptxt.Projector, x, y int, hiResWidth, hiResHeight, logicalWidth, logicalHeight int) (int, int, bool) {
+	return projectionOf(projector).Map(x, y, hiResWidth, hiResHeight, logicalWidth, logicalHeight)
+}
+
+// Returns the rectangle of high resolution pixels that map to the
+// given logical pixel. When the projection requires minification,
+// some logical pixels will not be visible and their rects will be
+// empty.
+func PixelRect(projector ptxt.Projector, logicalX, logicalY int, logicalWidth, logicalHeight, hiResWidth, hiResHeight int) image.Rectangle {
+	return projectionOf(projector).PixelRect(logicalX, logicalY, logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+}
+
+// Returns the smallest rectangle of high resolution pixels containing
+// the projected logical canvas. This matches the bounds of the image
+// returned by ptxt.Projector.Project().
+func ProjectedRect(projector ptxt.Projector, logicalWidth, logicalHeight, hiResWidth, hiResHeight int) image.Rectangle {
+	return projectionOf(projector).ProjectedRect(logicalWidth, logicalHeight, hiResWidth, hiResHeight)
+}
+
+// Returns the ptxt.Projector for the projection.
+func (self Projection) Projector() ptxt.Projector {
+	switch self {
+	case Proportional: return ptxt.Proportional
+	case PixelPerfect: return ptxt.PixelPerfect
+	case Stretched: return ptxt.Stretched
+	default:
+		panic("invalid projection")
+	}
+}
+
+func projectionOf(projector ptxt.Projector) Projection {
+	switch projector {
+	case ptxt.Proportional: return Proportional
+	case ptxt.PixelPerfect: return PixelPerfect
+	case ptxt.Stretched: return Stretched
+	default:
+		panic("invalid " + projector.String())
+	}
+}
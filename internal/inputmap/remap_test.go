@@ -0,0 +1,32 @@
+//go:build !cputext
+
+package inputmap
+
+import "testing"
+
+import "github.com/tinne26/ptxt"
+
+// ptxt's Projector.Remap() computes both coordinates from x when using
+// the Stretched projector. This test pins that behavior: if it starts
+// failing, the bug has been fixed upstream and the package docs should
+// be updated.
+func TestRemapStretchedUsesX(t *testing.T) {
+	const HiResWidth, HiResHeight, LogicalWidth, LogicalHeight = 200, 100, 100, 50
+	for _, point := range [][2]int{ {0, 99}, {199, 0}, {100, 20} } {
+		x, y := point[0], point[1]
+		remapX, remapY := ptxt.Stretched.Remap(x, y, HiResWidth, HiResHeight, LogicalWidth, LogicalHeight)
+		wantX, wantY := (x*LogicalWidth)/HiResWidth, (x*LogicalHeight)/HiResHeight // (sic)
+		if remapX != wantX || remapY != wantY {
+			t.Fatalf(
+				"Stretched.Remap(%d, %d) = (%d, %d), used to be (%d, %d); ptxt bug fixed?",
+				x, y, remapX, remapY, wantX, wantY,
+			)
+		}
+
+		mapX, mapY, inside := Map(ptxt.Stretched, x, y, HiResWidth, HiResHeight, LogicalWidth, LogicalHeight)
+		wantX, wantY = (x*LogicalWidth)/HiResWidth, (y*LogicalHeight)/HiResHeight
+		if mapX != wantX || mapY != wantY || !inside {
+			t.Fatalf("Map(Stretched, %d, %d) = (%d, %d, %t), want (%d, %d, true)", x, y, mapX, mapY, inside, wantX, wantY)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build cputext
+
+package labelcache
+
+import "image"
+import "image/draw"
+
+type labelImage = *image.RGBA
+
+func newImage(width, height int) labelImage {
+	return image.NewRGBA(image.Rect(0, 0, width, height))
+}
+
+func drawImage(target draw.Image, img labelImage, x, y int) {
+	bounds := img.Bounds().Add(image.Pt(x, y))
+	draw.Draw(target, bounds, img, image.Point{}, draw.Over)
+}
+
+func disposeImage(labelImage) {}
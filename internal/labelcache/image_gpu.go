@@ -0,0 +1,21 @@
+//go:build !cputext
+
+package labelcache
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+type labelImage = *ebiten.Image
+
+func newImage(width, height int) labelImage {
+	return ebiten.NewImage(width, height)
+}
+
+func drawImage(target *ebiten.Image, img labelImage, x, y int) {
+	var opts ebiten.DrawImageOptions
+	opts.GeoM.Translate(float64(x), float64(y))
+	target.DrawImage(img, &opts)
+}
+
+func disposeImage(img labelImage) {
+	if img != nil { img.Dispose() }
+}
@@ -0,0 +1,231 @@
+// Package labelcache caches rendered text labels in offscreen images,
+// so static text (instructions, info panels, etc.) can be drawn with
+// a single image blit instead of laying out and drawing each glyph on
+// every frame.
+//
+// Labels are keyed by their text and all the relevant renderer state
+// (strand and its settings, color, scale, align, bounding mode...), so
+// changing any of them simply renders a new label. Old labels are
+// evicted in least recently used order once the total pixel count
+// exceeds the cache limit.
+//
+// Since renderers don't expose their color, the cache sets it on each
+// [Cache.Draw]() call instead. Only horizontal text is cached: other
+// directions and multi-line text using LastBaseline with mask bounding
+// are drawn directly with the renderer. The renderer's blend mode is
+// not applied when blitting, labels are always composited over the
+// target. Strand changes that don't affect its settings or interspacing
+// (like shadows or glyph pickers) are not detected, so call
+// [Cache.Clear]() after making them.
+//
+// Usage:
+//	labels := labelcache.New(256*1024)
+//	...
+//	// on Draw()
+//	renderer.SetAlign(ptxt.Top | ptxt.Left)
+//	labels.Draw(canvas, renderer, "PRESS [SPACE] TO START", rgba, 6, 6)
+//
+// The package works both with Ebitengine and with the cpu path
+// (-tags cputext). The tests compare cached and direct draws on the cpu
+// path: go test -tags cputext ./labelcache
+package labelcache
+
+import "strings"
+import "image/color"
+import "container/list"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/core"
+import "github.com/tinne26/ptxt/strand"
+
+// A cache of rendered labels, bounded by their total pixel count.
+type Cache struct {
+	maxPixels int
+	numPixels int
+	order *list.List // front is the most recently used
+	entries map[labelKey]*list.Element
+}
+
+// Everything that can change how a label is drawn.
+type labelKey struct {
+	text string
+	strand *strand.Strand
+	settings string // snapshot of the strand settings
+	horzShift, vertShift int8 // strand interspacing shifts
+	color color.RGBA
+	scale uint8
+	horzAlign ptxt.Align
+	boundingMode ptxt.BoundingMode
+	parBreak bool
+}
+
+type label struct {
+	key labelKey
+	image labelImage // nil for labels without visible area
+	pixels int
+	offsetX, offsetY int // from the align anchor to the image origin, for Baseline aligns
+	top, height int // text bounds relative to the baseline, for vertical aligns
+}
+
+// Creates a cache that can hold labels up to the given total number
+// of pixels.
+func New(maxPixels int) *Cache {
+	return &Cache{
+		maxPixels: maxPixels,
+		order: list.New(),
+		entries: make(map[labelKey]*list.Element),
+	}
+}
+
+// Sets the renderer color and draws the text like renderer.Draw(),
+// rendering the label only if it's not cached already.
+func (self *Cache) Draw(target core.Target, renderer *ptxt.Renderer, text string, rgba color.RGBA, x, y int) {
+	renderer.SetColor(rgba)
+	if !isCacheable(renderer, text) {
+		renderer.Draw(target, text, x, y)
+		return
+	}
+
+	lbl := self.get(renderer, text, rgba)
+	if lbl == nil { // too big to be cached
+		renderer.Draw(target, text, x, y)
+		return
+	}
+	if lbl.image == nil { return }
+	baseline := y + vertShift(renderer, lbl, text)
+	drawImage(target, lbl.image, x + lbl.offsetX, baseline + lbl.offsetY)
+}
+
+// Removes all the labels from the cache.
+func (self *Cache) Clear() {
+	for element := self.order.Front(); element != nil; element = element.Next() {
+		disposeImage(element.Value.(*label).image)
+	}
+	self.order.Init()
+	clear(self.entries)
+	self.numPixels = 0
+}
+
+// Returns the number of cached labels and their total pixel count.
+func (self *Cache) Stats() (labels, pixels int) {
+	return len(self.entries), self.numPixels
+}
+
+// Returns the cached label or renders it. Returns nil if the label
+// exceeds the cache size.
+func (self *Cache) get(renderer *ptxt.Renderer, text string, rgba color.RGBA) *label {
+	key := newLabelKey(renderer, text, rgba)
+	element, found := self.entries[key]
+	if found {
+		self.order.MoveToFront(element)
+		return element.Value.(*label)
+	}
+
+	lbl := renderLabel(renderer, text, key)
+	if lbl.pixels > self.maxPixels {
+		disposeImage(lbl.image)
+		return nil
+	}
+	self.entries[key] = self.order.PushFront(lbl)
+	self.numPixels += lbl.pixels
+	for self.numPixels > self.maxPixels {
+		oldest := self.order.Back()
+		evicted := oldest.Value.(*label)
+		self.order.Remove(oldest)
+		delete(self.entries, evicted.key)
+		self.numPixels -= evicted.pixels
+		disposeImage(evicted.image)
+	}
+	return lbl
+}
+
+func isCacheable(renderer *ptxt.Renderer, text string) bool {
+	if renderer.GetDirection() != ptxt.Horizontal { return false }
+	if renderer.GetAlign().Vert() != ptxt.LastBaseline || !isMultiline(text) { return true }
+
+	// the last line descent depends on the glyph masks when
+	// using mask bounding, and ptxt doesn't expose it
+	mode := renderer.Advanced().GetBoundingMode()
+	return mode != ptxt.MaskBounding && mode != ptxt.NoDescMaskBounding
+}
+
+func newLabelKey(renderer *ptxt.Renderer, text string, rgba color.RGBA) labelKey {
+	fontStrand := renderer.Strand()
+	if fontStrand.IsMainDyeActive() { rgba = fontStrand.GetMainDye() }
+	return labelKey{
+		text: text,
+		strand: fontStrand,
+		settings: string(fontStrand.UnderlyingSettingsCache().UnsafeSlice()),
+		horzShift: fontStrand.HorzInterspacingShift(),
+		vertShift: fontStrand.VertInterspacingShift(),
+		color: rgba,
+		scale: renderer.GetScale(),
+		horzAlign: renderer.GetAlign().Horz(),
+		boundingMode: renderer.Advanced().GetBoundingMode(),
+		parBreak: renderer.Advanced().GetParBreakEnabled(),
+	}
+}
+
+// Renders the label with a Baseline align, so the same image can be
+// reused for any vertical align.
+func renderLabel(renderer *ptxt.Renderer, text string, key labelKey) *label {
+	width, height := renderer.Measure(text)
+	left, top := renderer.Advanced().LastBoundsOffset()
+	lbl := &label{ key: key, top: top, height: height }
+	if width <= 0 || height <= 0 { return lbl }
+
+	// horizontal anchor within the image, so lines are aligned
+	// the same way they would be when drawn directly
+	var anchorX int
+	switch key.horzAlign {
+	case ptxt.HorzCenter : anchorX = width >> 1
+	case ptxt.Right      : anchorX = width
+	}
+	anchorX -= left
+	lbl.offsetX, lbl.offsetY = -anchorX, top
+	lbl.pixels = width*height
+
+	align := renderer.GetAlign()
+	renderer.SetAlign(ptxt.Baseline | key.horzAlign)
+	lbl.image = newImage(width, height)
+	renderer.Draw(lbl.image, text, anchorX, -top)
+	renderer.SetAlign(align)
+	return lbl
+}
+
+// Returns the distance from the given y coordinate to the first
+// baseline, like ptxt does for each vertical align.
+func vertShift(renderer *ptxt.Renderer, lbl *label, text string) int {
+	metrics := renderer.Strand().Font().Metrics()
+	scale := int(renderer.GetScale())
+	bottom := lbl.top + lbl.height
+	switch renderer.GetAlign().Vert() {
+	case ptxt.Top:
+		return -lbl.top
+	case ptxt.CapLine:
+		if metrics.UppercaseAscent() == 0 { return -lbl.top }
+		return int(metrics.UppercaseAscent())*scale
+	case ptxt.Midline:
+		if metrics.MidlineAscent() == 0 { return -lbl.top >> 1 }
+		return int(metrics.MidlineAscent())*scale
+	case ptxt.VertCenter:
+		return -lbl.top - (lbl.height >> 1)
+	case ptxt.Baseline:
+		return 0
+	case ptxt.Bottom:
+		return -bottom
+	case ptxt.LastBaseline:
+		if !isMultiline(text) { return 0 }
+		descent := int(metrics.Descent())*scale
+		if renderer.Advanced().GetBoundingMode() == ptxt.NoDescLogicalBounding { descent = 0 }
+		return -(bottom - descent)
+	default:
+		panic("unexpected align")
+	}
+}
+
+// Matches ptxt's criteria for LastBaseline aligns: a single line
+// break doesn't make the text multi-line.
+func isMultiline(text string) bool {
+	return text != "\n" && strings.Contains(text, "\n")
+}
@@ -0,0 +1,224 @@
+//go:build cputext
+
+package labelcache
+
+import "fmt"
+import "image"
+import "strings"
+import "testing"
+import "image/draw"
+import "image/color"
+
+import "github.com/tinne26/ggfnt-fonts/jammy"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ptxt-examples/internal/fontreg"
+
+// Run with:
+// > go test -tags cputext ./labelcache
+
+const CanvasWidth, CanvasHeight = 320, 180
+
+var VertAligns = []ptxt.Align{
+	ptxt.Bottom, ptxt.LastBaseline, ptxt.Baseline,
+	ptxt.VertCenter, ptxt.Midline, ptxt.CapLine, ptxt.Top,
+}
+var HorzAligns = []ptxt.Align{ ptxt.Right, ptxt.HorzCenter, ptxt.Left }
+var BoundingModes = []ptxt.BoundingMode{ ptxt.LogicalBounding, ptxt.MaskBounding }
+var Scales = []uint8{ 1, 2, 3 }
+var Colors = []color.RGBA{
+	{255, 214, 175, 255},
+	{ 96,  40, 120, 160}, // premultiplied, translucent
+}
+var Texts = []string{
+	"CLICK AROUND",
+	"USE ARROWS TO CHANGE ALIGNS\n[D] CHANGE TEXT DIRECTION\n[T] CHANGE TEXT",
+	"Spare the judgement\ntake the weight away",
+	"\nLEADING LINE BREAK",
+	"TRAILING\n",
+}
+
+var Background = color.RGBA{131, 151, 136, 255}
+
+// Compares direct and cached draws for all aligns, several scales,
+// colors and fonts.
+func TestAligns(t *testing.T) {
+	for _, fontName := range []string{"jammy", "jumpy"} {
+		t.Run(fontName, func(t *testing.T) {
+			font, err := fontreg.Resolve(fontName)
+			if err != nil { t.Fatal(err) }
+			renderer := ptxt.NewRenderer()
+			renderer.SetStrand(strand.New(font))
+			labels := New(CanvasWidth*CanvasHeight*16)
+
+			var numConfigs int
+			for _, text := range Texts {
+				if !renderer.Advanced().AllGlyphsAvailable(strings.ReplaceAll(text, "\n", "")) { continue }
+				for _, mode := range BoundingModes {
+					renderer.Advanced().SetBoundingMode(mode)
+					for _, scale := range Scales {
+						renderer.SetScale(scale)
+						for _, rgba := range Colors {
+							for _, vertAlign := range VertAligns {
+								for _, horzAlign := range HorzAligns {
+									renderer.SetAlign(vertAlign | horzAlign)
+									numConfigs += 1
+									for pass := 0; pass < 2; pass++ { // second pass is cached
+										diff := compare(labels, renderer, text, rgba)
+										if diff == "" { continue }
+										t.Errorf(
+											"%s %s x%d %v pass %d %q: %s", mode,
+											renderer.GetAlign(), scale, rgba, pass, text, diff,
+										)
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+			if numConfigs == 0 { t.Fatal("no texts available for the font") }
+		})
+	}
+}
+
+// Changes the label properties one at a time and verifies that
+// each change renders a new label, and that redraws don't.
+func TestInvalidation(t *testing.T) {
+	fontStrand := strand.New(jammy.Font())
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(fontStrand)
+	renderer.SetScale(2)
+	renderer.SetAlign(ptxt.Top | ptxt.Left)
+	labels := New(CanvasWidth*CanvasHeight*16)
+
+	const Text = "SETTING 0 AND 1234"
+	rgba := Colors[0]
+	changes := []struct{ name string; apply func() }{
+		{ "initial draw", func() {} },
+		{ "color", func() { rgba = color.RGBA{40, 80, 160, 255} } },
+		{ "scale", func() { renderer.SetScale(3) } },
+		{ "setting", func() {
+			key := jammy.ZeroDisambiguationMarkSettingKey
+			numOpts := fontStrand.Font().Settings().GetNumOptions(key)
+			fontStrand.SetSetting(key, (fontStrand.GetSetting(key) + 1) % numOpts)
+		}},
+		{ "interspacing", func() { fontStrand.SetHorzInterspacingShift(1) } },
+		{ "horz align", func() { renderer.SetAlign(ptxt.Right) } },
+		{ "bounding mode", func() { renderer.Advanced().SetBoundingMode(ptxt.MaskBounding) } },
+		{ "main dye", func() { fontStrand.SetMainDye(color.RGBA{200, 20, 20, 255}) } },
+	}
+	for i, change := range changes {
+		change.apply()
+		diff := compare(labels, renderer, Text, rgba)
+		if diff != "" { t.Errorf("%s: %s", change.name, diff) }
+		numLabels, _ := labels.Stats()
+		if numLabels != i + 1 { t.Errorf("%s: got %d labels, want %d", change.name, numLabels, i + 1) }
+	}
+
+	// vertical align changes reuse the same label
+	numLabels, _ := labels.Stats()
+	for _, vertAlign := range VertAligns {
+		renderer.SetAlign(vertAlign)
+		diff := compare(labels, renderer, Text, rgba)
+		if diff != "" { t.Errorf("vert align %s: %s", vertAlign, diff) }
+	}
+	newNumLabels, _ := labels.Stats()
+	if newNumLabels != numLabels { t.Errorf("vert aligns: got %d labels, want %d", newNumLabels, numLabels) }
+}
+
+// Draws a sequence of labels on a cache that can only hold three of
+// them, and verifies that labels are rendered or reused following the
+// least recently used order, that the pixel limit is respected and
+// that labels bigger than the cache are drawn directly.
+func TestEviction(t *testing.T) {
+	renderer := ptxt.NewRenderer()
+	renderer.SetStrand(strand.New(jammy.Font()))
+	renderer.SetAlign(ptxt.Top | ptxt.Left)
+	rgba := Colors[0]
+	var numPasses int
+	renderer.Advanced().SetDrawPassListener(func(*ptxt.Renderer, ptxt.DrawPass) { numPasses += 1 })
+
+	width, height := renderer.Measure("LABEL 0")
+	maxPixels := width*height*3
+	labels := New(maxPixels)
+
+	steps := []struct{ text string; wantRender bool }{
+		{ "LABEL 0", true }, { "LABEL 1", true }, { "LABEL 2", true },
+		{ "LABEL 0", false }, // LRU order: 1, 2, 0
+		{ "LABEL 3", true  }, // evicts 1
+		{ "LABEL 2", false }, // LRU order: 0, 3, 2
+		{ "LABEL 1", true  }, // evicts 0
+		{ "LABEL 0", true  }, // evicts 3
+		{ "LABEL 2", false },
+		{ "LABEL 1", false },
+	}
+	for i, step := range steps {
+		numPasses = 0
+		diff := compare(labels, renderer, step.text, rgba)
+		if diff != "" { t.Errorf("step %d %q: %s", i, step.text, diff) }
+		rendered := (numPasses > 1) // compare() always draws once directly
+		if rendered != step.wantRender {
+			t.Errorf("step %d %q: got rendered = %t, want %t", i, step.text, rendered, step.wantRender)
+		}
+		numLabels, numPixels := labels.Stats()
+		if numLabels > 3 || numPixels > maxPixels {
+			t.Errorf(
+				"step %d %q: got %d labels with %d pixels, want <= 3 labels with <= %d pixels",
+				i, step.text, numLabels, numPixels, maxPixels,
+			)
+		}
+	}
+
+	// labels that don't fit are drawn directly
+	const LongText = "THIS LABEL IS TOO LONG FOR THE CACHE"
+	labels.Clear()
+	diff := compare(labels, renderer, LongText, rgba)
+	if diff != "" { t.Errorf("oversized label: %s", diff) }
+	numLabels, numPixels := labels.Stats()
+	if numLabels != 0 || numPixels != 0 {
+		t.Errorf("oversized label: got %d labels with %d pixels, want none", numLabels, numPixels)
+	}
+}
+
+// ---- helpers ----
+
+// Draws the text at the center of two canvases, one directly and
+// one through the cache, and returns a description of the first
+// mismatch, if any.
+func compare(labels *Cache, renderer *ptxt.Renderer, text string, rgba color.RGBA) string {
+	direct := newCanvas()
+	renderer.SetColor(rgba)
+	renderer.Draw(direct, text, CanvasWidth/2, CanvasHeight/2)
+	cached := newCanvas()
+	labels.Draw(cached, renderer, text, rgba, CanvasWidth/2, CanvasHeight/2)
+
+	for y := 0; y < CanvasHeight; y++ {
+		for x := 0; x < CanvasWidth; x++ {
+			want, got := direct.RGBAAt(x, y), cached.RGBAAt(x, y)
+			if !similar(want, got) {
+				return fmt.Sprintf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	return ""
+}
+
+func newCanvas() *image.RGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, CanvasWidth, CanvasHeight))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(Background), image.Point{}, draw.Src)
+	return canvas
+}
+
+// Blending translucent text twice can't be exact, so we allow
+// small rounding differences.
+func similar(a, b color.RGBA) bool {
+	return absDiff(a.R, b.R) <= 1 && absDiff(a.G, b.G) <= 1 &&
+		absDiff(a.B, b.B) <= 1 && absDiff(a.A, b.A) <= 1
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b { return a - b }
+	return b - a
+}
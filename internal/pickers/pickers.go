@@ -0,0 +1,170 @@
+// Package pickers provides simple but complete implementations of
+// strand.GlyphPicker for the examples in this repository:
+//  - [AlternatePicker], to alternate variants on repeated characters.
+//  - [WordPicker], to choose a random variant for each word.
+//  - [BlinkPicker], to switch between variants periodically.
+//
+// Glyph pickers only matter for fonts that map code points to multiple
+// glyphs. All pickers here are deterministic: given the same text,
+// configuration and ticks, they always pick the same glyphs.
+//
+// As an internal package, it can't be imported from outside this
+// repository: copy the pickers you need into your own project and
+// adapt them there. See cpu/pickers for an example.
+package pickers
+
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ggfnt"
+
+// Alternates glyph variants on each occurrence of the same code point,
+// which avoids having identical glyphs repeated in close proximity.
+// Occurrences are counted from the start of each measure or draw pass.
+type AlternatePicker struct {
+	occurrences map[rune]uint8
+}
+
+// Implements strand.GlyphPicker.
+func (self *AlternatePicker) Pick(codePoint rune, groupSize uint8, _ ggfnt.AnimationFlags, _ int) uint8 {
+	if self.occurrences == nil { self.occurrences = make(map[rune]uint8, 16) }
+	count := self.occurrences[codePoint]
+	self.occurrences[codePoint] = count + 1
+	return count % groupSize
+}
+
+// Implements strand.GlyphPicker.
+func (self *AlternatePicker) NotifyAddedGlyph(ggfnt.GlyphIndex, rune, uint8, ggfnt.AnimationFlags) {}
+
+// Implements strand.GlyphPicker.
+func (self *AlternatePicker) NotifyPass(_ strand.GlyphPickerPass, start bool) {
+	if start { clear(self.occurrences) }
+}
+
+// Chooses a random variant for each word, and uses it for all the
+// glyphs in the word. The randomness is derived from the seed and
+// the word position within the text.
+//
+// Notice that this picks among the glyph variants of each code point,
+// not among font settings: settings are applied by the strand when
+// mapping code points to glyph groups, before any picker is invoked,
+// so pickers can't change them. To randomize settings per word, draw
+// each word separately after calling strand.SetSetting().
+//
+// Since glyph pickers are not notified about the code points of
+// added glyphs, word separators have to be given as glyph indices.
+// Use [NewWordPicker]() to configure the separators automatically.
+type WordPicker struct {
+	Seed uint64
+	Separators []ggfnt.GlyphIndex
+	wordIndex uint64
+	afterSeparator bool
+}
+
+// Creates a [WordPicker] using the font's glyphs for spaces as separators.
+// Line breaks are always considered separators.
+func NewWordPicker(font *ggfnt.Font, seed uint64) *WordPicker {
+	picker := &WordPicker{ Seed: seed }
+	picker.Separators = append(picker.Separators, ggfnt.GlyphNewLine)
+	settings := make([]uint8, font.Settings().Count()) // default settings
+	for _, codePoint := range []rune{' ', '\t', '\u00A0'} {
+		group, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { continue }
+		for i := uint8(0); i < group.Size(); i++ {
+			picker.Separators = append(picker.Separators, group.Select(i))
+		}
+	}
+	return picker
+}
+
+// Implements strand.GlyphPicker.
+func (self *WordPicker) Pick(_ rune, groupSize uint8, _ ggfnt.AnimationFlags, _ int) uint8 {
+	if self.afterSeparator {
+		self.wordIndex += 1
+		self.afterSeparator = false
+	}
+	return uint8(splitMix64(self.Seed ^ self.wordIndex) % uint64(groupSize))
+}
+
+// Implements strand.GlyphPicker.
+func (self *WordPicker) NotifyAddedGlyph(glyphIndex ggfnt.GlyphIndex, _ rune, _ uint8, _ ggfnt.AnimationFlags) {
+	for _, separator := range self.Separators {
+		if glyphIndex == separator {
+			self.afterSeparator = true
+			return
+		}
+	}
+}
+
+// Implements strand.GlyphPicker.
+func (self *WordPicker) NotifyPass(_ strand.GlyphPickerPass, start bool) {
+	if start {
+		self.wordIndex = 0
+		self.afterSeparator = false
+	}
+}
+
+// Switches between the first and last variants of each glyph group
+// periodically. Instead of using time.Now(), the picker must be advanced
+// explicitly with [BlinkPicker.Tick](), typically from your game's
+// Update() method. This makes the results reproducible.
+type BlinkPicker struct {
+	Period int // number of ticks each state lasts, at least 1
+	tick int
+}
+
+// Advances the picker by a single tick.
+func (self *BlinkPicker) Tick() { self.tick += 1 }
+
+// Sets the current tick directly.
+func (self *BlinkPicker) SetTick(tick int) { self.tick = tick }
+
+// Implements strand.GlyphPicker.
+func (self *BlinkPicker) Pick(_ rune, groupSize uint8, _ ggfnt.AnimationFlags, _ int) uint8 {
+	if (self.tick/max(self.Period, 1)) % 2 == 0 { return 0 }
+	return groupSize - 1
+}
+
+// Implements strand.GlyphPicker.
+func (self *BlinkPicker) NotifyAddedGlyph(ggfnt.GlyphIndex, rune, uint8, ggfnt.AnimationFlags) {}
+
+// Implements strand.GlyphPicker.
+func (self *BlinkPicker) NotifyPass(strand.GlyphPickerPass, bool) {}
+
+// Feeds the text to the picker the same way ptxt does while mapping
+// code points to glyphs, with the font's default settings, and returns
+// a string with the variant chosen for each code point ('-' for code
+// points with a single glyph, line breaks are kept). Useful to test
+// or debug pickers without drawing. Panics if the font doesn't map
+// any of the code points.
+func TracePicks(picker strand.GlyphPicker, font *ggfnt.Font, text string) string {
+	settings := make([]uint8, font.Settings().Count())
+	picks := make([]byte, 0, len(text))
+	picker.NotifyPass(strand.DrawPass, true)
+	for _, codePoint := range text {
+		if codePoint == '\n' {
+			picks = append(picks, '\n')
+			picker.NotifyAddedGlyph(ggfnt.GlyphNewLine, strand.NoCodePoint, 0, 0)
+			continue
+		}
+		group, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { panic("missing glyph for '" + string(codePoint) + "'") }
+		var choice uint8
+		if group.Size() > 1 {
+			choice = picker.Pick(codePoint, group.Size(), group.AnimationFlags(), 0)
+			picks = append(picks, '0' + choice)
+		} else {
+			picks = append(picks, '-')
+		}
+		picker.NotifyAddedGlyph(group.Select(choice), strand.NoCodePoint, 0, 0)
+	}
+	picker.NotifyPass(strand.DrawPass, false)
+	return string(picks)
+}
+
+// ---- helpers ----
+
+func splitMix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30))*0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27))*0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
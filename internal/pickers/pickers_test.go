@@ -0,0 +1,145 @@
+//go:build cputext
+
+package pickers
+
+import "fmt"
+import "image"
+import "testing"
+
+import "github.com/tinne26/ptxt"
+import "github.com/tinne26/ptxt/core"
+import "github.com/tinne26/ptxt/strand"
+import "github.com/tinne26/ggfnt"
+import "github.com/tinne26/ggfnt-fonts/jumpy"
+
+// jumpy maps each uppercase letter to two glyph variants
+const SampleText = "AAAA BBBB BANANA SAYS HELLO"
+
+func TestAlternatePicker(t *testing.T) {
+	var picker AlternatePicker
+	font := jumpy.Font()
+	for pass := 0; pass < 2; pass++ { // occurrences are reset on each pass
+		expectPicks(t, fmt.Sprintf("pass %d", pass), TracePicks(&picker, font, SampleText), "0101-0101-000110-0101-00010")
+	}
+	expectPicks(t, "new text", TracePicks(&picker, font, "ZZZ"), "010")
+}
+
+func TestWordPicker(t *testing.T) {
+	font := jumpy.Font()
+	for _, c := range []struct{ seed uint64; variants string }{
+		{ 0xDECAF, "0000-1111-000000-0000-11111" },
+		{ 0, "1111-1111-000000-1111-00000" },
+		{ 42, "1111-0000-000000-1111-11111" },
+	} {
+		picker := NewWordPicker(font, c.seed)
+		expectPicks(t, fmt.Sprintf("seed %#x", c.seed), TracePicks(picker, font, SampleText), c.variants)
+		expectPicks(t, fmt.Sprintf("seed %#x again", c.seed), TracePicks(picker, font, SampleText), c.variants)
+	}
+
+	// line breaks also separate words
+	picker := NewWordPicker(font, 0xDECAF)
+	expectPicks(t, "line break", TracePicks(picker, font, "AAAA\nBBBB"), "0000\n1111")
+}
+
+func TestBlinkPicker(t *testing.T) {
+	font := jumpy.Font()
+	picker := BlinkPicker{ Period: 3 }
+	states := "00011100"
+	for tick, state := range states {
+		picker.SetTick(tick)
+		want := string([]rune{state, state, '-', state})
+		expectPicks(t, fmt.Sprintf("tick %d", tick), TracePicks(&picker, font, "AB C"), want)
+	}
+
+	// Tick() and zero periods
+	picker = BlinkPicker{}
+	for tick := 0; tick < 4; tick++ {
+		want := string(rune('0' + tick % 2))
+		expectPicks(t, fmt.Sprintf("zero period, tick %d", tick), TracePicks(&picker, font, "A"), want)
+		picker.Tick()
+	}
+}
+
+// Checks that ptxt invokes the pickers the same way as TracePicks().
+func TestDrawnGlyphs(t *testing.T) {
+	font := jumpy.Font()
+	for _, c := range []struct{ name string; picker strand.GlyphPicker; variants string }{
+		{ "alternate", &AlternatePicker{}, "0101-0101-000110-0101-00010" },
+		{ "word", NewWordPicker(font, 0xDECAF), "0000-1111-000000-0000-11111" },
+		{ "blink", &BlinkPicker{ Period: 1, tick: 1 }, "1111-1111-111111-1111-11111" },
+	} {
+		fontStrand, err := ptxt.NewStrand(font)
+		if err != nil { t.Fatal(err) }
+		fontStrand.GlyphPickers().Add(c.picker)
+		renderer := ptxt.NewRenderer()
+		renderer.SetStrand(fontStrand)
+		var drawn []ggfnt.GlyphIndex
+		renderer.Advanced().SetDrawFunc(func(_ core.Target, glyphIndex ggfnt.GlyphIndex, _ ptxt.MaskDrawParameters) {
+			drawn = append(drawn, glyphIndex)
+		})
+		canvas := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		renderer.Draw(canvas, SampleText, 0, 0)
+
+		want := withoutSpaces(font, glyphsFor(t, font, SampleText, c.variants))
+		expectGlyphs(t, c.name, withoutSpaces(font, drawn), want)
+	}
+}
+
+// ---- helpers ----
+
+// Returns the glyph indices for the given text and variants, with
+// one variant per code point ('-' for single glyph code points).
+func glyphsFor(t *testing.T, font *ggfnt.Font, text string, variants string) []ggfnt.GlyphIndex {
+	t.Helper()
+	codePoints := []rune(text)
+	if len(codePoints) != len(variants) { t.Fatalf("%q and %q lengths don't match", text, variants) }
+	settings := make([]uint8, font.Settings().Count())
+	glyphs := make([]ggfnt.GlyphIndex, 0, len(codePoints))
+	for i, codePoint := range codePoints {
+		if codePoint == '\n' {
+			glyphs = append(glyphs, ggfnt.GlyphNewLine)
+			continue
+		}
+		group, found := font.Mapping().Utf8(codePoint, settings)
+		if !found { t.Fatalf("missing glyph for '%c'", codePoint) }
+		variant := variants[i]
+		switch {
+		case variant == '-' && group.Size() == 1:
+			glyphs = append(glyphs, group.Select(0))
+		case variant >= '0' && variant - '0' < group.Size():
+			glyphs = append(glyphs, group.Select(variant - '0'))
+		default:
+			t.Fatalf("invalid variant '%c' for '%c' (%d glyphs)", variant, codePoint, group.Size())
+		}
+	}
+	return glyphs
+}
+
+func expectPicks(t *testing.T, name string, got, want string) {
+	t.Helper()
+	if got != want { t.Errorf("%s: got picks %q, want %q", name, got, want) }
+}
+
+func expectGlyphs(t *testing.T, name string, got, want []ggfnt.GlyphIndex) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d glyphs, want %d", name, len(got), len(want))
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: glyph #%d is %d, want %d (got %v)", name, i, got[i], want[i], got)
+			return
+		}
+	}
+}
+
+func withoutSpaces(font *ggfnt.Font, glyphs []ggfnt.GlyphIndex) []ggfnt.GlyphIndex {
+	settings := make([]uint8, font.Settings().Count())
+	space, _ := font.Mapping().Utf8(' ', settings)
+	filtered := make([]ggfnt.GlyphIndex, 0, len(glyphs))
+	for _, glyphIndex := range glyphs {
+		if glyphIndex != space.Select(0) { filtered = append(filtered, glyphIndex) }
+	}
+	return filtered
+}
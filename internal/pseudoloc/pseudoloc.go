@@ -0,0 +1,173 @@
+// Package pseudoloc implements a pseudo-localization transform, used
+// to stress text layouts before real translations are available.
+//
+// Pseudo-localized text is still readable, but it's longer than the
+// original (translations are often 30% longer than English, or even
+// more for short strings), uses accented letters that might collide
+// with the line above or below, and is enclosed in brackets, making
+// truncated or concatenated strings easy to spot:
+//	"Click here to continue" => "[Çlíçk héèrêë tóò çôñtìîñúùéè]"
+//
+// Only characters that the font can map are ever introduced, so the
+// result can be passed directly to ptxt renderers, which panic on
+// missing glyphs. Code points are checked with the default settings.
+//
+// Usage:
+//	pseudo := pseudoloc.New(strand.Font())
+//	...
+//	// on hotfont reloads
+//	pseudo.SetFont(loader.Strand().Font())
+//	...
+//	renderer.DrawWithWrap(canvas, pseudo.Transform(text), x, y, width)
+package pseudoloc
+
+import "math"
+import "strings"
+
+import "github.com/tinne26/ggfnt"
+
+// Default length expansion, as a fraction of the original length.
+const DefaultExpansion = 0.3
+
+// Candidate replacements for each letter, in order of preference.
+// Consecutive occurrences of the same letter cycle through the
+// candidates available in the font.
+var accents = map[rune][]rune{
+	'a': []rune("áàâäãå"), 'A': []rune("ÁÀÂÄÃÅ"),
+	'c': []rune("çć"),     'C': []rune("ÇĆ"),
+	'e': []rune("éèêë"),   'E': []rune("ÉÈÊË"),
+	'i': []rune("íìîï"),   'I': []rune("ÍÌÎÏ"),
+	'n': []rune("ñń"),     'N': []rune("ÑŃ"),
+	'o': []rune("óòôöõø"), 'O': []rune("ÓÒÔÖÕØ"),
+	's': []rune("šś"),     'S': []rune("ŠŚ"),
+	'u': []rune("úùûü"),   'U': []rune("ÚÙÛÜ"),
+	'y': []rune("ýÿ"),     'Y': []rune("Ý"),
+	'z': []rune("žź"),     'Z': []rune("ŽŹ"),
+}
+
+// Bracket pairs, in order of preference. The first pair fully
+// available in the font is used.
+var brackets = [][2]rune{ {'[', ']'}, {'(', ')'}, {'<', '>'}, {'|', '|'} }
+
+// Filler characters for text without vowels to expand, in order of
+// preference.
+var fillers = []rune{ '~', '.', '-', '_' }
+
+// A pseudo-localization transform. The configuration fields can be
+// modified at any time.
+type Transformer struct {
+	Expansion float64 // fraction of extra characters, like 0.3 for +30%
+	Accents bool // replace letters with accented variants
+	Brackets bool // enclose the text in brackets
+
+	font *ggfnt.Font
+	settings []uint8
+	mapped map[rune]bool // cached font.Mapping().Utf8() results
+}
+
+// Creates a transformer for the given font, with [DefaultExpansion],
+// accents and brackets enabled.
+func New(font *ggfnt.Font) *Transformer {
+	transformer := &Transformer{
+		Expansion: DefaultExpansion,
+		Accents: true,
+		Brackets: true,
+	}
+	transformer.SetFont(font)
+	return transformer
+}
+
+// Sets the font used to check which characters can be introduced.
+// Must be called again if the font is reloaded or replaced.
+func (self *Transformer) SetFont(font *ggfnt.Font) {
+	self.font = font
+	self.settings = make([]uint8, font.Settings().Count())
+	self.mapped = make(map[rune]bool)
+}
+
+// Returns the pseudo-localized text. Line breaks are preserved, and
+// each line is expanded independently, so paragraphs keep their
+// structure.
+func (self *Transformer) Transform(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = self.expand(line)
+	}
+	text = strings.Join(lines, "\n")
+	if self.Accents { text = self.accentuate(text) }
+	if self.Brackets {
+		for _, pair := range brackets {
+			if self.has(pair[0]) && self.has(pair[1]) {
+				text = string(pair[0]) + text + string(pair[1])
+				break
+			}
+		}
+	}
+	return text
+}
+
+// Duplicates vowels evenly across the line until its length grows by
+// the configured expansion. If the line has no vowels, fillers are
+// appended instead.
+func (self *Transformer) expand(line string) string {
+	runes := []rune(line)
+	var vowels []int
+	var length int
+	for i, codePoint := range runes {
+		if codePoint == ' ' { continue }
+		length += 1
+		if isVowel(codePoint) { vowels = append(vowels, i) }
+	}
+	extra := int(math.Round(float64(length)*max(self.Expansion, 0)))
+	if extra == 0 { return line }
+
+	var builder strings.Builder
+	if len(vowels) == 0 {
+		builder.WriteString(line)
+		for _, filler := range fillers {
+			if !self.has(filler) { continue }
+			if line != "" && self.has(' ') { builder.WriteRune(' ') }
+			builder.WriteString(strings.Repeat(string(filler), extra))
+			break
+		}
+		return builder.String()
+	}
+
+	var vowel int // index into vowels
+	for i, codePoint := range runes {
+		builder.WriteRune(codePoint)
+		if vowel < len(vowels) && vowels[vowel] == i {
+			copies := (vowel + 1)*extra/len(vowels) - vowel*extra/len(vowels)
+			for ; copies > 0; copies-- { builder.WriteRune(codePoint) }
+			vowel += 1
+		}
+	}
+	return builder.String()
+}
+
+func (self *Transformer) accentuate(text string) string {
+	uses := make(map[rune]int)
+	return strings.Map(func(codePoint rune) rune {
+		var available []rune
+		for _, candidate := range accents[codePoint] {
+			if self.has(candidate) { available = append(available, candidate) }
+		}
+		if len(available) == 0 { return codePoint }
+		replacement := available[uses[codePoint] % len(available)]
+		uses[codePoint] += 1
+		return replacement
+	}, text)
+}
+
+func (self *Transformer) has(codePoint rune) bool {
+	mapped, seen := self.mapped[codePoint]
+	if !seen {
+		_, mapped = self.font.Mapping().Utf8(codePoint, self.settings)
+		self.mapped[codePoint] = mapped
+	}
+	return mapped
+}
+
+func isVowel(codePoint rune) bool {
+	return strings.ContainsRune("aeiouAEIOU", codePoint)
+}
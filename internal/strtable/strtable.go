@@ -0,0 +1,210 @@
+// Package strtable reads localized string tables in JSON, CSV and
+// gettext PO formats, as used by the localization tools.
+//
+// Usage:
+//	strs, err := strtable.Read("strings.es.json")
+//	if err != nil { panic(err) }
+//	for _, str := range strs {
+//		fmt.Printf("%s %s: %s\n", str.Locale, str.Key, str.Text)
+//	}
+package strtable
+
+import "io"
+import "os"
+import "fmt"
+import "sort"
+import "bufio"
+import "strconv"
+import "strings"
+import "encoding/csv"
+import "encoding/json"
+import "path/filepath"
+
+// A translated string from a string table.
+type String struct {
+	Locale string
+	Key string
+	Text string
+}
+
+// Reads a string table, based on the file extension:
+//  - .json: one locale per file, named after the file ("es.json" or
+//    "strings.es.json" are both "es"). Nested objects and arrays are
+//    flattened into dotted keys ("menu.items.0").
+//  - .csv: the first column has the keys, and each other column is a
+//    locale, named in the header row ("key,en,es,fr").
+//  - .po: gettext catalogs. The locale is taken from the "Language"
+//    header, or from the file name if missing. Untranslated entries
+//    (empty msgstr) are skipped.
+func Read(path string) ([]String, error) {
+	file, err := os.Open(path)
+	if err != nil { return nil, err }
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return readJSON(file, localeFromPath(path))
+	case ".csv":
+		return readCSV(file)
+	case ".po":
+		return readPO(file, localeFromPath(path))
+	default:
+		return nil, fmt.Errorf("%s: unsupported string table format (expected .json, .csv or .po)", path)
+	}
+}
+
+func localeFromPath(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if index := strings.LastIndexByte(name, '.'); index != -1 {
+		name = name[index + 1 : ]
+	}
+	return name
+}
+
+// ---- json ----
+
+func readJSON(r io.Reader, locale string) ([]String, error) {
+	var root any
+	decoder := json.NewDecoder(r)
+	err := decoder.Decode(&root)
+	if err != nil { return nil, err }
+
+	var strs []String
+	var flatten func(key string, value any)
+	flatten = func(key string, value any) {
+		switch value := value.(type) {
+		case string:
+			strs = append(strs, String{ locale, key, value })
+		case map[string]any:
+			keys := make([]string, 0, len(value))
+			for childKey := range value { keys = append(keys, childKey) }
+			sort.Strings(keys)
+			for _, childKey := range keys {
+				flatten(joinKey(key, childKey), value[childKey])
+			}
+		case []any:
+			for i, child := range value {
+				flatten(joinKey(key, strconv.Itoa(i)), child)
+			}
+		} // numbers, bools and nulls are not translatable text
+	}
+	flatten("", root)
+	return strs, nil
+}
+
+func joinKey(parent, child string) string {
+	if parent == "" { return child }
+	return parent + "." + child
+}
+
+// ---- csv ----
+
+func readCSV(r io.Reader) ([]String, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // allow trailing empty cells to be omitted
+	header, err := reader.Read()
+	if err != nil { return nil, fmt.Errorf("reading CSV header: %w", err) }
+	if len(header) < 2 {
+		return nil, fmt.Errorf("CSV header must have a key column and at least one locale column")
+	}
+	header[0] = strings.TrimPrefix(header[0], "\uFEFF") // BOM
+
+	var strs []String
+	for {
+		record, err := reader.Read()
+		if err == io.EOF { break }
+		if err != nil { return nil, err }
+		key := record[0]
+		if key == "" { continue }
+		for column := 1; column < len(record) && column < len(header); column++ {
+			if record[column] == "" { continue } // untranslated
+			strs = append(strs, String{ strings.TrimSpace(header[column]), key, record[column] })
+		}
+	}
+	return strs, nil
+}
+
+// ---- po ----
+
+// Minimal gettext PO parser. Supports msgctxt, msgid, msgid_plural,
+// msgstr and msgstr[n], with multiline strings. Obsolete entries (#~)
+// and comments are ignored.
+func readPO(r io.Reader, fallbackLocale string) ([]String, error) {
+	type poEntry struct {
+		context, id string
+		translations []string
+	}
+	var entries []poEntry
+	var entry poEntry
+	var target *string // string being continued on the next lines
+	var flush = func() {
+		if entry.id != "" || len(entry.translations) > 0 {
+			entries = append(entries, entry)
+		}
+		entry, target = poEntry{}, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1 << 20)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") { continue }
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		if strings.HasPrefix(line, `"`) {
+			keyword, rest = "", line
+		}
+		value, err := strconv.Unquote(strings.TrimSpace(rest))
+		if err != nil { return nil, fmt.Errorf("line %d: invalid string %s", lineNum, rest) }
+		switch {
+		case keyword == "":
+			if target == nil { return nil, fmt.Errorf("line %d: unexpected string", lineNum) }
+			*target += value
+		case keyword == "msgctxt":
+			flush()
+			entry.context = value
+			target = &entry.context
+		case keyword == "msgid":
+			if entry.id != "" || len(entry.translations) > 0 { flush() }
+			entry.id = value
+			target = &entry.id
+		case keyword == "msgid_plural":
+			target = nil // plural source text is not checked
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			entry.translations = append(entry.translations, value)
+			target = &entry.translations[len(entry.translations) - 1]
+		default:
+			return nil, fmt.Errorf("line %d: unexpected keyword %q", lineNum, keyword)
+		}
+	}
+	flush()
+	err := scanner.Err()
+	if err != nil { return nil, err }
+
+	// header entry (empty msgid) can define the language
+	locale := fallbackLocale
+	var strs []String
+	for _, entry := range entries {
+		if entry.id == "" && entry.context == "" {
+			for _, line := range strings.Split(strings.Join(entry.translations, ""), "\n") {
+				name, value, found := strings.Cut(line, ":")
+				if found && strings.TrimSpace(name) == "Language" && strings.TrimSpace(value) != "" {
+					locale = strings.TrimSpace(value)
+				}
+			}
+			continue
+		}
+		key := entry.id
+		if entry.context != "" { key = entry.context + "|" + entry.id }
+		for i, translation := range entry.translations {
+			if translation == "" { continue } // untranslated
+			pluralKey := key
+			if len(entry.translations) > 1 { pluralKey += "[" + strconv.Itoa(i) + "]" }
+			strs = append(strs, String{ "", pluralKey, translation })
+		}
+	}
+	for i := range strs {
+		strs[i].Locale = locale
+	}
+	return strs, nil
+}